@@ -1,6 +1,7 @@
 package hubspot
 
 import (
+	"context"
 	"fmt"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
@@ -24,6 +25,10 @@ type AccessToken struct {
 
 // InspectAccessToken returns information about an access token
 func (service *Service) InspectAccessToken(accessToken *string) (*AccessToken, *errortools.Error) {
+	return service.InspectAccessTokenWithContext(context.Background(), accessToken)
+}
+
+func (service *Service) InspectAccessTokenWithContext(ctx context.Context, accessToken *string) (*AccessToken, *errortools.Error) {
 	var a AccessToken
 
 	if accessToken == nil {
@@ -48,7 +53,7 @@ func (service *Service) InspectAccessToken(accessToken *string) (*AccessToken, *
 		ResponseModel: &a,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
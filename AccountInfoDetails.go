@@ -1,6 +1,7 @@
 package hubspot
 
 import (
+	"context"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
 	"net/http"
@@ -11,6 +12,10 @@ type AccountInfoDetails struct {
 }
 
 func (service *Service) GetAccountInfoDetails() (*AccountInfoDetails, *errortools.Error) {
+	return service.GetAccountInfoDetailsWithContext(context.Background())
+}
+
+func (service *Service) GetAccountInfoDetailsWithContext(ctx context.Context) (*AccountInfoDetails, *errortools.Error) {
 	var accountInfoDetails AccountInfoDetails
 
 	requestConfig := go_http.RequestConfig{
@@ -19,7 +24,7 @@ func (service *Service) GetAccountInfoDetails() (*AccountInfoDetails, *errortool
 		ResponseModel: &accountInfoDetails,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
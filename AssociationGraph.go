@@ -0,0 +1,171 @@
+package hubspot
+
+import (
+	"context"
+	"slices"
+	"strconv"
+
+	errortools "github.com/leapforce-libraries/go_errortools"
+)
+
+// WalkAssociationsConfig configures a walk of the associations of seed objects.
+// The walk follows the associations of every object to the object types listed in Follow for its object type, up to Depth associations away from a seed.
+type WalkAssociationsConfig struct {
+	SeedObjectType ObjectType
+	SeedIds        []string
+	Follow         map[ObjectType][]ObjectType // e.g. companies to contacts, deals and tickets, and those to emails, calls and meetings
+	Depth          int
+	Properties     map[ObjectType][]string // properties to read of the objects of an object type, defaults to the default properties
+}
+
+// AssociationGraph holds the objects reached by WalkAssociations, every object once, and the associations followed to reach them
+type AssociationGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+type GraphNode struct {
+	ObjectType ObjectType `json:"objectType"`
+	Object     Object     `json:"object"`
+	Depth      int        `json:"depth"` // number of associations away from a seed
+}
+
+type GraphEdge struct {
+	FromObjectType ObjectType         `json:"fromObjectType"`
+	FromId         string             `json:"fromId"`
+	ToObjectType   ObjectType         `json:"toObjectType"`
+	ToId           string             `json:"toId"`
+	Types          []AssociationLabel `json:"types"`
+}
+
+type graphNodeKey struct {
+	objectType ObjectType
+	id         string
+}
+
+// WalkAssociations returns the graph of the objects reachable from config.SeedIds, reading associations and objects with batch requests
+func (service *Service) WalkAssociations(config *WalkAssociationsConfig) (*AssociationGraph, *errortools.Error) {
+	return service.WalkAssociationsWithContext(context.Background(), config)
+}
+
+func (service *Service) WalkAssociationsWithContext(ctx context.Context, config *WalkAssociationsConfig) (*AssociationGraph, *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
+	}
+	if config.SeedObjectType == "" {
+		return nil, errortools.ErrorMessage("SeedObjectType must not be empty")
+	}
+
+	graph := AssociationGraph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+
+	// index of a node in graph.Nodes
+	nodes := make(map[graphNodeKey]int)
+
+	addNode := func(objectType ObjectType, id string, depth int) bool {
+		key := graphNodeKey{objectType, id}
+		if _, ok := nodes[key]; ok {
+			return false
+		}
+		nodes[key] = len(graph.Nodes)
+		graph.Nodes = append(graph.Nodes, GraphNode{ObjectType: objectType, Object: Object{Id: id}, Depth: depth})
+		return true
+	}
+
+	frontier := make(map[ObjectType][]string)
+	for _, id := range config.SeedIds {
+		if addNode(config.SeedObjectType, id, 0) {
+			frontier[config.SeedObjectType] = append(frontier[config.SeedObjectType], id)
+		}
+	}
+
+	for depth := 1; depth <= config.Depth && len(frontier) > 0; depth++ {
+		next := make(map[ObjectType][]string)
+
+		// in a fixed order, so the graph is the same every walk
+		fromObjectTypes := make([]ObjectType, 0, len(frontier))
+		for fromObjectType := range frontier {
+			fromObjectTypes = append(fromObjectTypes, fromObjectType)
+		}
+		slices.Sort(fromObjectTypes)
+
+		for _, fromObjectType := range fromObjectTypes {
+			inputs := []BatchGetAssociationsInput{}
+			for _, id := range frontier[fromObjectType] {
+				inputs = append(inputs, BatchGetAssociationsInput{Id: id})
+			}
+
+			for _, toObjectType := range config.Follow[fromObjectType] {
+				associationsV4Set, e := service.BatchGetAssociationsWithContext(ctx, &BatchGetAssociationsConfig{
+					FromObjectType: string(fromObjectType),
+					ToObjectType:   string(toObjectType),
+					Inputs:         inputs,
+				})
+				if e != nil {
+					return nil, e
+				}
+
+				for _, associationV4 := range associationsV4Set.Results {
+					for _, to := range associationV4.To {
+						toId := strconv.FormatInt(to.ToObjectId, 10)
+
+						graph.Edges = append(graph.Edges, GraphEdge{
+							FromObjectType: fromObjectType,
+							FromId:         associationV4.From.Id,
+							ToObjectType:   toObjectType,
+							ToId:           toId,
+							Types:          to.AssociationTypes,
+						})
+
+						if addNode(toObjectType, toId, depth) {
+							next[toObjectType] = append(next[toObjectType], toId)
+						}
+					}
+				}
+			}
+		}
+
+		frontier = next
+	}
+
+	e := service.readGraphNodes(ctx, config, &graph, nodes)
+	if e != nil {
+		return nil, e
+	}
+
+	return &graph, nil
+}
+
+// readGraphNodes reads the objects of the nodes of graph, objects that cannot be read keep only their id
+func (service *Service) readGraphNodes(ctx context.Context, config *WalkAssociationsConfig, graph *AssociationGraph, nodes map[graphNodeKey]int) *errortools.Error {
+	inputs := make(map[ObjectType][]BatchGetObjectsInput)
+	var objectTypes []ObjectType
+
+	for _, node := range graph.Nodes {
+		if _, ok := inputs[node.ObjectType]; !ok {
+			objectTypes = append(objectTypes, node.ObjectType)
+		}
+		inputs[node.ObjectType] = append(inputs[node.ObjectType], BatchGetObjectsInput{Id: node.Object.Id})
+	}
+
+	for _, objectType := range objectTypes {
+		result, e := batchGetObjects[Object](ctx, service, objectType, &BatchGetObjectsConfig{
+			Inputs:     inputs[objectType],
+			Properties: config.Properties[objectType],
+		})
+		if e != nil {
+			return e
+		}
+
+		for _, object := range result.Successes {
+			if index, ok := nodes[graphNodeKey{objectType, object.Id}]; ok {
+				graph.Nodes[index].Object = object
+			}
+		}
+
+		if len(result.Failures) > 0 {
+			service.logger.Warn("objects of association graph not read", "objectType", objectType, "failures", len(result.Failures))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package hubspot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// graphServer answers the batch reads of associations and objects of a graph of objects, objects are named after their type and id
+type graphServer struct {
+	edges map[string][]string // associated objects of an object, keyed and valued by type/id
+
+	mutex    sync.Mutex
+	requests []string
+}
+
+func (server *graphServer) handle(w http.ResponseWriter, r *http.Request) {
+	server.mutex.Lock()
+	server.requests = append(server.requests, r.URL.Path)
+	server.mutex.Unlock()
+
+	var body struct {
+		Inputs []BatchGetObjectsInput `json:"inputs"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+
+	var fromObjectType, toObjectType, objectType string
+	if _, err := fmt.Sscanf(strings.ReplaceAll(r.URL.Path, "/", " "), " crm v4 associations %s %s batch read", &fromObjectType, &toObjectType); err == nil {
+		results := []string{}
+		for _, input := range body.Inputs {
+			to := []string{}
+			for _, edge := range server.edges[fromObjectType+"/"+input.Id] {
+				objectType, id, _ := strings.Cut(edge, "/")
+				if objectType != toObjectType {
+					continue
+				}
+				label := `{"category":"HUBSPOT_DEFINED","typeId":279,"label":null}`
+				if edge == "contacts/10" {
+					label = `{"category":"USER_DEFINED","typeId":100,"label":"Billing contact"}`
+				}
+				to = append(to, fmt.Sprintf(`{"toObjectId":%s,"associationTypes":[%s]}`, id, label))
+			}
+			if len(to) > 0 {
+				results = append(results, fmt.Sprintf(`{"from":{"id":"%s"},"to":[%s]}`, input.Id, strings.Join(to, ",")))
+			}
+		}
+		writeJson(w, http.StatusOK, fmt.Sprintf(`{"status":"COMPLETE","results":[%s]}`, strings.Join(results, ",")))
+		return
+	}
+
+	if _, err := fmt.Sscanf(strings.ReplaceAll(r.URL.Path, "/", " "), " crm v3 objects %s batch read", &objectType); err == nil {
+		results := []string{}
+		for _, input := range body.Inputs {
+			results = append(results, fmt.Sprintf(`{"id":"%s","properties":{"name":"%s %s"}}`, input.Id, objectType, input.Id))
+		}
+		writeJson(w, http.StatusOK, fmt.Sprintf(`{"status":"COMPLETE","results":[%s]}`, strings.Join(results, ",")))
+		return
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// newGraphServer returns company 1 with contacts 10 and 11 and deal 20, of which contact 10 and deal 20 share email 30
+func newGraphServer() *graphServer {
+	return &graphServer{edges: map[string][]string{
+		"companies/1": {"contacts/10", "contacts/11", "deals/20"},
+		"contacts/10": {"companies/1", "emails/30"},
+		"contacts/11": {"companies/1", "emails/31"},
+		"deals/20":    {"companies/1", "contacts/10", "emails/30"},
+		"emails/30":   {"contacts/10", "deals/20"},
+		"emails/31":   {"contacts/11"},
+	}}
+}
+
+var graphFollow = map[ObjectType][]ObjectType{
+	ObjectTypeCompanies: {ObjectTypeContacts, ObjectTypeDeals},
+	ObjectTypeContacts:  {ObjectTypeEmails},
+	ObjectTypeDeals:     {ObjectTypeContacts, ObjectTypeEmails},
+}
+
+// nodeIds returns the nodes of graph as type/id
+func nodeIds(graph *AssociationGraph) []string {
+	ids := []string{}
+	for _, node := range graph.Nodes {
+		ids = append(ids, fmt.Sprintf("%s/%s", node.ObjectType, node.Object.Id))
+	}
+	slices.Sort(ids)
+
+	return ids
+}
+
+func TestWalkAssociationsDepth(t *testing.T) {
+	tests := []struct {
+		depth int
+		nodes []string
+		edges int
+	}{
+		{0, []string{"companies/1"}, 0},
+		{1, []string{"companies/1", "contacts/10", "contacts/11", "deals/20"}, 3},
+		{2, []string{"companies/1", "contacts/10", "contacts/11", "deals/20", "emails/30", "emails/31"}, 7},
+		{3, []string{"companies/1", "contacts/10", "contacts/11", "deals/20", "emails/30", "emails/31"}, 7},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.depth), func(t *testing.T) {
+			service := newTestService(t, newGraphServer().handle, nil)
+
+			graph, e := service.WalkAssociations(&WalkAssociationsConfig{
+				SeedObjectType: ObjectTypeCompanies,
+				SeedIds:        []string{"1", "1"},
+				Follow:         graphFollow,
+				Depth:          test.depth,
+			})
+			if e != nil {
+				t.Fatal(e.Message())
+			}
+
+			if ids := nodeIds(graph); !slices.Equal(ids, test.nodes) {
+				t.Errorf("got nodes %v, want %v", ids, test.nodes)
+			}
+			if len(graph.Edges) != test.edges {
+				t.Errorf("got %v edges, want %v", len(graph.Edges), test.edges)
+			}
+		})
+	}
+}
+
+func TestWalkAssociationsGraph(t *testing.T) {
+	server := newGraphServer()
+	service := newTestService(t, server.handle, nil)
+
+	graph, e := service.WalkAssociations(&WalkAssociationsConfig{
+		SeedObjectType: ObjectTypeCompanies,
+		SeedIds:        []string{"1"},
+		Follow:         graphFollow,
+		Depth:          2,
+	})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+
+	// contact 10 and email 30 are reached twice but are one node, at the depth they were first reached
+	depths := map[string]int{}
+	for _, node := range graph.Nodes {
+		key := fmt.Sprintf("%s/%s", node.ObjectType, node.Object.Id)
+		if _, ok := depths[key]; ok {
+			t.Errorf("node %s added twice", key)
+		}
+		depths[key] = node.Depth
+		if node.Object.Properties["name"] != fmt.Sprintf("%s %s", node.ObjectType, node.Object.Id) {
+			t.Errorf("got node %+v, want the object read", node)
+		}
+	}
+	if depths["contacts/10"] != 1 || depths["emails/30"] != 2 {
+		t.Errorf("got depths %v, want contact 10 at 1 and email 30 at 2", depths)
+	}
+
+	// the edges keep their labels, also the edge from deal 20 to the contact already reached
+	labels := map[string]string{}
+	for _, edge := range graph.Edges {
+		label := ""
+		if edge.Types[0].Label != nil {
+			label = *edge.Types[0].Label
+		}
+		labels[fmt.Sprintf("%s/%s>%s/%s", edge.FromObjectType, edge.FromId, edge.ToObjectType, edge.ToId)] = label
+	}
+	if labels["companies/1>contacts/10"] != "Billing contact" || labels["deals/20>contacts/10"] != "Billing contact" {
+		t.Errorf("got edges %v, want the edges to contact 10 labelled Billing contact", labels)
+	}
+	if _, ok := labels["contacts/10>companies/1"]; ok {
+		t.Error("expected the association of contacts to companies not to be followed")
+	}
+
+	// every object type is read in one batch
+	reads := 0
+	for _, request := range server.requests {
+		if strings.HasPrefix(request, "/crm/v3/objects/") {
+			reads++
+		}
+	}
+	if reads != 4 {
+		t.Errorf("got %v object reads, want one for each of the 4 object types", reads)
+	}
+
+	b, err := json.Marshal(graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded AssociationGraph
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(nodeIds(&decoded), nodeIds(graph)) || !reflect.DeepEqual(decoded.Edges, graph.Edges) {
+		t.Errorf("got %+v after a JSON round-trip, want %+v", decoded, *graph)
+	}
+	if decoded.Nodes[0].Object.Properties["name"] != graph.Nodes[0].Object.Properties["name"] || decoded.Nodes[0].Depth != graph.Nodes[0].Depth {
+		t.Errorf("got node %+v after a JSON round-trip, want %+v", decoded.Nodes[0], graph.Nodes[0])
+	}
+}
@@ -0,0 +1,343 @@
+package hubspot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	errortools "github.com/leapforce-libraries/go_errortools"
+)
+
+const (
+	AssociationCategoryHubSpotDefined    string = "HUBSPOT_DEFINED"
+	AssociationCategoryUserDefined       string = "USER_DEFINED"
+	AssociationCategoryIntegratorDefined string = "INTEGRATOR_DEFINED"
+)
+
+// HubSpot defined association type ids, the Primary ones label the primary company of an object
+const (
+	AssociationTypeContactToCompanyPrimary int64 = 1
+	AssociationTypeContactToCompany        int64 = 279
+	AssociationTypeContactToContact        int64 = 449
+	AssociationTypeContactToDeal           int64 = 4
+	AssociationTypeContactToTicket         int64 = 15
+	AssociationTypeContactToQuote          int64 = 70
+	AssociationTypeContactToCall           int64 = 193
+	AssociationTypeContactToEmail          int64 = 197
+	AssociationTypeContactToMeeting        int64 = 199
+	AssociationTypeContactToNote           int64 = 201
+	AssociationTypeContactToTask           int64 = 203
+
+	AssociationTypeCompanyToContactPrimary int64 = 2
+	AssociationTypeCompanyToContact        int64 = 280
+	AssociationTypeCompanyToCompany        int64 = 450
+	AssociationTypeParentToChildCompany    int64 = 13
+	AssociationTypeChildToParentCompany    int64 = 14
+	AssociationTypeCompanyToDealPrimary    int64 = 6
+	AssociationTypeCompanyToDeal           int64 = 342
+	AssociationTypeCompanyToTicketPrimary  int64 = 25
+	AssociationTypeCompanyToTicket         int64 = 340
+	AssociationTypeCompanyToQuote          int64 = 72
+	AssociationTypeCompanyToCall           int64 = 181
+	AssociationTypeCompanyToEmail          int64 = 185
+	AssociationTypeCompanyToMeeting        int64 = 187
+	AssociationTypeCompanyToNote           int64 = 189
+	AssociationTypeCompanyToTask           int64 = 191
+
+	AssociationTypeDealToContact        int64 = 3
+	AssociationTypeDealToCompanyPrimary int64 = 5
+	AssociationTypeDealToCompany        int64 = 341
+	AssociationTypeDealToDeal           int64 = 451
+	AssociationTypeDealToTicket         int64 = 27
+	AssociationTypeDealToLineItem       int64 = 19
+	AssociationTypeDealToQuote          int64 = 63
+	AssociationTypeDealToCall           int64 = 205
+	AssociationTypeDealToEmail          int64 = 209
+	AssociationTypeDealToMeeting        int64 = 211
+	AssociationTypeDealToNote           int64 = 213
+	AssociationTypeDealToTask           int64 = 215
+
+	AssociationTypeTicketToContact        int64 = 16
+	AssociationTypeTicketToCompanyPrimary int64 = 26
+	AssociationTypeTicketToCompany        int64 = 339
+	AssociationTypeTicketToDeal           int64 = 28
+	AssociationTypeTicketToTicket         int64 = 452
+	AssociationTypeTicketToCall           int64 = 219
+	AssociationTypeTicketToEmail          int64 = 223
+	AssociationTypeTicketToMeeting        int64 = 225
+	AssociationTypeTicketToNote           int64 = 227
+	AssociationTypeTicketToTask           int64 = 229
+
+	AssociationTypeLineItemToDeal  int64 = 20
+	AssociationTypeLineItemToQuote int64 = 68
+
+	AssociationTypeQuoteToContact  int64 = 69
+	AssociationTypeQuoteToCompany  int64 = 71
+	AssociationTypeQuoteToDeal     int64 = 64
+	AssociationTypeQuoteToLineItem int64 = 67
+
+	AssociationTypeCallToContact int64 = 194
+	AssociationTypeCallToCompany int64 = 182
+	AssociationTypeCallToDeal    int64 = 206
+	AssociationTypeCallToTicket  int64 = 220
+
+	AssociationTypeEmailToContact int64 = 198
+	AssociationTypeEmailToCompany int64 = 186
+	AssociationTypeEmailToDeal    int64 = 210
+	AssociationTypeEmailToTicket  int64 = 224
+
+	AssociationTypeMeetingToContact int64 = 200
+	AssociationTypeMeetingToCompany int64 = 188
+	AssociationTypeMeetingToDeal    int64 = 212
+	AssociationTypeMeetingToTicket  int64 = 226
+
+	AssociationTypeNoteToContact int64 = 202
+	AssociationTypeNoteToCompany int64 = 190
+	AssociationTypeNoteToDeal    int64 = 214
+	AssociationTypeNoteToTicket  int64 = 228
+
+	AssociationTypeTaskToContact int64 = 204
+	AssociationTypeTaskToCompany int64 = 192
+	AssociationTypeTaskToDeal    int64 = 216
+	AssociationTypeTaskToTicket  int64 = 230
+)
+
+// primaryAssociationLabel is the label of the HubSpot defined primary association types
+const primaryAssociationLabel string = "Primary"
+
+type associationPair struct {
+	from ObjectType
+	to   ObjectType
+}
+
+// defaultAssociationTypes are the unlabelled association types of the standard object pairs
+var defaultAssociationTypes = map[associationPair]int64{
+	{ObjectTypeContacts, ObjectTypeCompanies}: AssociationTypeContactToCompany,
+	{ObjectTypeContacts, ObjectTypeContacts}:  AssociationTypeContactToContact,
+	{ObjectTypeContacts, ObjectTypeDeals}:     AssociationTypeContactToDeal,
+	{ObjectTypeContacts, ObjectTypeTickets}:   AssociationTypeContactToTicket,
+	{ObjectTypeContacts, ObjectTypeQuotes}:    AssociationTypeContactToQuote,
+	{ObjectTypeContacts, ObjectTypeCalls}:     AssociationTypeContactToCall,
+	{ObjectTypeContacts, ObjectTypeEmails}:    AssociationTypeContactToEmail,
+	{ObjectTypeContacts, ObjectTypeMeetings}:  AssociationTypeContactToMeeting,
+	{ObjectTypeContacts, ObjectTypeNotes}:     AssociationTypeContactToNote,
+	{ObjectTypeContacts, ObjectTypeTasks}:     AssociationTypeContactToTask,
+
+	{ObjectTypeCompanies, ObjectTypeContacts}:  AssociationTypeCompanyToContact,
+	{ObjectTypeCompanies, ObjectTypeCompanies}: AssociationTypeCompanyToCompany,
+	{ObjectTypeCompanies, ObjectTypeDeals}:     AssociationTypeCompanyToDeal,
+	{ObjectTypeCompanies, ObjectTypeTickets}:   AssociationTypeCompanyToTicket,
+	{ObjectTypeCompanies, ObjectTypeQuotes}:    AssociationTypeCompanyToQuote,
+	{ObjectTypeCompanies, ObjectTypeCalls}:     AssociationTypeCompanyToCall,
+	{ObjectTypeCompanies, ObjectTypeEmails}:    AssociationTypeCompanyToEmail,
+	{ObjectTypeCompanies, ObjectTypeMeetings}:  AssociationTypeCompanyToMeeting,
+	{ObjectTypeCompanies, ObjectTypeNotes}:     AssociationTypeCompanyToNote,
+	{ObjectTypeCompanies, ObjectTypeTasks}:     AssociationTypeCompanyToTask,
+
+	{ObjectTypeDeals, ObjectTypeContacts}:  AssociationTypeDealToContact,
+	{ObjectTypeDeals, ObjectTypeCompanies}: AssociationTypeDealToCompany,
+	{ObjectTypeDeals, ObjectTypeDeals}:     AssociationTypeDealToDeal,
+	{ObjectTypeDeals, ObjectTypeTickets}:   AssociationTypeDealToTicket,
+	{ObjectTypeDeals, ObjectTypeLineItems}: AssociationTypeDealToLineItem,
+	{ObjectTypeDeals, ObjectTypeQuotes}:    AssociationTypeDealToQuote,
+	{ObjectTypeDeals, ObjectTypeCalls}:     AssociationTypeDealToCall,
+	{ObjectTypeDeals, ObjectTypeEmails}:    AssociationTypeDealToEmail,
+	{ObjectTypeDeals, ObjectTypeMeetings}:  AssociationTypeDealToMeeting,
+	{ObjectTypeDeals, ObjectTypeNotes}:     AssociationTypeDealToNote,
+	{ObjectTypeDeals, ObjectTypeTasks}:     AssociationTypeDealToTask,
+
+	{ObjectTypeTickets, ObjectTypeContacts}:  AssociationTypeTicketToContact,
+	{ObjectTypeTickets, ObjectTypeCompanies}: AssociationTypeTicketToCompany,
+	{ObjectTypeTickets, ObjectTypeDeals}:     AssociationTypeTicketToDeal,
+	{ObjectTypeTickets, ObjectTypeTickets}:   AssociationTypeTicketToTicket,
+	{ObjectTypeTickets, ObjectTypeCalls}:     AssociationTypeTicketToCall,
+	{ObjectTypeTickets, ObjectTypeEmails}:    AssociationTypeTicketToEmail,
+	{ObjectTypeTickets, ObjectTypeMeetings}:  AssociationTypeTicketToMeeting,
+	{ObjectTypeTickets, ObjectTypeNotes}:     AssociationTypeTicketToNote,
+	{ObjectTypeTickets, ObjectTypeTasks}:     AssociationTypeTicketToTask,
+
+	{ObjectTypeLineItems, ObjectTypeDeals}:  AssociationTypeLineItemToDeal,
+	{ObjectTypeLineItems, ObjectTypeQuotes}: AssociationTypeLineItemToQuote,
+
+	{ObjectTypeQuotes, ObjectTypeContacts}:  AssociationTypeQuoteToContact,
+	{ObjectTypeQuotes, ObjectTypeCompanies}: AssociationTypeQuoteToCompany,
+	{ObjectTypeQuotes, ObjectTypeDeals}:     AssociationTypeQuoteToDeal,
+	{ObjectTypeQuotes, ObjectTypeLineItems}: AssociationTypeQuoteToLineItem,
+
+	{ObjectTypeCalls, ObjectTypeContacts}:  AssociationTypeCallToContact,
+	{ObjectTypeCalls, ObjectTypeCompanies}: AssociationTypeCallToCompany,
+	{ObjectTypeCalls, ObjectTypeDeals}:     AssociationTypeCallToDeal,
+	{ObjectTypeCalls, ObjectTypeTickets}:   AssociationTypeCallToTicket,
+
+	{ObjectTypeEmails, ObjectTypeContacts}:  AssociationTypeEmailToContact,
+	{ObjectTypeEmails, ObjectTypeCompanies}: AssociationTypeEmailToCompany,
+	{ObjectTypeEmails, ObjectTypeDeals}:     AssociationTypeEmailToDeal,
+	{ObjectTypeEmails, ObjectTypeTickets}:   AssociationTypeEmailToTicket,
+
+	{ObjectTypeMeetings, ObjectTypeContacts}:  AssociationTypeMeetingToContact,
+	{ObjectTypeMeetings, ObjectTypeCompanies}: AssociationTypeMeetingToCompany,
+	{ObjectTypeMeetings, ObjectTypeDeals}:     AssociationTypeMeetingToDeal,
+	{ObjectTypeMeetings, ObjectTypeTickets}:   AssociationTypeMeetingToTicket,
+
+	{ObjectTypeNotes, ObjectTypeContacts}:  AssociationTypeNoteToContact,
+	{ObjectTypeNotes, ObjectTypeCompanies}: AssociationTypeNoteToCompany,
+	{ObjectTypeNotes, ObjectTypeDeals}:     AssociationTypeNoteToDeal,
+	{ObjectTypeNotes, ObjectTypeTickets}:   AssociationTypeNoteToTicket,
+
+	{ObjectTypeTasks, ObjectTypeContacts}:  AssociationTypeTaskToContact,
+	{ObjectTypeTasks, ObjectTypeCompanies}: AssociationTypeTaskToCompany,
+	{ObjectTypeTasks, ObjectTypeDeals}:     AssociationTypeTaskToDeal,
+	{ObjectTypeTasks, ObjectTypeTickets}:   AssociationTypeTaskToTicket,
+}
+
+// primaryAssociationTypes are the association types labelled Primary of the standard object pairs
+var primaryAssociationTypes = map[associationPair]int64{
+	{ObjectTypeContacts, ObjectTypeCompanies}: AssociationTypeContactToCompanyPrimary,
+	{ObjectTypeCompanies, ObjectTypeContacts}: AssociationTypeCompanyToContactPrimary,
+	{ObjectTypeCompanies, ObjectTypeDeals}:    AssociationTypeCompanyToDealPrimary,
+	{ObjectTypeCompanies, ObjectTypeTickets}:  AssociationTypeCompanyToTicketPrimary,
+	{ObjectTypeDeals, ObjectTypeCompanies}:    AssociationTypeDealToCompanyPrimary,
+	{ObjectTypeTickets, ObjectTypeCompanies}:  AssociationTypeTicketToCompanyPrimary,
+}
+
+// DefaultAssociationType returns the unlabelled association type of a standard object pair
+func DefaultAssociationType(fromObjectType ObjectType, toObjectType ObjectType) (AssociationTypeV4, bool) {
+	typeId, ok := defaultAssociationTypes[associationPair{fromObjectType, toObjectType}]
+
+	return AssociationTypeV4{AssociationCategory: AssociationCategoryHubSpotDefined, AssociationTypeId: typeId}, ok
+}
+
+// PrimaryAssociationType returns the association type labelled Primary of a standard object pair
+func PrimaryAssociationType(fromObjectType ObjectType, toObjectType ObjectType) (AssociationTypeV4, bool) {
+	typeId, ok := primaryAssociationTypes[associationPair{fromObjectType, toObjectType}]
+
+	return AssociationTypeV4{AssociationCategory: AssociationCategoryHubSpotDefined, AssociationTypeId: typeId}, ok
+}
+
+// AssociationTypeRegistry resolves association types by label.
+// The labels of an object pair are loaded with GetAssociationLabels the first time they are needed and cached.
+// It is safe for concurrent use.
+type AssociationTypeRegistry struct {
+	service *Service
+	mutex   sync.Mutex
+	labels  map[associationPair]*associationLabels
+}
+
+// associationLabels are the labels of an object pair, available once done is closed
+type associationLabels struct {
+	done   chan struct{}
+	labels []AssociationLabel
+	e      *errortools.Error
+}
+
+func NewAssociationTypeRegistry(service *Service) *AssociationTypeRegistry {
+	return &AssociationTypeRegistry{service: service, labels: make(map[associationPair]*associationLabels)}
+}
+
+// Labels returns the association labels of fromObjectType to toObjectType, including the HubSpot defined ones
+func (registry *AssociationTypeRegistry) Labels(fromObjectType ObjectType, toObjectType ObjectType) ([]AssociationLabel, *errortools.Error) {
+	return registry.LabelsWithContext(context.Background(), fromObjectType, toObjectType)
+}
+
+// LabelsWithContext loads the labels of an object pair once, concurrent calls for the same pair wait for that request.
+// If it fails the waiting calls try again themselves.
+func (registry *AssociationTypeRegistry) LabelsWithContext(ctx context.Context, fromObjectType ObjectType, toObjectType ObjectType) ([]AssociationLabel, *errortools.Error) {
+	pair := associationPair{fromObjectType, toObjectType}
+
+	for {
+		registry.mutex.Lock()
+		cached, ok := registry.labels[pair]
+		if !ok {
+			cached = &associationLabels{done: make(chan struct{})}
+			registry.labels[pair] = cached
+		}
+		registry.mutex.Unlock()
+
+		if !ok {
+			return registry.load(ctx, pair, cached)
+		}
+
+		select {
+		case <-cached.done:
+		case <-ctx.Done():
+			return nil, newError(nil, ctx.Err())
+		}
+		if cached.e == nil {
+			return cached.labels, nil
+		}
+	}
+}
+
+// load requests the labels of pair into cached, which is dropped from the registry if the request fails
+func (registry *AssociationTypeRegistry) load(ctx context.Context, pair associationPair, cached *associationLabels) ([]AssociationLabel, *errortools.Error) {
+	defer close(cached.done)
+
+	labels, e := registry.service.GetAssociationLabelsWithContext(ctx, &GetAssociationLabelsConfig{
+		FromObjectType: string(pair.from),
+		ToObjectType:   string(pair.to),
+	})
+	if e != nil {
+		cached.e = e
+
+		registry.mutex.Lock()
+		if registry.labels[pair] == cached {
+			delete(registry.labels, pair)
+		}
+		registry.mutex.Unlock()
+
+		return nil, e
+	}
+
+	cached.labels = *labels
+
+	return cached.labels, nil
+}
+
+// Forget drops the cached labels of fromObjectType to toObjectType, e.g. after creating a label
+func (registry *AssociationTypeRegistry) Forget(fromObjectType ObjectType, toObjectType ObjectType) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	delete(registry.labels, associationPair{fromObjectType, toObjectType})
+}
+
+// Resolve returns the association type of fromObjectType to toObjectType labelled label, compared case insensitively.
+// An empty label returns the unlabelled association type, which for the standard object pairs needs no request.
+func (registry *AssociationTypeRegistry) Resolve(fromObjectType ObjectType, toObjectType ObjectType, label string) (AssociationTypeV4, *errortools.Error) {
+	return registry.ResolveWithContext(context.Background(), fromObjectType, toObjectType, label)
+}
+
+func (registry *AssociationTypeRegistry) ResolveWithContext(ctx context.Context, fromObjectType ObjectType, toObjectType ObjectType, label string) (AssociationTypeV4, *errortools.Error) {
+	if label == "" {
+		if associationType, ok := DefaultAssociationType(fromObjectType, toObjectType); ok {
+			return associationType, nil
+		}
+	}
+	if strings.EqualFold(label, primaryAssociationLabel) {
+		if associationType, ok := PrimaryAssociationType(fromObjectType, toObjectType); ok {
+			return associationType, nil
+		}
+	}
+
+	labels, e := registry.LabelsWithContext(ctx, fromObjectType, toObjectType)
+	if e != nil {
+		return AssociationTypeV4{}, e
+	}
+
+	for _, associationLabel := range labels {
+		name := ""
+		if associationLabel.Label != nil {
+			name = *associationLabel.Label
+		}
+		if strings.EqualFold(name, label) {
+			return associationLabel.AssociationTypeV4(), nil
+		}
+	}
+
+	if label == "" {
+		return AssociationTypeV4{}, errortools.ErrorMessage(fmt.Sprintf("No unlabelled association type of %s to %s", fromObjectType, toObjectType))
+	}
+
+	return AssociationTypeV4{}, errortools.ErrorMessage(fmt.Sprintf("No association label %q of %s to %s", label, fromObjectType, toObjectType))
+}
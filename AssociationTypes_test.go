@@ -0,0 +1,132 @@
+package hubspot
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAssociationTypeRegistryResolve(t *testing.T) {
+	var requests []string
+
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+
+		switch r.URL.Path {
+		case "/crm/v4/associations/contacts/companies/labels":
+			writeJson(w, http.StatusOK, `{"results":[{"category":"HUBSPOT_DEFINED","typeId":279,"label":null},{"category":"HUBSPOT_DEFINED","typeId":1,"label":"Primary"},{"category":"USER_DEFINED","typeId":100,"label":"Billing contact"}]}`)
+		case "/crm/v4/associations/contacts/p_cars/labels":
+			writeJson(w, http.StatusOK, `{"results":[{"category":"USER_DEFINED","typeId":50,"label":null}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}, nil)
+
+	registry := NewAssociationTypeRegistry(service)
+
+	resolve := func(from ObjectType, to ObjectType, label string) AssociationTypeV4 {
+		t.Helper()
+
+		associationType, e := registry.Resolve(from, to, label)
+		if e != nil {
+			t.Fatal(e.Message())
+		}
+		return associationType
+	}
+
+	// the types of standard pairs are builtin
+	if associationType := resolve(ObjectTypeContacts, ObjectTypeCompanies, ""); associationType.AssociationTypeId != AssociationTypeContactToCompany {
+		t.Errorf("got %+v, want the unlabelled type", associationType)
+	}
+	if associationType := resolve(ObjectTypeContacts, ObjectTypeCompanies, "primary"); associationType.AssociationTypeId != AssociationTypeContactToCompanyPrimary {
+		t.Errorf("got %+v, want the primary type", associationType)
+	}
+	if len(requests) != 0 {
+		t.Errorf("got requests %v, want none for builtin types", requests)
+	}
+
+	// user defined labels are loaded once
+	for range 2 {
+		associationType := resolve(ObjectTypeContacts, ObjectTypeCompanies, "billing CONTACT")
+		if associationType.AssociationTypeId != 100 || associationType.AssociationCategory != AssociationCategoryUserDefined {
+			t.Errorf("got %+v, want user defined type 100", associationType)
+		}
+	}
+	if _, e := registry.Resolve(ObjectTypeContacts, ObjectTypeCompanies, "reseller"); e == nil || !strings.Contains(e.Message(), `No association label "reseller" of contacts to companies`) {
+		t.Errorf("got error %v, want that the label does not exist", e)
+	}
+	if len(requests) != 1 {
+		t.Errorf("got requests %v, want one for the labels of contacts to companies", requests)
+	}
+
+	// pairs with a custom object have no builtin types
+	if associationType := resolve(ObjectTypeContacts, "p_cars", ""); associationType.AssociationTypeId != 50 {
+		t.Errorf("got %+v, want the unlabelled type 50", associationType)
+	}
+
+	registry.Forget(ObjectTypeContacts, ObjectTypeCompanies)
+	resolve(ObjectTypeContacts, ObjectTypeCompanies, "billing contact")
+	if len(requests) != 3 {
+		t.Errorf("got requests %v, want the labels loaded again after Forget", requests)
+	}
+}
+
+func TestAssociationTypeRegistryConcurrent(t *testing.T) {
+	var requests atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fail := atomic.Bool{}
+	fail.Store(true)
+
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/crm/v4/associations/contacts/p_cars/labels":
+			if requests.Add(1) == 1 {
+				close(started)
+			}
+			<-release
+			if fail.Swap(false) {
+				writeJson(w, http.StatusForbidden, `{"status":"error","category":"MISSING_SCOPES","message":"forbidden"}`)
+				return
+			}
+			writeJson(w, http.StatusOK, `{"results":[{"category":"USER_DEFINED","typeId":50,"label":"Driver"}]}`)
+		default:
+			writeJson(w, http.StatusOK, `{"results":[]}`)
+		}
+	}, nil)
+
+	registry := NewAssociationTypeRegistry(service)
+
+	var wg sync.WaitGroup
+	failures := make([]bool, 10)
+	for i := range failures {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, e := registry.Resolve(ObjectTypeContacts, "p_cars", "driver")
+			failures[i] = e != nil
+		}()
+	}
+
+	// other pairs are resolved while the labels of contacts to p_cars are loading
+	<-started
+	if _, e := registry.Labels(ObjectTypeContacts, "p_boats"); e != nil {
+		t.Fatal(e.Message())
+	}
+
+	close(release)
+	wg.Wait()
+
+	// the failed request is not cached, the calls that waited for it load the labels again
+	failed := 0
+	for _, failure := range failures {
+		if failure {
+			failed++
+		}
+	}
+	if failed != 1 || requests.Load() != 2 {
+		t.Errorf("got %v failures of %v requests, want 1 of 2", failed, requests.Load())
+	}
+}
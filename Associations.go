@@ -1,10 +1,15 @@
 package hubspot
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
+	"iter"
 	"net/http"
+	"net/url"
+	"slices"
 	"time"
 )
 
@@ -30,6 +35,23 @@ type AssociationV4 struct {
 	To   []AssociationTo `json:"to"`
 }
 
+// batchAssociationsResult is a result of the batch read, Paging refers to the next page of the associations of From
+type batchAssociationsResult struct {
+	AssociationV4
+	Paging *Paging `json:"paging"`
+}
+
+// nextInput returns the input to read the next page of the associations of result.From, or nil if there is none
+func (result batchAssociationsResult) nextInput() *BatchGetAssociationsInput {
+	if result.Paging == nil || result.Paging.Next.After == "" {
+		return nil
+	}
+
+	after := result.Paging.Next.After
+
+	return &BatchGetAssociationsInput{Id: result.From.Id, After: &after}
+}
+
 type AssociationTo struct {
 	ToObjectId       int64              `json:"toObjectId"`
 	AssociationTypes []AssociationLabel `json:"associationTypes"`
@@ -78,7 +100,8 @@ type AssociationTypeV4 struct {
 }
 
 type BatchGetAssociationsInput struct {
-	Id string `json:"id"`
+	Id    string  `json:"id"`
+	After *string `json:"after,omitempty"` // page of the associations of Id to start at
 }
 
 type BatchGetAssociationsConfig struct {
@@ -87,7 +110,12 @@ type BatchGetAssociationsConfig struct {
 	Inputs         []BatchGetAssociationsInput `json:"inputs"`
 }
 
+// BatchGetAssociations returns all associations of the objects of config.Inputs, following the paging of every input
 func (service *Service) BatchGetAssociations(config *BatchGetAssociationsConfig) (*AssociationsV4Set, *errortools.Error) {
+	return service.BatchGetAssociationsWithContext(context.Background(), config)
+}
+
+func (service *Service) BatchGetAssociationsWithContext(ctx context.Context, config *BatchGetAssociationsConfig) (*AssociationsV4Set, *errortools.Error) {
 	if config == nil {
 		return nil, nil
 	}
@@ -95,33 +123,131 @@ func (service *Service) BatchGetAssociations(config *BatchGetAssociationsConfig)
 		return nil, nil
 	}
 
-	endpoint := fmt.Sprintf("associations/%v/%v/batch/read", config.FromObjectType, config.ToObjectType)
-
 	var associationsV4Set AssociationsV4Set
 
-	for _, batch := range service.batches(len(config.Inputs)) {
-		var associationsV4Set_ AssociationsV4Set
+	// index of the associations of an object in associationsV4Set.Results
+	indexes := make(map[string]int)
 
-		requestConfig := go_http.RequestConfig{
-			Method: http.MethodPost,
-			Url:    service.urlV4(endpoint),
-			BodyModel: BatchGetAssociationsConfig{
-				Inputs: config.Inputs[batch.startIndex:batch.endIndex],
-			},
-			ResponseModel: &associationsV4Set_,
-		}
+	for inputs := config.Inputs; len(inputs) > 0; {
+		batches := service.batches(len(inputs))
+		results := make([][]batchAssociationsResult, len(batches))
+
+		e := service.forEachBatch(ctx, batches, func(ctx context.Context, i int, batch batch) *errortools.Error {
+			var e *errortools.Error
+			results[i], e = service.batchReadAssociations(ctx, config.FromObjectType, config.ToObjectType, inputs[batch.startIndex:batch.endIndex])
 
-		_, _, e := service.httpRequest(&requestConfig)
+			return e
+		})
 		if e != nil {
 			return nil, e
 		}
 
-		associationsV4Set.Results = append(associationsV4Set.Results, associationsV4Set_.Results...)
+		inputs = nil
+
+		for _, batchResults := range results {
+			for _, result := range batchResults {
+				if index, ok := indexes[result.From.Id]; ok {
+					associationsV4Set.Results[index].To = append(associationsV4Set.Results[index].To, result.To...)
+				} else {
+					indexes[result.From.Id] = len(associationsV4Set.Results)
+					associationsV4Set.Results = append(associationsV4Set.Results, result.AssociationV4)
+				}
+
+				if next := result.nextInput(); next != nil {
+					inputs = append(inputs, *next)
+				}
+			}
+		}
 	}
 
 	return &associationsV4Set, nil
 }
 
+// NewBatchAssociationsCursor returns a cursor at the start of BatchGetAssociations
+func NewBatchAssociationsCursor(config *BatchGetAssociationsConfig) *Cursor {
+	if config == nil {
+		return &Cursor{}
+	}
+
+	return &Cursor{
+		ObjectType: config.FromObjectType,
+		Associations: &AssociationsCursor{
+			ToObjectType: config.ToObjectType,
+			Inputs:       slices.Clone(config.Inputs),
+		},
+		Done: len(config.Inputs) == 0,
+	}
+}
+
+// IterBatchGetAssociations streams the associations of the objects of config.Inputs batch by batch.
+// The associations of an object with more than one page are yielded in more than one AssociationV4.
+func (service *Service) IterBatchGetAssociations(ctx context.Context, config *BatchGetAssociationsConfig) iter.Seq2[AssociationV4, error] {
+	return iterErrors(service.iterBatchGetAssociationsFrom(ctx, NewBatchAssociationsCursor(config)))
+}
+
+// IterBatchGetAssociationsFrom resumes IterBatchGetAssociations at cursor
+func (service *Service) IterBatchGetAssociationsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[AssociationV4, error] {
+	return iterErrors(service.iterBatchGetAssociationsFrom(ctx, cursor))
+}
+
+func (service *Service) iterBatchGetAssociationsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[AssociationV4, *errortools.Error] {
+	if e := cursor.check(""); e != nil {
+		return iterError[AssociationV4](e)
+	}
+	if cursor.Associations == nil || cursor.ParentId != "" {
+		return iterError[AssociationV4](errortools.ErrorMessage("Cursor was not created by NewBatchAssociationsCursor"))
+	}
+
+	return func(yield func(AssociationV4, *errortools.Error) bool) {
+		for !cursor.Done {
+			inputs := cursor.Associations.Inputs
+			n := min(maxItemsPerBatch, len(inputs))
+
+			results, e := service.batchReadAssociations(ctx, cursor.ObjectType, cursor.Associations.ToObjectType, inputs[:n])
+			if e != nil {
+				yield(AssociationV4{}, e)
+				return
+			}
+
+			next := slices.Clone(inputs[n:])
+
+			for _, result := range results {
+				if !yield(result.AssociationV4, nil) {
+					return
+				}
+
+				if input := result.nextInput(); input != nil {
+					next = append(next, *input)
+				}
+			}
+
+			cursor.Associations.Inputs = next
+			cursor.Done = len(next) == 0
+		}
+	}
+}
+
+// batchReadAssociations reads one page of the associations of at most 100 inputs
+func (service *Service) batchReadAssociations(ctx context.Context, fromObjectType string, toObjectType string, inputs []BatchGetAssociationsInput) ([]batchAssociationsResult, *errortools.Error) {
+	var response struct {
+		Results []batchAssociationsResult `json:"results"`
+	}
+
+	requestConfig := go_http.RequestConfig{
+		Method:        http.MethodPost,
+		Url:           service.urlV4(fmt.Sprintf("associations/%v/%v/batch/read", fromObjectType, toObjectType)),
+		BodyModel:     BatchGetAssociationsConfig{Inputs: inputs},
+		ResponseModel: &response,
+	}
+
+	_, _, e := service.httpRequest(ctx, &requestConfig)
+	if e != nil {
+		return nil, e
+	}
+
+	return response.Results, nil
+}
+
 type CreateAssociationConfig struct {
 	FromObjectType   string
 	FromObjectId     string
@@ -139,6 +265,10 @@ type CreateAssociationResponse struct {
 }
 
 func (service *Service) CreateAssociation(config *CreateAssociationConfig) (*CreateAssociationResponse, *errortools.Error) {
+	return service.CreateAssociationWithContext(context.Background(), config)
+}
+
+func (service *Service) CreateAssociationWithContext(ctx context.Context, config *CreateAssociationConfig) (*CreateAssociationResponse, *errortools.Error) {
 	if config == nil {
 		return nil, nil
 	}
@@ -154,7 +284,7 @@ func (service *Service) CreateAssociation(config *CreateAssociationConfig) (*Cre
 		ResponseModel: &createAssociationResponse,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -174,6 +304,9 @@ type BatchCreateAssociationsConfig struct {
 	Inputs         []BatchCreateAssociationsInput `json:"inputs"`
 }
 
+// BatchCreateAssociationsResponse is the response of a batch create of associations
+//
+// Deprecated: the batch functions of associations return a BatchResult
 type BatchCreateAssociationsResponse struct {
 	CompletedAt *time.Time `json:"completedAt"`
 	RequestedAt *time.Time `json:"requestedAt"`
@@ -187,39 +320,29 @@ type BatchCreateAssociationsResponse struct {
 	Status  string                      `json:"status"`
 }
 
+// BatchCreateAssociations returns the associations created, the inputs that failed are only logged
+//
+// Deprecated: use BatchCreateAssociationsWithResult, which also returns the inputs that failed
 func (service *Service) BatchCreateAssociations(config *BatchCreateAssociationsConfig) (*[]CreateAssociationResponse, *errortools.Error) {
-	if config == nil {
-		return nil, nil
-	}
-	if len(config.Inputs) == 0 {
-		return nil, nil
+	result, e := service.BatchCreateAssociationsWithResultWithContext(context.Background(), config)
+	if e != nil || result == nil {
+		return nil, e
 	}
 
-	endpoint := fmt.Sprintf("associations/%s/%s/batch/create", config.FromObjectType, config.ToObjectType)
-
-	var r []CreateAssociationResponse
-
-	for _, batch := range service.batches(len(config.Inputs)) {
-		var batchCreateAssociationsResponse BatchCreateAssociationsResponse
-
-		requestConfig := go_http.RequestConfig{
-			Method: http.MethodPost,
-			Url:    service.urlV4(endpoint),
-			BodyModel: BatchCreateAssociationsConfig{
-				Inputs: config.Inputs[batch.startIndex:batch.endIndex],
-			},
-			ResponseModel: &batchCreateAssociationsResponse,
-		}
+	return &result.Successes, nil
+}
 
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
+// BatchCreateAssociationsWithResult creates the associations of config.Inputs in batches of 100, the inputs of a partially failed batch are reported as failures
+func (service *Service) BatchCreateAssociationsWithResult(config *BatchCreateAssociationsConfig) (*BatchResult[CreateAssociationResponse], *errortools.Error) {
+	return service.BatchCreateAssociationsWithResultWithContext(context.Background(), config)
+}
 
-		r = append(r, batchCreateAssociationsResponse.Results...)
+func (service *Service) BatchCreateAssociationsWithResultWithContext(ctx context.Context, config *BatchCreateAssociationsConfig) (*BatchResult[CreateAssociationResponse], *errortools.Error) {
+	if config == nil {
+		return nil, nil
 	}
 
-	return &r, nil
+	return batchAssociations[CreateAssociationResponse](ctx, service, config.FromObjectType, config.ToObjectType, "create", config.Inputs)
 }
 
 type BatchArchiveAssociationsInput struct {
@@ -233,67 +356,227 @@ type BatchArchiveAssociationsConfig struct {
 	Inputs         []BatchArchiveAssociationsInput `json:"inputs"`
 }
 
+// BatchArchiveAssociations removes the associations of config.Inputs in batches of 100, the inputs that failed are only logged
+//
+// Deprecated: use BatchArchiveAssociationsWithFailures, which also returns the inputs that failed
 func (service *Service) BatchArchiveAssociations(config *BatchArchiveAssociationsConfig) *errortools.Error {
+	_, e := service.BatchArchiveAssociationsWithFailuresWithContext(context.Background(), config)
+	return e
+}
+
+// BatchArchiveAssociationsWithFailures removes the associations of config.Inputs in batches of 100, and returns the inputs of partially failed batches that failed
+func (service *Service) BatchArchiveAssociationsWithFailures(config *BatchArchiveAssociationsConfig) ([]BatchFailure, *errortools.Error) {
+	return service.BatchArchiveAssociationsWithFailuresWithContext(context.Background(), config)
+}
+
+func (service *Service) BatchArchiveAssociationsWithFailuresWithContext(ctx context.Context, config *BatchArchiveAssociationsConfig) ([]BatchFailure, *errortools.Error) {
 	if config == nil {
-		return nil
+		return nil, nil
 	}
-	if len(config.Inputs) == 0 {
-		return nil
+
+	result, e := batchAssociations[json.RawMessage](ctx, service, config.FromObjectType, config.ToObjectType, "archive", config.Inputs)
+	if result == nil {
+		return nil, e
 	}
 
-	endpoint := fmt.Sprintf("associations/%s/%s/batch/archive", config.FromObjectType, config.ToObjectType)
+	return result.Failures, e
+}
 
-	for _, batch := range service.batches(len(config.Inputs)) {
-		requestConfig := go_http.RequestConfig{
-			Method: http.MethodPost,
-			Url:    service.urlV4(endpoint),
-			BodyModel: BatchArchiveAssociationsConfig{
-				Inputs: config.Inputs[batch.startIndex:batch.endIndex],
-			},
-		}
+type BatchDefaultAssociationsInput struct {
+	From AssociationId `json:"from"`
+	To   AssociationId `json:"to"`
+}
 
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return e
-		}
+type BatchCreateDefaultAssociationsConfig struct {
+	FromObjectType string                          `json:"-"`
+	ToObjectType   string                          `json:"-"`
+	Inputs         []BatchDefaultAssociationsInput `json:"inputs"`
+}
+
+type DefaultAssociationResponse struct {
+	From            AssociationId     `json:"from"`
+	To              AssociationId     `json:"to"`
+	AssociationSpec AssociationTypeV4 `json:"associationSpec"`
+}
+
+// BatchCreateDefaultAssociations associates objects without a label, by the default association type of the object types
+func (service *Service) BatchCreateDefaultAssociations(config *BatchCreateDefaultAssociationsConfig) (*BatchResult[DefaultAssociationResponse], *errortools.Error) {
+	return service.BatchCreateDefaultAssociationsWithContext(context.Background(), config)
+}
+
+func (service *Service) BatchCreateDefaultAssociationsWithContext(ctx context.Context, config *BatchCreateDefaultAssociationsConfig) (*BatchResult[DefaultAssociationResponse], *errortools.Error) {
+	if config == nil {
+		return nil, nil
 	}
 
-	return nil
+	return batchAssociations[DefaultAssociationResponse](ctx, service, config.FromObjectType, config.ToObjectType, "associate/default", config.Inputs)
+}
+
+type BatchArchiveAssociationLabelsConfig struct {
+	FromObjectType string                         `json:"-"`
+	ToObjectType   string                         `json:"-"`
+	Inputs         []BatchCreateAssociationsInput `json:"inputs"`
+}
+
+// BatchArchiveAssociationLabels removes the association types of the inputs from associations, the associations themselves remain.
+// It returns the inputs of partially failed batches that failed.
+func (service *Service) BatchArchiveAssociationLabels(config *BatchArchiveAssociationLabelsConfig) ([]BatchFailure, *errortools.Error) {
+	return service.BatchArchiveAssociationLabelsWithContext(context.Background(), config)
+}
+
+func (service *Service) BatchArchiveAssociationLabelsWithContext(ctx context.Context, config *BatchArchiveAssociationLabelsConfig) ([]BatchFailure, *errortools.Error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	result, e := batchAssociations[json.RawMessage](ctx, service, config.FromObjectType, config.ToObjectType, "labels/archive", config.Inputs)
+	if result == nil {
+		return nil, e
+	}
+
+	return result.Failures, e
+}
+
+// associationInput is an input of an association batch, keys returns the objects it associates
+type associationInput interface {
+	keys() (string, []string)
+}
+
+func (input BatchCreateAssociationsInput) keys() (string, []string) {
+	return input.From.Id, []string{input.To.Id}
+}
+
+func (input BatchDefaultAssociationsInput) keys() (string, []string) {
+	return input.From.Id, []string{input.To.Id}
+}
+
+func (input BatchArchiveAssociationsInput) keys() (string, []string) {
+	var toIds []string
+	for _, to := range input.To {
+		toIds = append(toIds, to.Id)
+	}
+	return input.From.Id, toIds
+}
+
+// batchAssociations sends inputs to the association batch endpoint of action in batches of 100
+func batchAssociations[R any, I associationInput](ctx context.Context, service *Service, fromObjectType string, toObjectType string, action string, inputs []I) (*BatchResult[R], *errortools.Error) {
+	if len(inputs) == 0 {
+		return &BatchResult[R]{Successes: []R{}, SuccessIndexes: []int{}}, nil
+	}
+
+	url := service.urlV4(fmt.Sprintf("associations/%s/%s/batch/%s", fromObjectType, toObjectType, action))
+
+	batches := service.batches(len(inputs))
+	results := make([]BatchResult[R], len(batches))
+
+	e := service.forEachBatch(ctx, batches, func(ctx context.Context, i int, batch batch) *errortools.Error {
+		body := struct {
+			Inputs []I `json:"inputs"`
+		}{inputs[batch.startIndex:batch.endIndex]}
+
+		indexes := associationInputIndexes(batch, func(i int) (string, []string) { return inputs[i].keys() })
+
+		return sendBatchTo(ctx, service, url, ObjectType(fromObjectType), fmt.Sprintf("%s to %s", action, toObjectType), batch, body, indexes, &results[i])
+	})
+	if e != nil {
+		return nil, e
+	}
+
+	return mergeBatchResults(results), nil
 }
 
 type GetAssociationsConfig struct {
 	FromObjectType string
 	FromObjectId   string
 	ToObjectType   string
+	Limit          *uint // page size, max 500
+	After          *string
 }
 
 type GetAssociationsResponse struct {
 	Results []AssociationTo `json:"results"`
+	Paging  *Paging         `json:"paging,omitempty"` // next page, if config.After was set
 }
 
+// GetAssociations returns all associations of an object with objects of ToObjectType, or one page if config.After is set
 func (service *Service) GetAssociations(config *GetAssociationsConfig) (*GetAssociationsResponse, *errortools.Error) {
+	return service.GetAssociationsWithContext(context.Background(), config)
+}
+
+func (service *Service) GetAssociationsWithContext(ctx context.Context, config *GetAssociationsConfig) (*GetAssociationsResponse, *errortools.Error) {
 	if config == nil {
 		return nil, nil
 	}
 
-	endpoint := fmt.Sprintf("objects/%s/%s/associations/%s", config.FromObjectType, config.FromObjectId, config.ToObjectType)
-
-	var getAssociationsResponse GetAssociationsResponse
-
-	requestConfig := go_http.RequestConfig{
-		Method:        http.MethodGet,
-		Url:           service.urlV4(endpoint),
-		ResponseModel: &getAssociationsResponse,
-	}
+	cursor := NewAssociationsCursor(config)
 
-	_, _, e := service.httpRequest(&requestConfig)
+	results, e := collect(service.iterAssociationsFrom(ctx, cursor, config.After != nil))
 	if e != nil {
 		return nil, e
 	}
 
+	getAssociationsResponse := GetAssociationsResponse{Results: *results}
+
+	if !cursor.Done {
+		getAssociationsResponse.Paging = &Paging{}
+		getAssociationsResponse.Paging.Next.After = cursor.After
+	}
+
 	return &getAssociationsResponse, nil
 }
 
+// IterAssociations streams all associations of an object with objects of ToObjectType page by page, starting at config.After
+func (service *Service) IterAssociations(ctx context.Context, config *GetAssociationsConfig) iter.Seq2[AssociationTo, error] {
+	return iterErrors(service.iterAssociationsFrom(ctx, NewAssociationsCursor(config), false))
+}
+
+// NewAssociationsCursor returns a cursor at the start of GetAssociations, or at config.After
+func NewAssociationsCursor(config *GetAssociationsConfig) *Cursor {
+	if config == nil {
+		return &Cursor{}
+	}
+
+	values := url.Values{}
+
+	if config.Limit != nil {
+		values.Set("limit", fmt.Sprintf("%v", *config.Limit))
+	}
+
+	after := ""
+	if config.After != nil {
+		after = *config.After
+	}
+
+	return &Cursor{
+		ObjectType:   config.FromObjectType,
+		ParentId:     config.FromObjectId,
+		Query:        values,
+		After:        after,
+		Associations: &AssociationsCursor{ToObjectType: config.ToObjectType},
+	}
+}
+
+// IterAssociationsFrom resumes IterAssociations at cursor
+func (service *Service) IterAssociationsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[AssociationTo, error] {
+	return iterErrors(service.iterAssociationsFrom(ctx, cursor, false))
+}
+
+func (service *Service) iterAssociationsFrom(ctx context.Context, cursor *Cursor, singlePage bool) iter.Seq2[AssociationTo, *errortools.Error] {
+	if e := cursor.check(""); e != nil {
+		return iterError[AssociationTo](e)
+	}
+	if cursor.Associations == nil || cursor.ParentId == "" {
+		return iterError[AssociationTo](errortools.ErrorMessage("Cursor was not created by NewAssociationsCursor"))
+	}
+
+	return iterPages[AssociationTo](ctx, service, cursor, singlePage, func(after string) go_http.RequestConfig {
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlV4(fmt.Sprintf("objects/%s/%s/associations/%s?%s", cursor.ObjectType, cursor.ParentId, cursor.Associations.ToObjectType, encodeWithParam(cursor.Query, "after", after))),
+		}
+	})
+}
+
 type DeleteAssociationConfig struct {
 	FromObjectType string
 	FromObjectId   string
@@ -302,6 +585,10 @@ type DeleteAssociationConfig struct {
 }
 
 func (service *Service) DeleteAssociation(config *DeleteAssociationConfig) *errortools.Error {
+	return service.DeleteAssociationWithContext(context.Background(), config)
+}
+
+func (service *Service) DeleteAssociationWithContext(ctx context.Context, config *DeleteAssociationConfig) *errortools.Error {
 	if config == nil {
 		return nil
 	}
@@ -313,7 +600,7 @@ func (service *Service) DeleteAssociation(config *DeleteAssociationConfig) *erro
 		Url:    service.urlV4(endpoint),
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return e
 	}
@@ -327,6 +614,10 @@ type GetAssociationTypesConfig struct {
 }
 
 func (service *Service) GetAssociationTypes(config *GetAssociationTypesConfig) (*[]AssociationType, *errortools.Error) {
+	return service.GetAssociationTypesWithContext(context.Background(), config)
+}
+
+func (service *Service) GetAssociationTypesWithContext(ctx context.Context, config *GetAssociationTypesConfig) (*[]AssociationType, *errortools.Error) {
 	var response struct {
 		Results []AssociationType `json:"results"`
 	}
@@ -337,7 +628,7 @@ func (service *Service) GetAssociationTypes(config *GetAssociationTypesConfig) (
 		ResponseModel: &response,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -351,6 +642,10 @@ type GetAssociationLabelsConfig struct {
 }
 
 func (service *Service) GetAssociationLabels(config *GetAssociationLabelsConfig) (*[]AssociationLabel, *errortools.Error) {
+	return service.GetAssociationLabelsWithContext(context.Background(), config)
+}
+
+func (service *Service) GetAssociationLabelsWithContext(ctx context.Context, config *GetAssociationLabelsConfig) (*[]AssociationLabel, *errortools.Error) {
 	var response struct {
 		Results []AssociationLabel `json:"results"`
 	}
@@ -361,10 +656,109 @@ func (service *Service) GetAssociationLabels(config *GetAssociationLabelsConfig)
 		ResponseModel: &response,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
+	if e != nil {
+		return nil, e
+	}
+
+	return &response.Results, nil
+}
+
+// AssociationTypeV4 returns the association type of label for CreateAssociation and BatchCreateAssociations
+func (label AssociationLabel) AssociationTypeV4() AssociationTypeV4 {
+	return AssociationTypeV4{
+		AssociationCategory: label.Category,
+		AssociationTypeId:   label.TypeId,
+	}
+}
+
+type CreateAssociationLabelConfig struct {
+	FromObjectType string  `json:"-"`
+	ToObjectType   string  `json:"-"`
+	Name           string  `json:"name"`                   // internal name
+	Label          string  `json:"label"`                  // label of FromObjectType to ToObjectType
+	InverseLabel   *string `json:"inverseLabel,omitempty"` // label of ToObjectType to FromObjectType, makes a paired label
+}
+
+// CreateAssociationLabel creates a user defined association label.
+// A paired label returns the association type of each direction, the inverse one labelled InverseLabel.
+func (service *Service) CreateAssociationLabel(config *CreateAssociationLabelConfig) (*[]AssociationLabel, *errortools.Error) {
+	return service.CreateAssociationLabelWithContext(context.Background(), config)
+}
+
+func (service *Service) CreateAssociationLabelWithContext(ctx context.Context, config *CreateAssociationLabelConfig) (*[]AssociationLabel, *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
+	}
+
+	var response struct {
+		Results []AssociationLabel `json:"results"`
+	}
+
+	requestConfig := go_http.RequestConfig{
+		Method:        http.MethodPost,
+		Url:           service.urlV4(fmt.Sprintf("associations/%s/%s/labels", config.FromObjectType, config.ToObjectType)),
+		BodyModel:     config,
+		ResponseModel: &response,
+	}
+
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
 
 	return &response.Results, nil
 }
+
+type UpdateAssociationLabelConfig struct {
+	FromObjectType    string  `json:"-"`
+	ToObjectType      string  `json:"-"`
+	AssociationTypeId int64   `json:"associationTypeId"`
+	Label             string  `json:"label"`
+	InverseLabel      *string `json:"inverseLabel,omitempty"`
+}
+
+// UpdateAssociationLabel renames a user defined association label
+func (service *Service) UpdateAssociationLabel(config *UpdateAssociationLabelConfig) *errortools.Error {
+	return service.UpdateAssociationLabelWithContext(context.Background(), config)
+}
+
+func (service *Service) UpdateAssociationLabelWithContext(ctx context.Context, config *UpdateAssociationLabelConfig) *errortools.Error {
+	if config == nil {
+		return errortools.ErrorMessage("config is nil")
+	}
+
+	requestConfig := go_http.RequestConfig{
+		Method:    http.MethodPut,
+		Url:       service.urlV4(fmt.Sprintf("associations/%s/%s/labels", config.FromObjectType, config.ToObjectType)),
+		BodyModel: config,
+	}
+
+	_, _, e := service.httpRequest(ctx, &requestConfig)
+	return e
+}
+
+type DeleteAssociationLabelConfig struct {
+	FromObjectType    string
+	ToObjectType      string
+	AssociationTypeId int64
+}
+
+// DeleteAssociationLabel deletes a user defined association label, of a paired label both directions are deleted
+func (service *Service) DeleteAssociationLabel(config *DeleteAssociationLabelConfig) *errortools.Error {
+	return service.DeleteAssociationLabelWithContext(context.Background(), config)
+}
+
+func (service *Service) DeleteAssociationLabelWithContext(ctx context.Context, config *DeleteAssociationLabelConfig) *errortools.Error {
+	if config == nil {
+		return errortools.ErrorMessage("config is nil")
+	}
+
+	requestConfig := go_http.RequestConfig{
+		Method: http.MethodDelete,
+		Url:    service.urlV4(fmt.Sprintf("associations/%s/%s/labels/%v", config.FromObjectType, config.ToObjectType, config.AssociationTypeId)),
+	}
+
+	_, _, e := service.httpRequest(ctx, &requestConfig)
+	return e
+}
@@ -0,0 +1,373 @@
+package hubspot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBatchCreateAssociationsPartialFailure(t *testing.T) {
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/crm/v4/associations/contacts/companies/batch/create" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeJson(w, http.StatusMultiStatus, `{
+			"results":[{"fromObjectTypeId":"0-1","fromObjectId":3,"toObjectTypeId":"0-2","toObjectId":30,"labels":[]},{"fromObjectTypeId":"0-1","fromObjectId":1,"toObjectTypeId":"0-2","toObjectId":10,"labels":[]}],
+			"errors":[{"status":"error","category":"VALIDATION_ERROR","message":"No contacts with ids 2","context":{"fromObjectId":["2"]}}]
+		}`)
+	}, nil)
+
+	inputs := []BatchCreateAssociationsInput{
+		{From: AssociationId{Id: "1"}, To: AssociationId{Id: "10"}},
+		{From: AssociationId{Id: "2"}, To: AssociationId{Id: "20"}},
+		{From: AssociationId{Id: "3"}, To: AssociationId{Id: "30"}},
+	}
+
+	result, e := service.BatchCreateAssociationsWithResult(&BatchCreateAssociationsConfig{FromObjectType: "contacts", ToObjectType: "companies", Inputs: inputs})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if !slices.Equal(result.SuccessIndexes, []int{2, 0}) {
+		t.Errorf("unexpected success indexes %v", result.SuccessIndexes)
+	}
+	if len(result.Failures) != 1 || result.Failures[0].InputIndex != 1 {
+		t.Errorf("expected input 1 to fail, got %+v", result.Failures)
+	}
+
+	// the deprecated version returns the successes only
+	created, e := service.BatchCreateAssociations(&BatchCreateAssociationsConfig{FromObjectType: "contacts", ToObjectType: "companies", Inputs: inputs})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if len(*created) != 2 || (*created)[0].FromObjectId != 3 {
+		t.Errorf("got %+v, want the two associations created", *created)
+	}
+}
+
+func TestBatchArchiveAssociations(t *testing.T) {
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}, nil)
+
+	failures, e := service.BatchArchiveAssociationsWithFailures(&BatchArchiveAssociationsConfig{
+		FromObjectType: "contacts",
+		ToObjectType:   "companies",
+		Inputs:         []BatchArchiveAssociationsInput{{From: AssociationId{Id: "1"}, To: []AssociationId{{Id: "10"}, {Id: "11"}}}},
+	})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if len(failures) != 0 {
+		t.Errorf("unexpected failures %+v", failures)
+	}
+
+	if e := service.BatchArchiveAssociations(&BatchArchiveAssociationsConfig{FromObjectType: "contacts", ToObjectType: "companies"}); e != nil {
+		t.Fatal(e.Message())
+	}
+}
+
+func TestBatchObjectsNilConfig(t *testing.T) {
+	service := newTestService(t, nil, nil)
+
+	if _, e := service.BatchCreateDeals(nil); e == nil {
+		t.Error("expected an error for a nil config")
+	}
+	if _, e := service.BatchUpdateContacts(nil, ""); e == nil {
+		t.Error("expected an error for a nil config")
+	}
+}
+
+// labelsServer keeps the user defined labels of contacts to companies in memory
+type labelsServer struct {
+	mutex  sync.Mutex
+	labels []AssociationLabel
+	nextId int64
+}
+
+func (server *labelsServer) handle(w http.ResponseWriter, r *http.Request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	path, ok := strings.CutPrefix(r.URL.Path, "/crm/v4/associations/contacts/companies/labels")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var body struct {
+		Name              string  `json:"name"`
+		Label             string  `json:"label"`
+		InverseLabel      *string `json:"inverseLabel"`
+		AssociationTypeId int64   `json:"associationTypeId"`
+	}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	results := func(labels []AssociationLabel) string {
+		b, _ := json.Marshal(map[string]any{"results": labels})
+		return string(b)
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJson(w, http.StatusOK, results(server.labels))
+	case http.MethodPost:
+		if body.Name == "" || body.Label == "" {
+			writeJson(w, http.StatusBadRequest, `{"status":"error","category":"VALIDATION_ERROR","message":"name and label are required"}`)
+			return
+		}
+		created := []AssociationLabel{{Category: AssociationCategoryUserDefined, TypeId: server.nextId, Label: &body.Label}}
+		if body.InverseLabel != nil {
+			created = append(created, AssociationLabel{Category: AssociationCategoryUserDefined, TypeId: server.nextId + 1, Label: body.InverseLabel})
+		}
+		server.nextId += int64(len(created))
+		server.labels = append(server.labels, created...)
+		writeJson(w, http.StatusOK, results(created))
+	case http.MethodPut:
+		for i := range server.labels {
+			if server.labels[i].TypeId == body.AssociationTypeId {
+				server.labels[i].Label = &body.Label
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		writeJson(w, http.StatusNotFound, `{"status":"error","category":"OBJECT_NOT_FOUND","message":"label not found"}`)
+	case http.MethodDelete:
+		typeId, _ := strconv.ParseInt(strings.TrimPrefix(path, "/"), 10, 64)
+		n := len(server.labels)
+		server.labels = slices.DeleteFunc(server.labels, func(label AssociationLabel) bool { return label.TypeId == typeId })
+		if len(server.labels) == n {
+			writeJson(w, http.StatusNotFound, `{"status":"error","category":"OBJECT_NOT_FOUND","message":"label not found"}`)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestAssociationLabels(t *testing.T) {
+	server := labelsServer{nextId: 100}
+	service := newTestService(t, server.handle, nil)
+
+	inverseLabel := "Reseller of"
+	created, e := service.CreateAssociationLabel(&CreateAssociationLabelConfig{FromObjectType: "contacts", ToObjectType: "companies", Name: "reseller", Label: "Reseller", InverseLabel: &inverseLabel})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if len(*created) != 2 || *(*created)[0].Label != "Reseller" || *(*created)[1].Label != "Reseller of" {
+		t.Fatalf("got %+v, want the label and its inverse", *created)
+	}
+	associationType := (*created)[0].AssociationTypeV4()
+	if associationType.AssociationCategory != AssociationCategoryUserDefined || associationType.AssociationTypeId != 100 {
+		t.Errorf("got association type %+v, want user defined type 100", associationType)
+	}
+
+	if e := service.UpdateAssociationLabel(&UpdateAssociationLabelConfig{FromObjectType: "contacts", ToObjectType: "companies", AssociationTypeId: 100, Label: "Partner"}); e != nil {
+		t.Fatal(e.Message())
+	}
+	labels, e := service.GetAssociationLabels(&GetAssociationLabelsConfig{FromObjectType: "contacts", ToObjectType: "companies"})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if len(*labels) != 2 || *(*labels)[0].Label != "Partner" {
+		t.Errorf("got labels %+v, want the renamed label", *labels)
+	}
+
+	if e := service.DeleteAssociationLabel(&DeleteAssociationLabelConfig{FromObjectType: "contacts", ToObjectType: "companies", AssociationTypeId: 101}); e != nil {
+		t.Fatal(e.Message())
+	}
+	labels, e = service.GetAssociationLabels(&GetAssociationLabelsConfig{FromObjectType: "contacts", ToObjectType: "companies"})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if len(*labels) != 1 || (*labels)[0].TypeId != 100 {
+		t.Errorf("got labels %+v, want only label 100", *labels)
+	}
+
+	if e := service.DeleteAssociationLabel(&DeleteAssociationLabelConfig{FromObjectType: "contacts", ToObjectType: "companies", AssociationTypeId: 101}); e == nil {
+		t.Error("expected an error deleting a missing label")
+	}
+	if _, e := service.CreateAssociationLabel(&CreateAssociationLabelConfig{FromObjectType: "contacts", ToObjectType: "companies"}); e == nil {
+		t.Error("expected an error creating a label without name")
+	}
+	if _, e := service.CreateAssociationLabel(nil); e == nil {
+		t.Error("expected an error for a nil config")
+	}
+}
+
+// associationsServer answers the v4 association reads of contacts to companies in pages of two,
+// contact i is associated with companies i*100+1 to i*100+counts[i], the first one labelled Primary
+type associationsServer struct {
+	counts map[string]int
+
+	mutex    sync.Mutex
+	requests int
+}
+
+// page returns the associations of contact id starting at after, and the after of the next page
+func (server *associationsServer) page(id string, after string) (string, string) {
+	contact, _ := strconv.Atoi(id)
+	offset, _ := strconv.Atoi(after)
+
+	rows := []string{}
+	for j := offset + 1; j <= min(offset+2, server.counts[id]); j++ {
+		label := `{"category":"USER_DEFINED","typeId":5,"label":"Billing"}`
+		if j == 1 {
+			label = `{"category":"HUBSPOT_DEFINED","typeId":1,"label":"Primary"}`
+		}
+		rows = append(rows, fmt.Sprintf(`{"toObjectId":%v,"associationTypes":[%s]}`, contact*100+j, label))
+	}
+
+	next := ""
+	if offset+2 < server.counts[id] {
+		next = strconv.Itoa(offset + 2)
+	}
+
+	return "[" + strings.Join(rows, ",") + "]", next
+}
+
+// pagingJson returns the paging member of a response with a next page at after, empty if there is none
+func pagingJson(after string) string {
+	if after == "" {
+		return ""
+	}
+	return fmt.Sprintf(`,"paging":{"next":{"after":"%s"}}`, after)
+}
+
+func (server *associationsServer) handle(w http.ResponseWriter, r *http.Request) {
+	server.mutex.Lock()
+	server.requests++
+	server.mutex.Unlock()
+
+	if r.URL.Path == "/crm/v4/associations/contacts/companies/batch/read" {
+		var body BatchGetAssociationsConfig
+		json.NewDecoder(r.Body).Decode(&body)
+
+		results := []string{}
+		for _, input := range body.Inputs {
+			after := ""
+			if input.After != nil {
+				after = *input.After
+			}
+			rows, next := server.page(input.Id, after)
+			results = append(results, fmt.Sprintf(`{"from":{"id":"%s"},"to":%s%s}`, input.Id, rows, pagingJson(next)))
+		}
+		writeJson(w, http.StatusOK, fmt.Sprintf(`{"status":"COMPLETE","results":[%s]}`, strings.Join(results, ",")))
+		return
+	}
+
+	id, ok := strings.CutPrefix(r.URL.Path, "/crm/v4/objects/contacts/")
+	id, ok2 := strings.CutSuffix(id, "/associations/companies")
+	if !ok || !ok2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	rows, next := server.page(id, r.URL.Query().Get("after"))
+	writeJson(w, http.StatusOK, fmt.Sprintf(`{"results":%s%s}`, rows, pagingJson(next)))
+}
+
+// checkAssociations fails unless to holds the companies of contact id in order, with their labels
+func checkAssociations(t *testing.T, server *associationsServer, id string, to []AssociationTo) {
+	t.Helper()
+
+	contact, _ := strconv.Atoi(id)
+	if len(to) != server.counts[id] {
+		t.Fatalf("got %v associations of contact %s, want %v", len(to), id, server.counts[id])
+	}
+	for j, association := range to {
+		if association.ToObjectId != int64(contact*100+j+1) {
+			t.Errorf("got company %v at %v, want %v", association.ToObjectId, j, contact*100+j+1)
+		}
+		if len(association.AssociationTypes) != 1 || association.AssociationTypes[0].Label == nil {
+			t.Fatalf("got association types %+v, want a label", association.AssociationTypes)
+		}
+		if label := *association.AssociationTypes[0].Label; (j == 0) != (label == "Primary") {
+			t.Errorf("got label %s for company %v", label, association.ToObjectId)
+		}
+	}
+}
+
+func TestGetAssociationsPaging(t *testing.T) {
+	server := associationsServer{counts: map[string]int{"1": 5}}
+	service := newTestService(t, server.handle, nil)
+
+	config := GetAssociationsConfig{FromObjectType: "contacts", FromObjectId: "1", ToObjectType: "companies"}
+	response, e := service.GetAssociations(&config)
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	checkAssociations(t, &server, "1", response.Results)
+	if server.requests != 3 || response.Paging != nil {
+		t.Errorf("got %v requests and paging %+v, want 3 and no paging", server.requests, response.Paging)
+	}
+
+	// an explicit after returns one page and the next one
+	after := "2"
+	config.After = &after
+	response, e = service.GetAssociations(&config)
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if len(response.Results) != 2 || response.Results[0].ToObjectId != 103 || response.Paging == nil || response.Paging.Next.After != "4" {
+		t.Errorf("got %+v, want companies 103 and 104 and the page after 4", response)
+	}
+}
+
+func TestBatchGetAssociationsPaging(t *testing.T) {
+	server := associationsServer{counts: map[string]int{"1": 5, "2": 1, "3": 4, "4": 0}}
+	service := newTestService(t, server.handle, nil)
+
+	config := BatchGetAssociationsConfig{
+		FromObjectType: "contacts",
+		ToObjectType:   "companies",
+		Inputs:         []BatchGetAssociationsInput{{Id: "1"}, {Id: "2"}, {Id: "3"}, {Id: "4"}},
+	}
+	associations, e := service.BatchGetAssociations(&config)
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+
+	if len(associations.Results) != 4 {
+		t.Fatalf("got %v results, want one per input", len(associations.Results))
+	}
+	for _, result := range associations.Results {
+		checkAssociations(t, &server, result.From.Id, result.To)
+	}
+	// the pages of contacts 1 and 3 are read together
+	if server.requests != 3 {
+		t.Errorf("got %v requests, want 3", server.requests)
+	}
+
+	// stop at the first result of the second batch, resuming at the checkpoint reads the remaining pages once
+	cursor := NewBatchAssociationsCursor(&config)
+	to := map[string][]AssociationTo{}
+	for association, err := range service.IterBatchGetAssociationsFrom(context.Background(), cursor) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, ok := to[association.From.Id]; ok {
+			break
+		}
+		to[association.From.Id] = append(to[association.From.Id], association.To...)
+	}
+	if len(cursor.Associations.Inputs) != 2 {
+		t.Fatalf("got cursor at %+v, want the next pages of contacts 1 and 3", cursor.Associations.Inputs)
+	}
+	resumed := resumeCursor(t, cursor)
+	for association, err := range service.IterBatchGetAssociationsFrom(context.Background(), resumed) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		to[association.From.Id] = append(to[association.From.Id], association.To...)
+	}
+	for _, id := range []string{"1", "2", "3"} {
+		checkAssociations(t, &server, id, to[id])
+	}
+}
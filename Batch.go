@@ -0,0 +1,399 @@
+package hubspot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	errortools "github.com/leapforce-libraries/go_errortools"
+	go_http "github.com/leapforce-libraries/go_http"
+)
+
+// BatchResponse is the response of the batch endpoints of the CRM objects
+type BatchResponse[T any] struct {
+	Status      string            `json:"status"`
+	Results     []T               `json:"results"`
+	NumErrors   int               `json:"numErrors"`
+	Errors      []BatchError      `json:"errors"`
+	RequestedAt *time.Time        `json:"requestedAt"`
+	StartedAt   *time.Time        `json:"startedAt"`
+	CompletedAt *time.Time        `json:"completedAt"`
+	Links       map[string]string `json:"links"`
+}
+
+// BatchError is an error HubSpot returns for one or more inputs of a partially failed batch (207)
+type BatchError struct {
+	ErrorResponse
+	Id string `json:"id"`
+}
+
+// BatchResult lists the objects a batch operation returned and the inputs that failed
+type BatchResult[T any] struct {
+	Successes      []T            `json:"successes"`
+	SuccessIndexes []int          `json:"successIndexes"` // index in config.Inputs of every success, -1 if it cannot be matched to an input
+	Failures       []BatchFailure `json:"failures"`
+}
+
+// batchResult is a result of a batch request, with the keys that match it to its input
+type batchResult[T any] struct {
+	result T
+	keys   batchResultKeys
+}
+
+type batchResultKeys struct {
+	Id                 string            `json:"id"`
+	ObjectWriteTraceId string            `json:"objectWriteTraceId"`
+	Properties         map[string]string `json:"properties"`
+	// associations
+	FromObjectId json.Number    `json:"fromObjectId"`
+	ToObjectId   json.Number    `json:"toObjectId"`
+	From         *AssociationId `json:"from"`
+	To           *AssociationId `json:"to"`
+}
+
+func (r *batchResult[T]) UnmarshalJSON(b []byte) error {
+	// results that are no objects have no keys
+	if json.Unmarshal(b, &r.keys) != nil {
+		r.keys = batchResultKeys{}
+	}
+
+	return json.Unmarshal(b, &r.result)
+}
+
+// BatchFailure is an input of a batch operation that failed
+type BatchFailure struct {
+	InputIndex     int             `json:"inputIndex"` // index in config.Inputs, -1 if HubSpot did not identify the input
+	Id             string          `json:"id,omitempty"`
+	Status         string          `json:"status,omitempty"`
+	Category       string          `json:"category"`
+	SubCategory    string          `json:"subCategory,omitempty"`
+	Message        string          `json:"message"`
+	PropertyErrors []PropertyError `json:"propertyErrors,omitempty"`
+	MissingScopes  []string        `json:"missingScopes,omitempty"`
+	Details        []ErrorDetail   `json:"details,omitempty"`
+}
+
+// batchInputIndexes maps the ids and objectWriteTraceIds of the inputs of a batch to their index in config.Inputs
+type batchInputIndexes struct {
+	ids          map[string]int
+	traceIds     map[string]int
+	idProperty   string                  // property the ids are values of, empty for object ids
+	associations map[associationEdge]int // inputs of association batches
+}
+
+// successIndex returns the index of the input of a result, by its objectWriteTraceId, id or the objects it associates
+func (indexes batchInputIndexes) successIndex(keys batchResultKeys) int {
+	if indexes.associations != nil {
+		edge := associationEdge{keys.FromObjectId.String(), keys.ToObjectId.String()}
+		if keys.From != nil && keys.To != nil {
+			edge = associationEdge{keys.From.Id, keys.To.Id}
+		}
+		if index, ok := indexes.associations[edge]; ok {
+			return index
+		}
+		return -1
+	}
+
+	if index, ok := indexes.traceIds[keys.ObjectWriteTraceId]; ok && keys.ObjectWriteTraceId != "" {
+		return index
+	}
+	id := keys.Id
+	if indexes.idProperty != "" && indexes.idProperty != "hs_object_id" {
+		id = keys.Properties[indexes.idProperty]
+	}
+	if index, ok := indexes.ids[id]; ok && id != "" {
+		return index
+	}
+	return -1
+}
+
+// associationInputIndexes maps the objects an association batch associates to their index in config.Inputs
+func associationInputIndexes(batch batch, keys func(i int) (string, []string)) batchInputIndexes {
+	indexes := batchInputIndexes{associations: make(map[associationEdge]int)}
+
+	for i := batch.startIndex; i < batch.endIndex; i++ {
+		fromId, toIds := keys(i)
+		for _, toId := range toIds {
+			indexes.associations[associationEdge{fromId, toId}] = i
+		}
+	}
+
+	return indexes
+}
+
+// failures returns a failure for every input batchError refers to
+func (indexes batchInputIndexes) failures(batchError BatchError) []BatchFailure {
+	failure := BatchFailure{
+		InputIndex:     -1,
+		Id:             batchError.Id,
+		Status:         batchError.Status,
+		Category:       batchError.Category,
+		SubCategory:    rawString(batchError.SubCategory),
+		Message:        batchError.Message,
+		PropertyErrors: propertyErrors(batchError.Message, batchError.Errors, batchError.Context),
+		MissingScopes:  missingScopes(&batchError.ErrorResponse),
+		Details:        batchError.Errors,
+	}
+
+	var failures []BatchFailure
+
+	for _, traceId := range rawStrings(batchError.Context["objectWriteTraceId"]) {
+		if index, ok := indexes.traceIds[traceId]; ok {
+			failure.InputIndex = index
+			failures = append(failures, failure)
+		}
+	}
+	if len(failures) > 0 {
+		return failures
+	}
+
+	if indexes.associations != nil {
+		return indexes.associationFailures(batchError, failure)
+	}
+
+	for _, id := range rawStrings(batchError.Context["ids"]) {
+		failure.Id = id
+		failure.InputIndex = -1
+		if index, ok := indexes.ids[id]; ok {
+			failure.InputIndex = index
+		}
+		failures = append(failures, failure)
+	}
+	if len(failures) > 0 {
+		return failures
+	}
+
+	if index, ok := indexes.ids[batchError.Id]; ok && batchError.Id != "" {
+		failure.InputIndex = index
+	}
+
+	return []BatchFailure{failure}
+}
+
+// associationFailures returns a failure for every input of an association batch with the fromObjectId, and toObjectId if any, batchError refers to
+func (indexes batchInputIndexes) associationFailures(batchError BatchError, failure BatchFailure) []BatchFailure {
+	fromIds := rawStrings(batchError.Context["fromObjectId"])
+	toIds := rawStrings(batchError.Context["toObjectId"])
+
+	var inputIndexes []int
+	for edge, index := range indexes.associations {
+		if slices.Contains(fromIds, edge.fromId) && (len(toIds) == 0 || slices.Contains(toIds, edge.toId)) && !slices.Contains(inputIndexes, index) {
+			inputIndexes = append(inputIndexes, index)
+		}
+	}
+	if len(inputIndexes) == 0 {
+		return []BatchFailure{failure}
+	}
+	slices.Sort(inputIndexes)
+
+	var failures []BatchFailure
+	for _, index := range inputIndexes {
+		failure.InputIndex = index
+		failures = append(failures, failure)
+	}
+
+	return failures
+}
+
+// batchBody returns the request body for batch, inputs without ObjectWriteTraceId get their index as trace id to identify failures
+func (config *BatchObjectsConfig) batchBody(batch batch) BatchObjectsConfig {
+	inputs := make([]BatchObjectInput, 0, batch.endIndex-batch.startIndex)
+
+	for i, input := range config.Inputs[batch.startIndex:batch.endIndex] {
+		if input.IdProperty == nil {
+			input.IdProperty = config.IdProperty
+		}
+		if input.ObjectWriteTraceId == nil {
+			traceId := strconv.Itoa(batch.startIndex + i)
+			input.ObjectWriteTraceId = &traceId
+		}
+		inputs = append(inputs, input)
+	}
+
+	return BatchObjectsConfig{Inputs: inputs}
+}
+
+// batchInputIndexes must be called with the inputs of batchBody
+func (config *BatchObjectsConfig) batchInputIndexes(batch batch, inputs []BatchObjectInput) batchInputIndexes {
+	indexes := batchInputIndexes{ids: make(map[string]int), traceIds: make(map[string]int)}
+
+	for i, input := range inputs {
+		if input.Id != nil {
+			indexes.ids[*input.Id] = batch.startIndex + i
+		}
+		if input.ObjectWriteTraceId != nil {
+			indexes.traceIds[*input.ObjectWriteTraceId] = batch.startIndex + i
+		}
+	}
+
+	return indexes
+}
+
+func (config *BatchGetObjectsConfig) batchInputIndexes(batch batch) batchInputIndexes {
+	indexes := batchInputIndexes{ids: make(map[string]int), idProperty: config.IdProperty}
+
+	for i, input := range config.Inputs[batch.startIndex:batch.endIndex] {
+		indexes.ids[input.Id] = batch.startIndex + i
+	}
+
+	return indexes
+}
+
+// batchObjects sends config.Inputs to the batch endpoint of action in batches of 100
+func batchObjects[R any](ctx context.Context, service *Service, objectType ObjectType, action string, config *BatchObjectsConfig) (*BatchResult[R], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
+	}
+
+	batches := service.batches(len(config.Inputs))
+	results := make([]BatchResult[R], len(batches))
+
+	e := service.forEachBatch(ctx, batches, func(ctx context.Context, i int, batch batch) *errortools.Error {
+		body := config.batchBody(batch)
+
+		return sendObjects(ctx, service, objectType, action, config, batch, body, config.batchInputIndexes(batch, body.Inputs), &results[i])
+	})
+	if e != nil {
+		return nil, e
+	}
+
+	return mergeBatchResults(results), nil
+}
+
+// mergeBatchResults merges the results of the batches in input order
+func mergeBatchResults[R any](results []BatchResult[R]) *BatchResult[R] {
+	result := BatchResult[R]{Successes: []R{}, SuccessIndexes: []int{}}
+
+	for _, r := range results {
+		result.Successes = append(result.Successes, r.Successes...)
+		result.SuccessIndexes = append(result.SuccessIndexes, r.SuccessIndexes...)
+		result.Failures = append(result.Failures, r.Failures...)
+	}
+
+	return &result
+}
+
+// sendObjects sends body, recovering from invalid property values by config.InvalidPropertyPolicy.
+// If config.Bisect is set a rejected body is split in halves until the rejected inputs are isolated.
+func sendObjects[R any](ctx context.Context, service *Service, objectType ObjectType, action string, config *BatchObjectsConfig, batch batch, body BatchObjectsConfig, indexes batchInputIndexes, result *BatchResult[R]) *errortools.Error {
+	for retries := 0; len(body.Inputs) > 0; retries++ {
+		e := sendBatch(ctx, service, objectType, action, batch, body, indexes, result)
+		if e == nil {
+			return nil
+		}
+
+		if retries < maxInvalidPropertyRetries {
+			failures, ok := service.recoverInvalidProperties(config.InvalidPropertyPolicy, objectType, &body, indexes, e)
+			if ok {
+				result.Failures = append(result.Failures, failures...)
+				continue
+			}
+		}
+
+		apiError := rejectedInputsError(e)
+		if config.Bisect == nil || !*config.Bisect || apiError == nil {
+			return e
+		}
+
+		if len(body.Inputs) == 1 {
+			result.Failures = append(result.Failures, indexes.inputFailure(body.Inputs[0], apiError))
+			return nil
+		}
+
+		half := len(body.Inputs) / 2
+
+		service.logger.Debug(fmt.Sprintf("batch %s rejected, bisecting", action), "objectType", objectType, "startIndex", batch.startIndex, "inputs", len(body.Inputs))
+
+		for _, inputs := range [][]BatchObjectInput{body.Inputs[:half], body.Inputs[half:]} {
+			e := sendObjects(ctx, service, objectType, action, config, batch, BatchObjectsConfig{Inputs: inputs}, indexes, result)
+			if e != nil {
+				return e
+			}
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+// rejectedInputsError returns the error if HubSpot rejected a batch because of its inputs, a validation error or a conflict
+func rejectedInputsError(e *errortools.Error) *ApiError {
+	err := AsError(e)
+
+	var validationError *ValidationError
+	var conflictError *ConflictError
+	if !errors.As(err, &validationError) && !errors.As(err, &conflictError) {
+		return nil
+	}
+
+	var apiError *ApiError
+	errors.As(err, &apiError)
+
+	return apiError
+}
+
+// inputFailure returns the failure of input rejected by apiError
+func (indexes batchInputIndexes) inputFailure(input BatchObjectInput, apiError *ApiError) BatchFailure {
+	failure := BatchFailure{
+		InputIndex:     -1,
+		Status:         "error",
+		Category:       apiError.Category,
+		SubCategory:    apiError.SubCategory,
+		Message:        apiError.Message,
+		PropertyErrors: propertyErrors(apiError.Message, apiError.Details, nil),
+		Details:        apiError.Details,
+	}
+	if input.ObjectWriteTraceId != nil {
+		if index, ok := indexes.traceIds[*input.ObjectWriteTraceId]; ok {
+			failure.InputIndex = index
+		}
+	}
+	if input.Id != nil {
+		failure.Id = *input.Id
+	}
+
+	return failure
+}
+
+// sendBatch sends one batch, the failures of a partially failed batch are added to result
+func sendBatch[R any](ctx context.Context, service *Service, objectType ObjectType, action string, batch batch, body any, indexes batchInputIndexes, result *BatchResult[R]) *errortools.Error {
+	return sendBatchTo(ctx, service, service.urlCrm(fmt.Sprintf("objects/%s/batch/%s", objectType, action)), objectType, action, batch, body, indexes, result)
+}
+
+// sendBatchTo sends one batch to url, the failures of a partially failed batch are added to result
+func sendBatchTo[R any](ctx context.Context, service *Service, url string, objectType ObjectType, action string, batch batch, body any, indexes batchInputIndexes, result *BatchResult[R]) *errortools.Error {
+	var r BatchResponse[batchResult[R]]
+
+	requestConfig := go_http.RequestConfig{
+		Method:        http.MethodPost,
+		Url:           url,
+		BodyModel:     body,
+		ResponseModel: &r,
+	}
+
+	_, response, e := service.httpRequest(ctx, &requestConfig)
+	if response != nil && response.StatusCode == http.StatusMultiStatus {
+		service.logger.Warn(fmt.Sprintf("batch %s partially failed", action), "objectType", objectType, "startIndex", batch.startIndex, "numErrors", r.NumErrors)
+
+		for _, batchError := range r.Errors {
+			result.Failures = append(result.Failures, indexes.failures(batchError)...)
+		}
+	} else if e != nil && (response == nil || response.StatusCode != http.StatusNoContent) {
+		return e
+	}
+
+	for _, success := range r.Results {
+		result.Successes = append(result.Successes, success.result)
+		result.SuccessIndexes = append(result.SuccessIndexes, indexes.successIndex(success.keys))
+	}
+
+	service.logger.Debug(fmt.Sprintf("batch %s done", action), "objectType", objectType, "startIndex", batch.startIndex, "endIndex", batch.endIndex)
+
+	return nil
+}
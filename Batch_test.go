@@ -0,0 +1,256 @@
+package hubspot
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBatchSuccessIndexes(t *testing.T) {
+	// every input with an email ending in 7 fails
+	server := batchServer{respond: func(request int, inputs []BatchObjectInput) (int, string) {
+		var created []BatchObjectInput
+		var errors []string
+		for _, input := range inputs {
+			if input.Properties["email"][len(input.Properties["email"])-1] == '7' {
+				errors = append(errors, fmt.Sprintf(`{"status":"error","category":"VALIDATION_ERROR","message":"invalid","context":{"objectWriteTraceId":["%s"]}}`, *input.ObjectWriteTraceId))
+				continue
+			}
+			created = append(created, input)
+		}
+		if len(errors) == 0 {
+			return http.StatusCreated, batchCreated(created)
+		}
+		response := batchCreated(created)
+		return http.StatusMultiStatus, fmt.Sprintf(`{"errors":[%s],"results":%s}`, strings.Join(errors, ","), response[strings.Index(response, "["):strings.LastIndex(response, "]")+1])
+	}}
+	service := newTestService(t, server.handle, &ServiceConfig{BatchConcurrency: intPointer(3)})
+
+	var inputs []BatchObjectInput
+	for i := range 250 {
+		inputs = append(inputs, BatchObjectInput{Properties: map[string]string{"email": strconv.Itoa(i)}})
+	}
+
+	result, e := NewObjects[Object](service, ObjectTypeContacts).BatchCreate(&BatchObjectsConfig{Inputs: inputs})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+
+	if len(result.Failures) != 25 || len(result.Successes) != 225 || len(result.SuccessIndexes) != 225 {
+		t.Fatalf("expected 225 successes and 25 failures, got %d and %d", len(result.Successes), len(result.Failures))
+	}
+	for i, object := range result.Successes {
+		if object.Properties["email"] != inputs[result.SuccessIndexes[i]].Properties["email"] {
+			t.Errorf("success %d does not match input %d", i, result.SuccessIndexes[i])
+		}
+	}
+	for _, failure := range result.Failures {
+		if failure.InputIndex%10 != 7 {
+			t.Errorf("unexpected failure of input %d", failure.InputIndex)
+		}
+	}
+}
+
+func TestBatchBisect(t *testing.T) {
+	// a batch holding an input with email bad is rejected as a whole
+	server := batchServer{respond: func(request int, inputs []BatchObjectInput) (int, string) {
+		for _, input := range inputs {
+			if input.Properties["email"] == "bad" {
+				return http.StatusBadRequest, `{"status":"error","category":"VALIDATION_ERROR","message":"invalid"}`
+			}
+		}
+		return http.StatusCreated, batchCreated(inputs)
+	}}
+	service := newTestService(t, server.handle, nil)
+
+	var inputs []BatchObjectInput
+	for i := range 8 {
+		email := strconv.Itoa(i)
+		if i == 5 {
+			email = "bad"
+		}
+		inputs = append(inputs, BatchObjectInput{Properties: map[string]string{"email": email}})
+	}
+
+	bisect := true
+	result, e := NewObjects[Object](service, ObjectTypeContacts).BatchCreate(&BatchObjectsConfig{Inputs: inputs, Bisect: &bisect})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+
+	if len(result.Failures) != 1 || result.Failures[0].InputIndex != 5 {
+		t.Fatalf("expected input 5 to fail, got %+v", result.Failures)
+	}
+
+	indexes := slices.Clone(result.SuccessIndexes)
+	slices.Sort(indexes)
+	if !slices.Equal(indexes, []int{0, 1, 2, 3, 4, 6, 7}) {
+		t.Errorf("unexpected success indexes %v", result.SuccessIndexes)
+	}
+	for i, object := range result.Successes {
+		if object.Properties["email"] != inputs[result.SuccessIndexes[i]].Properties["email"] {
+			t.Errorf("success %d does not match input %d", i, result.SuccessIndexes[i])
+		}
+	}
+}
+
+func TestBatchReadSuccessIndexes(t *testing.T) {
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusOK, `{"results":[{"id":"3","properties":{"email":"c@d.e"}},{"id":"1","properties":{"email":"a@b.c"}}]}`)
+	}, nil)
+
+	tests := []struct {
+		name       string
+		idProperty string
+		inputs     []BatchGetObjectsInput
+	}{
+		{"id", "", []BatchGetObjectsInput{{Id: "1"}, {Id: "2"}, {Id: "3"}}},
+		{"id property", "email", []BatchGetObjectsInput{{Id: "a@b.c"}, {Id: "x@y.z"}, {Id: "c@d.e"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, e := NewObjects[Object](service, ObjectTypeContacts).BatchRead(&BatchGetObjectsConfig{IdProperty: test.idProperty, Inputs: test.inputs})
+			if e != nil {
+				t.Fatal(e.Message())
+			}
+			if !slices.Equal(result.SuccessIndexes, []int{2, 0}) {
+				t.Errorf("unexpected success indexes %v", result.SuccessIndexes)
+			}
+		})
+	}
+}
+
+func TestBatchUpsert(t *testing.T) {
+	var paths []string
+
+	// inputs with an even email exist already
+	server := batchServer{respond: func(request int, inputs []BatchObjectInput) (int, string) {
+		var results []string
+		for _, input := range slices.Backward(inputs) {
+			if *input.IdProperty != "email" || input.Properties["email"] != "" {
+				return http.StatusBadRequest, `{"status":"error","category":"VALIDATION_ERROR","message":"unexpected input"}`
+			}
+			i, _ := strconv.Atoi(*input.Id)
+			results = append(results, fmt.Sprintf(`{"id":"id%v","objectWriteTraceId":"%s","new":%v,"properties":{"email":"%s"}}`, i, *input.ObjectWriteTraceId, i%2 == 1, *input.Id))
+		}
+		return http.StatusOK, fmt.Sprintf(`{"status":"COMPLETE","results":[%s]}`, strings.Join(results, ","))
+	}}
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		server.mutex.Lock()
+		paths = append(paths, r.URL.Path)
+		server.mutex.Unlock()
+		server.handle(w, r)
+	}, nil)
+
+	var inputs []BatchObjectInput
+	for i := range 150 {
+		email := strconv.Itoa(i)
+		inputs = append(inputs, BatchObjectInput{Id: &email, Properties: map[string]string{"firstname": "x"}})
+	}
+
+	idProperty := "email"
+	result, e := NewObjects[Object](service, ObjectTypeContacts).BatchUpsert(&BatchObjectsConfig{IdProperty: &idProperty, Inputs: inputs})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+
+	if len(server.requests) != 2 || paths[0] != "/crm/v3/objects/contacts/batch/upsert" {
+		t.Fatalf("got requests to %v, want 2 to the upsert endpoint", paths)
+	}
+	if len(result.Successes) != 150 || len(result.Failures) != 0 {
+		t.Fatalf("got %v successes and %v failures, want 150 and 0", len(result.Successes), len(result.Failures))
+	}
+	created := 0
+	for i, upserted := range result.Successes {
+		index := result.SuccessIndexes[i]
+		if upserted.Object.Properties["email"] != *inputs[index].Id {
+			t.Errorf("success %d does not match input %d", i, index)
+		}
+		if upserted.New != (index%2 == 1) {
+			t.Errorf("got new %v for input %d", upserted.New, index)
+		}
+		if upserted.New {
+			created++
+		}
+	}
+	if created != 75 {
+		t.Errorf("got %v created objects, want 75", created)
+	}
+
+	// an upsert needs the unique value and property of every input
+	if _, e := service.BatchUpsertObjects(&BatchObjectsConfig{ObjectType: "contacts", Inputs: []BatchObjectInput{{Id: &idProperty}}}); e == nil {
+		t.Error("expected an error for an input without IdProperty")
+	}
+	if _, e := service.BatchUpsertObjects(&BatchObjectsConfig{ObjectType: "contacts", IdProperty: &idProperty, Inputs: []BatchObjectInput{{}}}); e == nil {
+		t.Error("expected an error for an input without Id")
+	}
+}
+
+func TestBatchBisectUpdate(t *testing.T) {
+	// a batch holding input 17 is rejected as invalid, a batch holding input 60 as a conflict
+	server := batchServer{respond: func(request int, inputs []BatchObjectInput) (int, string) {
+		for _, input := range inputs {
+			switch *input.Id {
+			case "17":
+				return http.StatusBadRequest, `{"status":"error","category":"VALIDATION_ERROR","message":"invalid 17"}`
+			case "60":
+				return http.StatusConflict, `{"status":"error","category":"CONFLICT","message":"conflict 60"}`
+			case "forbidden":
+				return http.StatusForbidden, `{"status":"error","category":"MISSING_SCOPES","message":"forbidden"}`
+			}
+		}
+		return http.StatusOK, batchCreated(inputs)
+	}}
+	service := newTestService(t, server.handle, nil)
+
+	var inputs []BatchObjectInput
+	for i := range 100 {
+		id := strconv.Itoa(i)
+		inputs = append(inputs, BatchObjectInput{Id: &id, Properties: map[string]string{"firstname": "x"}})
+	}
+
+	bisect := true
+	result, e := NewObjects[Object](service, ObjectTypeContacts).BatchUpdate(&BatchObjectsConfig{Inputs: inputs, Bisect: &bisect})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+
+	if len(result.Successes) != 98 || len(result.Failures) != 2 {
+		t.Fatalf("got %v successes and %v failures, want 98 and 2", len(result.Successes), len(result.Failures))
+	}
+	slices.SortFunc(result.Failures, func(a BatchFailure, b BatchFailure) int { return a.InputIndex - b.InputIndex })
+	if failure := result.Failures[0]; failure.InputIndex != 17 || failure.Id != "17" || failure.Category != "VALIDATION_ERROR" || failure.Message != "invalid 17" {
+		t.Errorf("unexpected failure %+v", failure)
+	}
+	if failure := result.Failures[1]; failure.InputIndex != 60 || failure.Category != "CONFLICT" {
+		t.Errorf("unexpected failure %+v", failure)
+	}
+	// every level of the bisection splits the halves holding a rejected input, about 2*log2(100) requests per input
+	if len(server.requests) > 30 {
+		t.Errorf("got %v requests, want at most 30", len(server.requests))
+	}
+
+	// without Bisect the batch fails as a whole
+	server.requests = nil
+	if _, e := NewObjects[Object](service, ObjectTypeContacts).BatchUpdate(&BatchObjectsConfig{Inputs: inputs}); e == nil {
+		t.Error("expected the rejected batch to fail")
+	}
+	if len(server.requests) != 1 {
+		t.Errorf("got %v requests, want 1", len(server.requests))
+	}
+
+	// errors other than rejected inputs are not bisected
+	server.requests = nil
+	forbidden := "forbidden"
+	inputs[50].Id = &forbidden
+	if _, e := NewObjects[Object](service, ObjectTypeContacts).BatchUpdate(&BatchObjectsConfig{Inputs: inputs[20:60], Bisect: &bisect}); e == nil {
+		t.Error("expected the forbidden batch to fail")
+	}
+	if len(server.requests) != 1 {
+		t.Errorf("got %v requests, want 1", len(server.requests))
+	}
+}
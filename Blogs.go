@@ -1,9 +1,11 @@
 package hubspot
 
 import (
+	"context"
 	"fmt"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
+	"iter"
 	"net/http"
 	"net/url"
 )
@@ -26,8 +28,27 @@ type GetBlogsConfig struct {
 
 // GetBlogPosts returns all blog posts
 func (service *Service) GetBlogPosts(config *GetBlogsConfig) (*[]BlogPost, *errortools.Error) {
+	return service.GetBlogPostsWithContext(context.Background(), config)
+}
+
+func (service *Service) GetBlogPostsWithContext(ctx context.Context, config *GetBlogsConfig) (*[]BlogPost, *errortools.Error) {
+	return collect(service.iterBlogPosts(ctx, config, config != nil && config.After != nil))
+}
+
+// IterBlogPosts streams all blog posts page by page, starting at config.After
+func (service *Service) IterBlogPosts(ctx context.Context, config *GetBlogsConfig) iter.Seq2[BlogPost, error] {
+	return iterErrors(service.iterBlogPosts(ctx, config, false))
+}
+
+func (service *Service) iterBlogPosts(ctx context.Context, config *GetBlogsConfig, singlePage bool) iter.Seq2[BlogPost, *errortools.Error] {
+	return func(yield func(BlogPost, *errortools.Error) bool) {
+		service.iterBlogPostsFrom(ctx, NewBlogPostsCursor(config), singlePage)(yield)
+	}
+}
+
+// NewBlogPostsCursor returns a cursor at the start of GetBlogPosts, or at config.After
+func NewBlogPostsCursor(config *GetBlogsConfig) *Cursor {
 	values := url.Values{}
-	endpoint := "blogs/posts"
 
 	if config != nil {
 		if config.Limit != nil {
@@ -43,50 +64,33 @@ func (service *Service) GetBlogPosts(config *GetBlogsConfig) (*[]BlogPost, *erro
 		}
 	}
 
-	var blogPosts []BlogPost
-
-	for {
-		blogPostsResponse := BlogPostsResponse{}
-
-		if after != "" {
-			values.Set("after", after)
-		}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodGet,
-			Url:           service.urlCms(fmt.Sprintf("%s?%s", endpoint, values.Encode())),
-			ResponseModel: &blogPostsResponse,
-		}
-
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
-
-		blogPosts = append(blogPosts, blogPostsResponse.Results...)
-
-		if config != nil {
-			if config.After != nil { // explicit after parameter requested
-				break
-			}
-		}
-
-		if blogPostsResponse.Paging == nil {
-			break
-		}
+	return &Cursor{ObjectType: "blog_posts", Query: values, After: after}
+}
 
-		if blogPostsResponse.Paging.Next.After == "" {
-			break
-		}
+// IterBlogPostsFrom resumes IterBlogPosts at cursor
+func (service *Service) IterBlogPostsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[BlogPost, error] {
+	return iterErrors(service.iterBlogPostsFrom(ctx, cursor, false))
+}
 
-		after = blogPostsResponse.Paging.Next.After
+func (service *Service) iterBlogPostsFrom(ctx context.Context, cursor *Cursor, singlePage bool) iter.Seq2[BlogPost, *errortools.Error] {
+	if e := cursor.check("blog_posts"); e != nil {
+		return iterError[BlogPost](e)
 	}
 
-	return &blogPosts, nil
+	return iterPages[BlogPost](ctx, service, cursor, singlePage, func(after string) go_http.RequestConfig {
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlCms(fmt.Sprintf("blogs/posts?%s", encodeWithParam(cursor.Query, "after", after))),
+		}
+	})
 }
 
 // DeleteBlogPost deletes a specific blog post
 func (service *Service) DeleteBlogPost(id string) *errortools.Error {
+	return service.DeleteBlogPostWithContext(context.Background(), id)
+}
+
+func (service *Service) DeleteBlogPostWithContext(ctx context.Context, id string) *errortools.Error {
 	endpoint := "blogs/posts"
 
 	requestConfig := go_http.RequestConfig{
@@ -94,7 +98,7 @@ func (service *Service) DeleteBlogPost(id string) *errortools.Error {
 		Url:    service.urlCms(fmt.Sprintf("%s/%s", endpoint, id)),
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return e
 	}
@@ -1,8 +1,10 @@
 package hubspot
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"net/http"
 	"net/url"
 	"strings"
@@ -40,8 +42,27 @@ type GetCompaniesConfig struct {
 
 // GetCompanies returns all companies
 func (service *Service) GetCompanies(config *GetCompaniesConfig) (*[]Company, *errortools.Error) {
+	return service.GetCompaniesWithContext(context.Background(), config)
+}
+
+func (service *Service) GetCompaniesWithContext(ctx context.Context, config *GetCompaniesConfig) (*[]Company, *errortools.Error) {
+	return collect(service.iterCompanies(ctx, config, config != nil && config.After != nil))
+}
+
+// IterCompanies streams all companies page by page, starting at config.After
+func (service *Service) IterCompanies(ctx context.Context, config *GetCompaniesConfig) iter.Seq2[Company, error] {
+	return iterErrors(service.iterCompanies(ctx, config, false))
+}
+
+func (service *Service) iterCompanies(ctx context.Context, config *GetCompaniesConfig, singlePage bool) iter.Seq2[Company, *errortools.Error] {
+	return func(yield func(Company, *errortools.Error) bool) {
+		service.iterCompaniesFrom(ctx, NewCompaniesCursor(config), singlePage)(yield)
+	}
+}
+
+// NewCompaniesCursor returns a cursor at the start of GetCompanies, or at config.After
+func NewCompaniesCursor(config *GetCompaniesConfig) *Cursor {
 	values := url.Values{}
-	endpoint := "objects/companies"
 
 	if config != nil {
 		if config.Limit != nil {
@@ -67,51 +88,36 @@ func (service *Service) GetCompanies(config *GetCompaniesConfig) (*[]Company, *e
 	}
 
 	after := ""
-	if config.After != nil {
+	if config != nil && config.After != nil {
 		after = *config.After
 	}
 
-	companies := []Company{}
-
-	for {
-		companiesResponse := CompaniesResponse{}
-
-		if after != "" {
-			values.Set("after", after)
-		}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodGet,
-			Url:           service.urlCrm(fmt.Sprintf("%s?%s", endpoint, values.Encode())),
-			ResponseModel: &companiesResponse,
-		}
-
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
-
-		companies = append(companies, companiesResponse.Results...)
-
-		if config.After != nil { // explicit after parameter requested
-			break
-		}
-
-		if companiesResponse.Paging == nil {
-			break
-		}
+	return &Cursor{ObjectType: string(ObjectTypeCompanies), Query: values, After: after}
+}
 
-		if companiesResponse.Paging.Next.After == "" {
-			break
-		}
+// IterCompaniesFrom resumes IterCompanies at cursor
+func (service *Service) IterCompaniesFrom(ctx context.Context, cursor *Cursor) iter.Seq2[Company, error] {
+	return iterErrors(service.iterCompaniesFrom(ctx, cursor, false))
+}
 
-		after = companiesResponse.Paging.Next.After
+func (service *Service) iterCompaniesFrom(ctx context.Context, cursor *Cursor, singlePage bool) iter.Seq2[Company, *errortools.Error] {
+	if e := cursor.check(string(ObjectTypeCompanies)); e != nil {
+		return iterError[Company](e)
 	}
 
-	return &companies, nil
+	return iterPages[Company](ctx, service, cursor, singlePage, func(after string) go_http.RequestConfig {
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlCrm(fmt.Sprintf("objects/%s?%s", cursor.ObjectType, encodeWithParam(cursor.Query, "after", after))),
+		}
+	})
 }
 
 func (service *Service) CreateCompany(config *CreateObjectConfig) (*Company, *errortools.Error) {
+	return service.CreateCompanyWithContext(context.Background(), config)
+}
+
+func (service *Service) CreateCompanyWithContext(ctx context.Context, config *CreateObjectConfig) (*Company, *errortools.Error) {
 	endpoint := "objects/companies"
 	company := Company{}
 
@@ -122,7 +128,7 @@ func (service *Service) CreateCompany(config *CreateObjectConfig) (*Company, *er
 		ResponseModel: &company,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -130,58 +136,9 @@ func (service *Service) CreateCompany(config *CreateObjectConfig) (*Company, *er
 	return &company, nil
 }
 
-func (service *Service) BatchCreateCompanies(config *BatchObjectsConfig, invalidEmailProperty string) (*[]Company, *errortools.Error) {
-	var companies []Company
-	var retrying = false
-
-	for _, batch := range service.batches(len(config.Inputs)) {
-	retry:
-		var r BatchCompaniesResponse
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(fmt.Sprintf("objects/%s/batch/create", config.ObjectType)),
-			BodyModel:     BatchObjectsConfig{Inputs: config.Inputs[batch.startIndex:batch.endIndex]},
-			ResponseModel: &r,
-		}
-
-		_, response, e := service.httpRequest(&requestConfig)
-		if response != nil {
-			if response.StatusCode == http.StatusMultiStatus {
-				fmt.Println(r.Errors)
-				goto ok
-			}
-
-			if response.StatusCode == http.StatusBadRequest && !retrying {
-				errorResponse := service.ErrorResponse()
-				if errorResponse != nil {
-					if strings.HasPrefix(errorResponse.Message, "Property values were not valid: ") {
-						stop := checkInvalidEmails(config, invalidEmailProperty, errorResponse.Message, batch)
-
-						if stop {
-							goto stop
-						}
-
-						retrying = true
-						goto retry
-					}
-				}
-			}
-		}
-
-	stop:
-		if e != nil {
-			return nil, e
-		}
-	ok:
-		companies = append(companies, r.Results...)
-
-		fmt.Println("batch", batch.startIndex)
-	}
-
-	return &companies, nil
-}
-
+// BatchCompaniesResponse is the response of a batch request of companies
+//
+// Deprecated: the batch functions return a BatchResult
 type BatchCompaniesResponse struct {
 	CompletedAt *time.Time        `json:"completedAt"`
 	NumErrors   int               `json:"numErrors"`
@@ -210,55 +167,59 @@ type BatchCompaniesResponse struct {
 	Status string `json:"status"`
 }
 
-func (service *Service) BatchUpdateCompanies(config *BatchObjectsConfig, invalidEmailProperty string) (*[]Company, *errortools.Error) {
-	var companies []Company
+// BatchCreateCompanies returns the created companies, the inputs that failed are only logged
+//
+// Deprecated: use BatchCreateCompaniesWithResult, which also returns the inputs that failed
+func (service *Service) BatchCreateCompanies(config *BatchObjectsConfig, invalidEmailProperty string) (*[]Company, *errortools.Error) {
+	result, e := service.BatchCreateCompaniesWithResultWithContext(context.Background(), config, invalidEmailProperty)
+	if e != nil {
+		return nil, e
+	}
 
-	for _, batch := range service.batches(len(config.Inputs)) {
-	retry:
-		var r BatchCompaniesResponse
+	return &result.Successes, nil
+}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(fmt.Sprintf("objects/%s/batch/update", config.ObjectType)),
-			BodyModel:     BatchObjectsConfig{Inputs: config.Inputs[batch.startIndex:batch.endIndex]},
-			ResponseModel: &r,
-		}
+func (service *Service) BatchCreateCompaniesWithResult(config *BatchObjectsConfig, invalidEmailProperty string) (*BatchResult[Company], *errortools.Error) {
+	return service.BatchCreateCompaniesWithResultWithContext(context.Background(), config, invalidEmailProperty)
+}
 
-		_, response, e := service.httpRequest(&requestConfig)
-		if response != nil {
-			if response.StatusCode == http.StatusMultiStatus {
-				fmt.Println(r.Errors)
-				goto ok
-			}
-			if response.StatusCode == http.StatusBadRequest {
-				errorResponse := service.ErrorResponse()
-				if errorResponse != nil {
-					if strings.HasPrefix(errorResponse.Message, "Property values were not valid: ") {
-						stop := checkInvalidEmails(config, invalidEmailProperty, errorResponse.Message, batch)
-
-						if stop {
-							goto stop
-						}
-
-						goto retry
-					}
-				}
-			}
-		}
-	stop:
-		if e != nil {
-			return nil, e
-		}
-	ok:
-		companies = append(companies, r.Results...)
+func (service *Service) BatchCreateCompaniesWithResultWithContext(ctx context.Context, config *BatchObjectsConfig, invalidEmailProperty string) (*BatchResult[Company], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
+	}
+
+	return batchObjects[Company](ctx, service, ObjectType(config.ObjectType), "create", withInvalidEmailProperty(config, invalidEmailProperty))
+}
 
-		fmt.Println("batch", batch.startIndex)
+// BatchUpdateCompanies returns the updated companies, the inputs that failed are only logged
+//
+// Deprecated: use BatchUpdateCompaniesWithResult, which also returns the inputs that failed
+func (service *Service) BatchUpdateCompanies(config *BatchObjectsConfig, invalidEmailProperty string) (*[]Company, *errortools.Error) {
+	result, e := service.BatchUpdateCompaniesWithResultWithContext(context.Background(), config, invalidEmailProperty)
+	if e != nil {
+		return nil, e
 	}
 
-	return &companies, nil
+	return &result.Successes, nil
+}
+
+func (service *Service) BatchUpdateCompaniesWithResult(config *BatchObjectsConfig, invalidEmailProperty string) (*BatchResult[Company], *errortools.Error) {
+	return service.BatchUpdateCompaniesWithResultWithContext(context.Background(), config, invalidEmailProperty)
+}
+
+func (service *Service) BatchUpdateCompaniesWithResultWithContext(ctx context.Context, config *BatchObjectsConfig, invalidEmailProperty string) (*BatchResult[Company], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
+	}
+
+	return batchObjects[Company](ctx, service, ObjectType(config.ObjectType), "update", withInvalidEmailProperty(config, invalidEmailProperty))
 }
 
 func (service *Service) UpdateCompany(config *UpdateObjectConfig) (*Company, *errortools.Error) {
+	return service.UpdateCompanyWithContext(context.Background(), config)
+}
+
+func (service *Service) UpdateCompanyWithContext(ctx context.Context, config *UpdateObjectConfig) (*Company, *errortools.Error) {
 	endpoint := "objects/companies"
 	company := Company{}
 
@@ -269,7 +230,7 @@ func (service *Service) UpdateCompany(config *UpdateObjectConfig) (*Company, *er
 		ResponseModel: &company,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -285,6 +246,10 @@ type GetCompanyConfig struct {
 
 // GetCompany returns a specific company
 func (service *Service) GetCompany(config *GetCompanyConfig) (*Company, *errortools.Error) {
+	return service.GetCompanyWithContext(context.Background(), config)
+}
+
+func (service *Service) GetCompanyWithContext(ctx context.Context, config *GetCompanyConfig) (*Company, *errortools.Error) {
 	values := url.Values{}
 	endpoint := "objects/companies"
 
@@ -319,7 +284,7 @@ func (service *Service) GetCompany(config *GetCompanyConfig) (*Company, *errorto
 		ResponseModel: &company,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -331,7 +296,7 @@ type FilterGroup struct {
 	Filters *[]filter `json:"filters"`
 }
 
-func (fg *FilterGroup) AddPropertyFilter(operator string, property string, value string, highValue string) {
+func (fg *FilterGroup) AddPropertyFilter(operator Operator, property string, value string, highValue string) {
 	if fg.Filters == nil {
 		fg.Filters = &[]filter{}
 	}
@@ -345,7 +310,7 @@ func (fg *FilterGroup) AddPropertyFilter(operator string, property string, value
 	})
 }
 
-func (fg *FilterGroup) AddCustomPropertyFilter(operator string, propertyName string, value string, highValue string) {
+func (fg *FilterGroup) AddCustomPropertyFilter(operator Operator, propertyName string, value string, highValue string) {
 	if fg.Filters == nil {
 		fg.Filters = &[]filter{}
 	}
@@ -359,96 +324,80 @@ func (fg *FilterGroup) AddCustomPropertyFilter(operator string, propertyName str
 	})
 }
 
+// AddAssociationFilter limits the search to objects associated with the object of objectType with objectId
+func (fg *FilterGroup) AddAssociationFilter(objectType ObjectType, objectId string) {
+	fg.AddPropertyFilter(OperatorEq, associationsProperty(objectType), objectId, "")
+}
+
 type filter struct {
-	Operator     string `json:"operator"`
-	PropertyName string `json:"propertyName,omitempty"`
-	Value        string `json:"value,omitempty"`
-	HighValue    string `json:"highValue,omitempty"`
+	Operator     Operator `json:"operator"`
+	PropertyName string   `json:"propertyName,omitempty"`
+	Value        string   `json:"value,omitempty"`
+	HighValue    string   `json:"highValue,omitempty"`
+	Values       []string `json:"values,omitempty"`
 	isCustom     bool
 }
 
 // SearchCompanies returns a specific company
 func (service *Service) SearchCompanies(config *SearchObjectsConfig) (*[]Company, *errortools.Error) {
-	if config == nil {
-		return nil, errortools.ErrorMessage("Config is nil")
-	}
-
-	endpoint := "objects/companies/search"
-
-	companiesResponse := CompaniesResponse{}
-
-	requestConfig := go_http.RequestConfig{
-		Method:        http.MethodPost,
-		Url:           service.urlCrm(endpoint),
-		BodyModel:     config,
-		ResponseModel: &companiesResponse,
-	}
-
-	_, _, e := service.httpRequest(&requestConfig)
-	if e != nil {
-		return nil, e
-	}
-
-	after := config.After
-
-	companies := []Company{}
-
-	for {
-		companiesResponse := CompaniesResponse{}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm("objects/companies/search"),
-			BodyModel:     config,
-			ResponseModel: &companiesResponse,
-		}
-
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
-
-		companies = append(companies, companiesResponse.Results...)
+	return service.SearchCompaniesWithContext(context.Background(), config)
+}
 
-		if after != nil { // explicit after parameter requested
-			break
-		}
+func (service *Service) SearchCompaniesWithContext(ctx context.Context, config *SearchObjectsConfig) (*[]Company, *errortools.Error) {
+	return collect(iterSearch[Company](ctx, service, NewSearchCursor(ObjectTypeCompanies, config), ObjectTypeCompanies, config != nil && config.After != nil))
+}
 
-		if companiesResponse.Paging == nil {
-			break
-		}
+// SearchCompaniesWithTotal returns the companies matching config and the total HubSpot reports for the search
+func (service *Service) SearchCompaniesWithTotal(config *SearchObjectsConfig) (*SearchResult[Company], *errortools.Error) {
+	return service.SearchCompaniesWithTotalWithContext(context.Background(), config)
+}
 
-		if companiesResponse.Paging.Next.After == "" {
-			break
-		}
+func (service *Service) SearchCompaniesWithTotalWithContext(ctx context.Context, config *SearchObjectsConfig) (*SearchResult[Company], *errortools.Error) {
+	cursor := NewSearchCursor(ObjectTypeCompanies, config)
+	return collectSearch(cursor, iterSearch[Company](ctx, service, cursor, ObjectTypeCompanies, config != nil && config.After != nil))
+}
 
-		config.After = &companiesResponse.Paging.Next.After
-	}
+// IterSearchCompanies streams all companies matching config page by page, starting at config.After
+func (service *Service) IterSearchCompanies(ctx context.Context, config *SearchObjectsConfig) iter.Seq2[Company, error] {
+	return iterErrors(func(yield func(Company, *errortools.Error) bool) {
+		iterSearch[Company](ctx, service, NewSearchCursor(ObjectTypeCompanies, config), ObjectTypeCompanies, false)(yield)
+	})
+}
 
-	return &companies, nil
+// IterSearchCompaniesFrom resumes IterSearchCompanies at a cursor created by NewSearchCursor
+func (service *Service) IterSearchCompaniesFrom(ctx context.Context, cursor *Cursor) iter.Seq2[Company, error] {
+	return iterErrors(iterSearch[Company](ctx, service, cursor, ObjectTypeCompanies, false))
 }
 
 func (service *Service) ArchiveCompany(companyId string) *errortools.Error {
+	return service.ArchiveCompanyWithContext(context.Background(), companyId)
+}
+
+func (service *Service) ArchiveCompanyWithContext(ctx context.Context, companyId string) *errortools.Error {
 	requestConfig := go_http.RequestConfig{
 		Method: http.MethodDelete,
 		Url:    service.urlCrm(fmt.Sprintf("objects/companies/%s", companyId)),
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	return e
 }
 
 func (service *Service) BatchArchiveCompanies(companyIds []string) *errortools.Error {
+	return service.BatchArchiveCompaniesWithContext(context.Background(), companyIds)
+}
+
+func (service *Service) BatchArchiveCompaniesWithContext(ctx context.Context, companyIds []string) *errortools.Error {
 	var maxItemsPerBatch = 100
 	var index = 0
 	for len(companyIds) > index {
 		if len(companyIds) > index+maxItemsPerBatch {
-			e := service.batchArchiveCompanies(companyIds[index : index+maxItemsPerBatch])
+			e := service.batchArchiveCompanies(ctx, companyIds[index:index+maxItemsPerBatch])
 			if e != nil {
 				return e
 			}
 		} else {
-			e := service.batchArchiveCompanies(companyIds[index:])
+			e := service.batchArchiveCompanies(ctx, companyIds[index:])
 			if e != nil {
 				return e
 			}
@@ -460,7 +409,7 @@ func (service *Service) BatchArchiveCompanies(companyIds []string) *errortools.E
 	return nil
 }
 
-func (service *Service) batchArchiveCompanies(companyIds []string) *errortools.Error {
+func (service *Service) batchArchiveCompanies(ctx context.Context, companyIds []string) *errortools.Error {
 	var body struct {
 		Inputs []struct {
 			Id string `json:"id"`
@@ -479,6 +428,6 @@ func (service *Service) batchArchiveCompanies(companyIds []string) *errortools.E
 		BodyModel: body,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	return e
 }
@@ -1,9 +1,11 @@
 package hubspot
 
 import (
+	"context"
 	"fmt"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
+	"iter"
 	"net/http"
 	"net/url"
 )
@@ -62,8 +64,27 @@ type GetContactListsConfig struct {
 
 // GetContactLists returns all contactLists
 func (service *Service) GetContactLists(config *GetContactListsConfig) (*[]ContactList, *errortools.Error) {
+	return service.GetContactListsWithContext(context.Background(), config)
+}
+
+func (service *Service) GetContactListsWithContext(ctx context.Context, config *GetContactListsConfig) (*[]ContactList, *errortools.Error) {
+	return collect(service.iterContactLists(ctx, config, config != nil && config.Offset != nil))
+}
+
+// IterContactLists streams all contactLists page by page, starting at config.Offset
+func (service *Service) IterContactLists(ctx context.Context, config *GetContactListsConfig) iter.Seq2[ContactList, error] {
+	return iterErrors(service.iterContactLists(ctx, config, false))
+}
+
+func (service *Service) iterContactLists(ctx context.Context, config *GetContactListsConfig, singlePage bool) iter.Seq2[ContactList, *errortools.Error] {
+	return func(yield func(ContactList, *errortools.Error) bool) {
+		service.iterContactListsFrom(ctx, NewContactListsCursor(config), singlePage)(yield)
+	}
+}
+
+// NewContactListsCursor returns a cursor at the start of GetContactLists, or at config.Offset
+func NewContactListsCursor(config *GetContactListsConfig) *Cursor {
 	values := url.Values{}
-	endpoint := "lists"
 
 	var offset int64 = 0
 
@@ -76,45 +97,39 @@ func (service *Service) GetContactLists(config *GetContactListsConfig) (*[]Conta
 		}
 	}
 
-	contactLists := []ContactList{}
-
-	for {
-		contactListsResponse := ContactListsResponse{}
-
-		if offset > 0 {
-			values.Set("offset", fmt.Sprintf("%v", offset))
-		}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodGet,
-			Url:           service.urlContacts(fmt.Sprintf("%s?%s", endpoint, values.Encode())),
-			ResponseModel: &contactListsResponse,
-		}
+	return &Cursor{ObjectType: "contact_lists", Query: values, Offset: offset}
+}
 
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
+// IterContactListsFrom resumes IterContactLists at cursor
+func (service *Service) IterContactListsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[ContactList, error] {
+	return iterErrors(service.iterContactListsFrom(ctx, cursor, false))
+}
 
-		contactLists = append(contactLists, contactListsResponse.Lists...)
+func (service *Service) iterContactListsFrom(ctx context.Context, cursor *Cursor, singlePage bool) iter.Seq2[ContactList, *errortools.Error] {
+	if e := cursor.check("contact_lists"); e != nil {
+		return iterError[ContactList](e)
+	}
 
-		if config != nil {
-			if config.Offset != nil { // explicit after parameter requested
-				break
-			}
+	return iterOffsetPages(ctx, service, cursor, singlePage, func(offset int64) go_http.RequestConfig {
+		_offset := ""
+		if offset > 0 {
+			_offset = fmt.Sprintf("%v", offset)
 		}
 
-		if !contactListsResponse.HasMore {
-			break
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlContacts(fmt.Sprintf("lists?%s", encodeWithParam(cursor.Query, "offset", _offset))),
 		}
-
-		offset = contactListsResponse.Offset
-	}
-
-	return &contactLists, nil
+	}, func(response *ContactListsResponse) ([]ContactList, bool, int64) {
+		return response.Lists, response.HasMore, response.Offset
+	})
 }
 
 func (service *Service) CreateContactList(contactList *ContactList) (*ContactList, *errortools.Error) {
+	return service.CreateContactListWithContext(context.Background(), contactList)
+}
+
+func (service *Service) CreateContactListWithContext(ctx context.Context, contactList *ContactList) (*ContactList, *errortools.Error) {
 	endpoint := "lists"
 	contactListNew := ContactList{}
 
@@ -125,7 +140,7 @@ func (service *Service) CreateContactList(contactList *ContactList) (*ContactLis
 		ResponseModel: &contactListNew,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -134,6 +149,10 @@ func (service *Service) CreateContactList(contactList *ContactList) (*ContactLis
 }
 
 func (service *Service) UpdateContactList(contactList *ContactList) (*ContactList, *errortools.Error) {
+	return service.UpdateContactListWithContext(context.Background(), contactList)
+}
+
+func (service *Service) UpdateContactListWithContext(ctx context.Context, contactList *ContactList) (*ContactList, *errortools.Error) {
 	if contactList.ListId == nil {
 		return nil, errortools.ErrorMessage("ListId is required")
 	}
@@ -148,7 +167,7 @@ func (service *Service) UpdateContactList(contactList *ContactList) (*ContactLis
 		ResponseModel: &contactListNew,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -157,6 +176,10 @@ func (service *Service) UpdateContactList(contactList *ContactList) (*ContactLis
 }
 
 func (service *Service) DeleteContactList(contactListId string) *errortools.Error {
+	return service.DeleteContactListWithContext(context.Background(), contactListId)
+}
+
+func (service *Service) DeleteContactListWithContext(ctx context.Context, contactListId string) *errortools.Error {
 	endpoint := "lists"
 
 	requestConfig := go_http.RequestConfig{
@@ -164,7 +187,7 @@ func (service *Service) DeleteContactList(contactListId string) *errortools.Erro
 		Url:    service.urlContacts(fmt.Sprintf("%s/%s", endpoint, contactListId)),
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return e
 	}
@@ -186,6 +209,10 @@ type AddContactsToContactListResponse struct {
 }
 
 func (service *Service) AddContactsToContactList(config *AddContactsToContactListConfig) (*AddContactsToContactListResponse, *errortools.Error) {
+	return service.AddContactsToContactListWithContext(context.Background(), config)
+}
+
+func (service *Service) AddContactsToContactListWithContext(ctx context.Context, config *AddContactsToContactListConfig) (*AddContactsToContactListResponse, *errortools.Error) {
 	endpoint := "lists"
 	res := AddContactsToContactListResponse{}
 
@@ -196,7 +223,7 @@ func (service *Service) AddContactsToContactList(config *AddContactsToContactLis
 		ResponseModel: &res,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -250,37 +277,59 @@ type ContactInContactList struct {
 }
 
 func (service *Service) GetContactsInContactList(config *GetContactsInContactListConfig) (*[]ContactInContactList, *errortools.Error) {
-	values := url.Values{}
-	endpoint := "lists"
+	return service.GetContactsInContactListWithContext(context.Background(), config)
+}
 
-	var contacts []ContactInContactList
-	var offset int = 0
+func (service *Service) GetContactsInContactListWithContext(ctx context.Context, config *GetContactsInContactListConfig) (*[]ContactInContactList, *errortools.Error) {
+	return collect(service.iterContactsInContactList(ctx, config))
+}
 
-	for {
-		if offset > 0 {
-			values.Set("vidOffset", fmt.Sprintf("%v", offset))
-		}
+// IterContactsInContactList streams all contacts in a contactList page by page
+func (service *Service) IterContactsInContactList(ctx context.Context, config *GetContactsInContactListConfig) iter.Seq2[ContactInContactList, error] {
+	return iterErrors(service.iterContactsInContactList(ctx, config))
+}
 
-		res := GetContactsInContactListResponse{}
+func (service *Service) iterContactsInContactList(ctx context.Context, config *GetContactsInContactListConfig) iter.Seq2[ContactInContactList, *errortools.Error] {
+	if config == nil {
+		return iterError[ContactInContactList](errortools.ErrorMessage("Config is nil"))
+	}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodGet,
-			Url:           service.urlContacts(fmt.Sprintf("%s/%v/contacts/all?%s", endpoint, config.ListId, values.Encode())),
-			ResponseModel: &res,
-		}
+	return func(yield func(ContactInContactList, *errortools.Error) bool) {
+		service.iterContactsInContactListFrom(ctx, NewContactsInContactListCursor(config))(yield)
+	}
+}
 
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
+// NewContactsInContactListCursor returns a cursor at the start of GetContactsInContactList
+func NewContactsInContactListCursor(config *GetContactsInContactListConfig) *Cursor {
+	cursor := Cursor{ObjectType: "contact_list_contacts"}
+	if config != nil {
+		cursor.ParentId = fmt.Sprintf("%v", config.ListId)
+	}
 
-		contacts = append(contacts, res.Contacts...)
+	return &cursor
+}
 
-		if !res.HasMore {
-			break
-		}
+// IterContactsInContactListFrom resumes IterContactsInContactList at cursor
+func (service *Service) IterContactsInContactListFrom(ctx context.Context, cursor *Cursor) iter.Seq2[ContactInContactList, error] {
+	return iterErrors(service.iterContactsInContactListFrom(ctx, cursor))
+}
 
-		offset = res.VidOffset
+func (service *Service) iterContactsInContactListFrom(ctx context.Context, cursor *Cursor) iter.Seq2[ContactInContactList, *errortools.Error] {
+	if e := cursor.check("contact_list_contacts"); e != nil {
+		return iterError[ContactInContactList](e)
 	}
-	return &contacts, nil
+
+	return iterOffsetPages(ctx, service, cursor, false, func(offset int64) go_http.RequestConfig {
+		_offset := ""
+		if offset > 0 {
+			_offset = fmt.Sprintf("%v", offset)
+		}
+
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlContacts(fmt.Sprintf("lists/%s/contacts/all?%s", cursor.ParentId, encodeWithParam(cursor.Query, "vidOffset", _offset))),
+		}
+	}, func(response *GetContactsInContactListResponse) ([]ContactInContactList, bool, int64) {
+		return response.Contacts, response.HasMore, int64(response.VidOffset)
+	})
 }
@@ -1,8 +1,10 @@
 package hubspot
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"net/http"
 	"net/url"
 	"strings"
@@ -41,8 +43,27 @@ type GetContactsConfig struct {
 
 // GetContacts returns all contacts
 func (service *Service) GetContacts(config *GetContactsConfig) (*[]Contact, *errortools.Error) {
+	return service.GetContactsWithContext(context.Background(), config)
+}
+
+func (service *Service) GetContactsWithContext(ctx context.Context, config *GetContactsConfig) (*[]Contact, *errortools.Error) {
+	return collect(service.iterContacts(ctx, config, config != nil && config.After != nil))
+}
+
+// IterContacts streams all contacts page by page, starting at config.After
+func (service *Service) IterContacts(ctx context.Context, config *GetContactsConfig) iter.Seq2[Contact, error] {
+	return iterErrors(service.iterContacts(ctx, config, false))
+}
+
+func (service *Service) iterContacts(ctx context.Context, config *GetContactsConfig, singlePage bool) iter.Seq2[Contact, *errortools.Error] {
+	return func(yield func(Contact, *errortools.Error) bool) {
+		service.iterContactsFrom(ctx, NewContactsCursor(config), singlePage)(yield)
+	}
+}
+
+// NewContactsCursor returns a cursor at the start of GetContacts, or at config.After
+func NewContactsCursor(config *GetContactsConfig) *Cursor {
 	values := url.Values{}
-	endpoint := "objects/contacts"
 
 	if config != nil {
 		if config.Limit != nil {
@@ -73,51 +94,36 @@ func (service *Service) GetContacts(config *GetContactsConfig) (*[]Contact, *err
 	}
 
 	after := ""
-	if config.After != nil {
+	if config != nil && config.After != nil {
 		after = *config.After
 	}
 
-	contacts := []Contact{}
-
-	for {
-		contactsResponse := ContactsResponse{}
-
-		if after != "" {
-			values.Set("after", after)
-		}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodGet,
-			Url:           service.urlCrm(fmt.Sprintf("%s?%s", endpoint, values.Encode())),
-			ResponseModel: &contactsResponse,
-		}
-
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
-
-		contacts = append(contacts, contactsResponse.Results...)
-
-		if config.After != nil { // explicit after parameter requested
-			break
-		}
-
-		if contactsResponse.Paging == nil {
-			break
-		}
+	return &Cursor{ObjectType: string(ObjectTypeContacts), Query: values, After: after}
+}
 
-		if contactsResponse.Paging.Next.After == "" {
-			break
-		}
+// IterContactsFrom resumes IterContacts at cursor
+func (service *Service) IterContactsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[Contact, error] {
+	return iterErrors(service.iterContactsFrom(ctx, cursor, false))
+}
 
-		after = contactsResponse.Paging.Next.After
+func (service *Service) iterContactsFrom(ctx context.Context, cursor *Cursor, singlePage bool) iter.Seq2[Contact, *errortools.Error] {
+	if e := cursor.check(string(ObjectTypeContacts)); e != nil {
+		return iterError[Contact](e)
 	}
 
-	return &contacts, nil
+	return iterPages[Contact](ctx, service, cursor, singlePage, func(after string) go_http.RequestConfig {
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlCrm(fmt.Sprintf("objects/%s?%s", cursor.ObjectType, encodeWithParam(cursor.Query, "after", after))),
+		}
+	})
 }
 
 func (service *Service) CreateContact(config *CreateObjectConfig) (*Contact, *errortools.Error) {
+	return service.CreateContactWithContext(context.Background(), config)
+}
+
+func (service *Service) CreateContactWithContext(ctx context.Context, config *CreateObjectConfig) (*Contact, *errortools.Error) {
 	endpoint := "objects/contacts"
 	contact := Contact{}
 
@@ -128,7 +134,7 @@ func (service *Service) CreateContact(config *CreateObjectConfig) (*Contact, *er
 		ResponseModel: &contact,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -136,92 +142,9 @@ func (service *Service) CreateContact(config *CreateObjectConfig) (*Contact, *er
 	return &contact, nil
 }
 
-func (service *Service) BatchCreateContacts(config *BatchObjectsConfig, invalidEmailProperty string) (*[]Contact, *errortools.Error) {
-	var contacts []Contact
-
-	for _, batch := range service.batches(len(config.Inputs)) {
-		var retrying = false
-	retry:
-		var r BatchContactsResponse
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(fmt.Sprintf("objects/%s/batch/create", config.ObjectType)),
-			BodyModel:     BatchObjectsConfig{Inputs: config.Inputs[batch.startIndex:batch.endIndex]},
-			ResponseModel: &r,
-		}
-
-		_, response, e := service.httpRequest(&requestConfig)
-		if response != nil {
-			if response.StatusCode == http.StatusMultiStatus {
-				fmt.Println(r.Errors)
-				goto ok
-			}
-
-			if response.StatusCode == http.StatusBadRequest && !retrying {
-				errorResponse := service.ErrorResponse()
-				if errorResponse != nil {
-					if strings.HasPrefix(errorResponse.Message, "Property values were not valid: ") {
-						stop := checkInvalidEmails(config, invalidEmailProperty, errorResponse.Message, batch)
-
-						if stop {
-							goto stop
-						}
-
-						retrying = true
-						goto retry
-					}
-				}
-			}
-		}
-
-	stop:
-		if e != nil {
-			return nil, e
-		}
-	ok:
-		contacts = append(contacts, r.Results...)
-
-		fmt.Println("batch", batch.startIndex)
-	}
-
-	return &contacts, nil
-}
-
-func checkInvalidEmails(config *BatchObjectsConfig, invalidEmailProperty string, message string, batch batch) (stop bool) {
-	m := strings.TrimPrefix(message, "Property values were not valid: ")
-
-	var propertyErrors []PropertyError
-	err := json.Unmarshal([]byte(m), &propertyErrors)
-	if err != nil {
-		fmt.Println(err)
-		return true
-	}
-
-	hasNonEmailError := false
-	for _, propertyError := range propertyErrors {
-		fmt.Println(propertyError.Message)
-
-		if propertyError.Error == "INVALID_EMAIL" {
-			// English error message
-			email := strings.TrimSuffix(strings.TrimPrefix(propertyError.Message, "Email address "), " is invalid")
-			// Dutch error message
-			email = strings.TrimSuffix(strings.TrimPrefix(propertyError.Message, "E-mailadres "), " is ongeldig")
-			for i := batch.startIndex; i < batch.endIndex; i++ {
-				if (*config).Inputs[i].Properties["email"] == email {
-					(*config).Inputs[i].Properties["email"] = ""
-					if invalidEmailProperty != "" {
-						(*config).Inputs[i].Properties[invalidEmailProperty] = email
-					}
-				}
-			}
-		} else {
-			hasNonEmailError = true
-		}
-	}
-	return hasNonEmailError
-}
-
+// BatchContactsResponse is the response of a batch request of contacts
+//
+// Deprecated: the batch functions return a BatchResult
 type BatchContactsResponse struct {
 	CompletedAt *time.Time        `json:"completedAt"`
 	NumErrors   int               `json:"numErrors"`
@@ -250,55 +173,77 @@ type BatchContactsResponse struct {
 	Status string `json:"status"`
 }
 
-func (service *Service) BatchUpdateContacts(config *BatchObjectsConfig, invalidEmailProperty string) (*[]Contact, *errortools.Error) {
-	var contacts []Contact
+// BatchCreateContacts returns the created contacts, the inputs that failed are only logged
+//
+// Deprecated: use BatchCreateContactsWithResult, which also returns the inputs that failed
+func (service *Service) BatchCreateContacts(config *BatchObjectsConfig, invalidEmailProperty string) (*[]Contact, *errortools.Error) {
+	result, e := service.BatchCreateContactsWithResultWithContext(context.Background(), config, invalidEmailProperty)
+	if e != nil {
+		return nil, e
+	}
 
-	for _, batch := range service.batches(len(config.Inputs)) {
-	retry:
-		var r BatchContactsResponse
+	return &result.Successes, nil
+}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(fmt.Sprintf("objects/%s/batch/update", config.ObjectType)),
-			BodyModel:     BatchObjectsConfig{Inputs: config.Inputs[batch.startIndex:batch.endIndex]},
-			ResponseModel: &r,
-		}
+func (service *Service) BatchCreateContactsWithResult(config *BatchObjectsConfig, invalidEmailProperty string) (*BatchResult[Contact], *errortools.Error) {
+	return service.BatchCreateContactsWithResultWithContext(context.Background(), config, invalidEmailProperty)
+}
 
-		_, response, e := service.httpRequest(&requestConfig)
-		if response != nil {
-			if response.StatusCode == http.StatusMultiStatus {
-				fmt.Println(r.Errors)
-				goto ok
-			}
-			if response.StatusCode == http.StatusBadRequest {
-				errorResponse := service.ErrorResponse()
-				if errorResponse != nil {
-					if strings.HasPrefix(errorResponse.Message, "Property values were not valid: ") {
-						stop := checkInvalidEmails(config, invalidEmailProperty, errorResponse.Message, batch)
-
-						if stop {
-							goto stop
-						}
-
-						goto retry
-					}
-				}
-			}
-		}
-	stop:
-		if e != nil {
-			return nil, e
-		}
-	ok:
-		contacts = append(contacts, r.Results...)
+func (service *Service) BatchCreateContactsWithResultWithContext(ctx context.Context, config *BatchObjectsConfig, invalidEmailProperty string) (*BatchResult[Contact], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
+	}
+
+	return batchObjects[Contact](ctx, service, ObjectType(config.ObjectType), "create", withInvalidEmailProperty(config, invalidEmailProperty))
+}
+
+// withInvalidEmailProperty returns config with a policy that moves invalid email addresses to invalidEmailProperty,
+// or clears them if it is empty, unless config has an InvalidPropertyPolicy already
+func withInvalidEmailProperty(config *BatchObjectsConfig, invalidEmailProperty string) *BatchObjectsConfig {
+	if config == nil || config.InvalidPropertyPolicy != nil {
+		return config
+	}
+
+	recovery := InvalidPropertyRecovery{Action: InvalidPropertyClear}
+	if invalidEmailProperty != "" {
+		recovery = InvalidPropertyRecovery{Action: InvalidPropertyMove, Fallback: invalidEmailProperty}
+	}
+
+	config_ := *config
+	config_.InvalidPropertyPolicy = InvalidPropertyRules(map[string]InvalidPropertyRecovery{"INVALID_EMAIL": recovery})
+
+	return &config_
+}
+
+// BatchUpdateContacts returns the updated contacts, the inputs that failed are only logged
+//
+// Deprecated: use BatchUpdateContactsWithResult, which also returns the inputs that failed
+func (service *Service) BatchUpdateContacts(config *BatchObjectsConfig, invalidEmailProperty string) (*[]Contact, *errortools.Error) {
+	result, e := service.BatchUpdateContactsWithResultWithContext(context.Background(), config, invalidEmailProperty)
+	if e != nil {
+		return nil, e
+	}
+
+	return &result.Successes, nil
+}
 
-		fmt.Println("batch", batch.startIndex)
+func (service *Service) BatchUpdateContactsWithResult(config *BatchObjectsConfig, invalidEmailProperty string) (*BatchResult[Contact], *errortools.Error) {
+	return service.BatchUpdateContactsWithResultWithContext(context.Background(), config, invalidEmailProperty)
+}
+
+func (service *Service) BatchUpdateContactsWithResultWithContext(ctx context.Context, config *BatchObjectsConfig, invalidEmailProperty string) (*BatchResult[Contact], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
 	}
 
-	return &contacts, nil
+	return batchObjects[Contact](ctx, service, ObjectType(config.ObjectType), "update", withInvalidEmailProperty(config, invalidEmailProperty))
 }
 
 func (service *Service) UpdateContact(config *UpdateObjectConfig) (*Contact, *errortools.Error) {
+	return service.UpdateContactWithContext(context.Background(), config)
+}
+
+func (service *Service) UpdateContactWithContext(ctx context.Context, config *UpdateObjectConfig) (*Contact, *errortools.Error) {
 	values := url.Values{}
 	endpoint := "objects/contacts"
 
@@ -319,7 +264,7 @@ func (service *Service) UpdateContact(config *UpdateObjectConfig) (*Contact, *er
 		ResponseModel: &contact,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -336,6 +281,10 @@ type GetContactConfig struct {
 
 // GetContact returns a specific contact
 func (service *Service) GetContact(config *GetContactConfig) (*Contact, *errortools.Error) {
+	return service.GetContactWithContext(context.Background(), config)
+}
+
+func (service *Service) GetContactWithContext(ctx context.Context, config *GetContactConfig) (*Contact, *errortools.Error) {
 	values := url.Values{}
 	endpoint := "objects/contacts"
 
@@ -374,7 +323,7 @@ func (service *Service) GetContact(config *GetContactConfig) (*Contact, *errorto
 		ResponseModel: &contact,
 	}
 
-	_, response, e := service.httpRequest(&requestConfig)
+	_, response, e := service.httpRequest(ctx, &requestConfig)
 	if response != nil {
 		if response.StatusCode == http.StatusNotFound {
 			return nil, nil
@@ -391,103 +340,102 @@ type SearchObjectsConfig struct {
 	Limit        *uint          `json:"limit,omitempty"`
 	After        *string        `json:"after,omitempty"`
 	FilterGroups *[]FilterGroup `json:"filterGroups,omitempty"`
-	Sorts        *[]string      `json:"sorts,omitempty"`
+	Sorts        *[]Sort        `json:"sorts,omitempty"`
 	Query        *string        `json:"query,omitempty"`
 	Properties   *[]string      `json:"properties,omitempty"`
+	// SplitBy makes a search return all results instead of the first 10,000, by splitting it in ranges of a property sorted ascending.
+	// Use hs_object_id, or hs_lastmodifieddate (lastmodifieddate for contacts). Sorts and After are ignored.
+	SplitBy *string `json:"-"`
 }
 
-// SearchContact returns a specific contact
-func (service *Service) SearchContact(config *SearchObjectsConfig) (*[]Contact, *errortools.Error) {
-	if config == nil {
-		return nil, errortools.ErrorMessage("Config is nil")
+// iterSearch streams the results of a search page by page, starting at cursor.After
+func iterSearch[T any](ctx context.Context, service *Service, cursor *Cursor, objectType ObjectType, singlePage bool) iter.Seq2[T, *errortools.Error] {
+	if e := cursor.check(string(objectType)); e != nil {
+		return iterError[T](e)
 	}
-
-	endpoint := "objects/contacts/search"
-
-	contactsResponse := ContactsResponse{}
-
-	requestConfig := go_http.RequestConfig{
-		Method:        http.MethodPost,
-		Url:           service.urlCrm(endpoint),
-		BodyModel:     config,
-		ResponseModel: &contactsResponse,
+	if cursor.Search == nil {
+		return iterError[T](errortools.ErrorMessage("Config is nil"))
 	}
-
-	_, _, e := service.httpRequest(&requestConfig)
-	if e != nil {
-		return nil, e
+	if e := cursor.Search.validate(); e != nil {
+		return iterError[T](e)
 	}
-
-	properties := []string{}
-	if config.FilterGroups != nil {
-		for _, filterGroup := range *config.FilterGroups {
-			for _, filter := range *filterGroup.Filters {
-				if filter.isCustom {
-					properties = append(properties, filter.PropertyName)
-				}
-			}
-		}
+	if cursor.Split != nil {
+		return iterSplitSearch[T](ctx, service, cursor, singlePage)
 	}
 
-	after := config.After
-
-	contacts := []Contact{}
-
-	for {
-		contactsResponse := ContactsResponse{}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm("objects/contacts/search"),
-			BodyModel:     config,
-			ResponseModel: &contactsResponse,
+	return iterPages[T](ctx, service, cursor, singlePage, func(after string) go_http.RequestConfig {
+		body := *cursor.Search
+		if after != "" {
+			body.After = &after
 		}
 
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
+		return go_http.RequestConfig{
+			Method:    http.MethodPost,
+			Url:       service.urlCrm(fmt.Sprintf("objects/%s/search", cursor.ObjectType)),
+			BodyModel: body,
 		}
+	})
+}
 
-		contacts = append(contacts, contactsResponse.Results...)
+// SearchContact returns a specific contact
+func (service *Service) SearchContact(config *SearchObjectsConfig) (*[]Contact, *errortools.Error) {
+	return service.SearchContactWithContext(context.Background(), config)
+}
 
-		if after != nil { // explicit after parameter requested
-			break
-		}
+func (service *Service) SearchContactWithContext(ctx context.Context, config *SearchObjectsConfig) (*[]Contact, *errortools.Error) {
+	return collect(iterSearch[Contact](ctx, service, NewSearchCursor(ObjectTypeContacts, config), ObjectTypeContacts, config != nil && config.After != nil))
+}
 
-		if contactsResponse.Paging == nil {
-			break
-		}
+// SearchContactWithTotal returns the contacts matching config and the total HubSpot reports for the search
+func (service *Service) SearchContactWithTotal(config *SearchObjectsConfig) (*SearchResult[Contact], *errortools.Error) {
+	return service.SearchContactWithTotalWithContext(context.Background(), config)
+}
 
-		if contactsResponse.Paging.Next.After == "" {
-			break
-		}
+func (service *Service) SearchContactWithTotalWithContext(ctx context.Context, config *SearchObjectsConfig) (*SearchResult[Contact], *errortools.Error) {
+	cursor := NewSearchCursor(ObjectTypeContacts, config)
+	return collectSearch(cursor, iterSearch[Contact](ctx, service, cursor, ObjectTypeContacts, config != nil && config.After != nil))
+}
 
-		config.After = &contactsResponse.Paging.Next.After
-	}
+// IterSearchContacts streams all contacts matching config page by page, starting at config.After
+func (service *Service) IterSearchContacts(ctx context.Context, config *SearchObjectsConfig) iter.Seq2[Contact, error] {
+	return iterErrors(func(yield func(Contact, *errortools.Error) bool) {
+		iterSearch[Contact](ctx, service, NewSearchCursor(ObjectTypeContacts, config), ObjectTypeContacts, false)(yield)
+	})
+}
 
-	return &contacts, nil
+// IterSearchContactsFrom resumes IterSearchContacts at a cursor created by NewSearchCursor
+func (service *Service) IterSearchContactsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[Contact, error] {
+	return iterErrors(iterSearch[Contact](ctx, service, cursor, ObjectTypeContacts, false))
 }
 
 func (service *Service) DeleteContact(contactId string) *errortools.Error {
+	return service.DeleteContactWithContext(context.Background(), contactId)
+}
+
+func (service *Service) DeleteContactWithContext(ctx context.Context, contactId string) *errortools.Error {
 	requestConfig := go_http.RequestConfig{
 		Method: http.MethodDelete,
 		Url:    service.urlCrm(fmt.Sprintf("objects/contacts/%s", contactId)),
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	return e
 }
 
 func (service *Service) BatchArchiveContacts(contactIds []string) *errortools.Error {
+	return service.BatchArchiveContactsWithContext(context.Background(), contactIds)
+}
+
+func (service *Service) BatchArchiveContactsWithContext(ctx context.Context, contactIds []string) *errortools.Error {
 	var index = 0
 	for len(contactIds) > index {
 		if len(contactIds) > index+maxItemsPerBatch {
-			e := service.batchArchiveContacts(contactIds[index : index+maxItemsPerBatch])
+			e := service.batchArchiveContacts(ctx, contactIds[index:index+maxItemsPerBatch])
 			if e != nil {
 				return e
 			}
 		} else {
-			e := service.batchArchiveContacts(contactIds[index:])
+			e := service.batchArchiveContacts(ctx, contactIds[index:])
 			if e != nil {
 				return e
 			}
@@ -499,7 +447,7 @@ func (service *Service) BatchArchiveContacts(contactIds []string) *errortools.Er
 	return nil
 }
 
-func (service *Service) batchArchiveContacts(contactIds []string) *errortools.Error {
+func (service *Service) batchArchiveContacts(ctx context.Context, contactIds []string) *errortools.Error {
 	var body struct {
 		Inputs []struct {
 			Id string `json:"id"`
@@ -518,6 +466,6 @@ func (service *Service) batchArchiveContacts(contactIds []string) *errortools.Er
 		BodyModel: body,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	return e
 }
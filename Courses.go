@@ -1,11 +1,13 @@
 package hubspot
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
 	h_types "github.com/leapforce-libraries/go_hubspot/types"
+	"iter"
 	"net/http"
 	"net/url"
 	"strings"
@@ -39,8 +41,27 @@ type GetCoursesConfig struct {
 
 // GetCourses returns all courses
 func (service *Service) GetCourses(config *GetCoursesConfig) (*[]Course, *errortools.Error) {
+	return service.GetCoursesWithContext(context.Background(), config)
+}
+
+func (service *Service) GetCoursesWithContext(ctx context.Context, config *GetCoursesConfig) (*[]Course, *errortools.Error) {
+	return collect(service.iterCourses(ctx, config, config != nil && config.After != nil))
+}
+
+// IterCourses streams all courses page by page, starting at config.After
+func (service *Service) IterCourses(ctx context.Context, config *GetCoursesConfig) iter.Seq2[Course, error] {
+	return iterErrors(service.iterCourses(ctx, config, false))
+}
+
+func (service *Service) iterCourses(ctx context.Context, config *GetCoursesConfig, singlePage bool) iter.Seq2[Course, *errortools.Error] {
+	return func(yield func(Course, *errortools.Error) bool) {
+		service.iterCoursesFrom(ctx, NewCoursesCursor(config), singlePage)(yield)
+	}
+}
+
+// NewCoursesCursor returns a cursor at the start of GetCourses, or at config.After
+func NewCoursesCursor(config *GetCoursesConfig) *Cursor {
 	values := url.Values{}
-	endpoint := "objects/0-410"
 
 	if config != nil {
 		if config.Limit != nil {
@@ -75,51 +96,36 @@ func (service *Service) GetCourses(config *GetCoursesConfig) (*[]Course, *errort
 	}
 
 	after := ""
-	if config.After != nil {
+	if config != nil && config.After != nil {
 		after = *config.After
 	}
 
-	courses := []Course{}
-
-	for {
-		coursesResponse := CoursesResponse{}
-
-		if after != "" {
-			values.Set("after", after)
-		}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodGet,
-			Url:           service.urlCrm(fmt.Sprintf("%s?%s", endpoint, values.Encode())),
-			ResponseModel: &coursesResponse,
-		}
-
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
-
-		courses = append(courses, coursesResponse.Results...)
-
-		if config.After != nil { // explicit after parameter requested
-			break
-		}
-
-		if coursesResponse.Paging == nil {
-			break
-		}
+	return &Cursor{ObjectType: string(ObjectTypeCourses), Query: values, After: after}
+}
 
-		if coursesResponse.Paging.Next.After == "" {
-			break
-		}
+// IterCoursesFrom resumes IterCourses at cursor
+func (service *Service) IterCoursesFrom(ctx context.Context, cursor *Cursor) iter.Seq2[Course, error] {
+	return iterErrors(service.iterCoursesFrom(ctx, cursor, false))
+}
 
-		after = coursesResponse.Paging.Next.After
+func (service *Service) iterCoursesFrom(ctx context.Context, cursor *Cursor, singlePage bool) iter.Seq2[Course, *errortools.Error] {
+	if e := cursor.check(string(ObjectTypeCourses)); e != nil {
+		return iterError[Course](e)
 	}
 
-	return &courses, nil
+	return iterPages[Course](ctx, service, cursor, singlePage, func(after string) go_http.RequestConfig {
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlCrm(fmt.Sprintf("objects/%s?%s", cursor.ObjectType, encodeWithParam(cursor.Query, "after", after))),
+		}
+	})
 }
 
 func (service *Service) CreateCourse(config *CreateObjectConfig) (*Course, *errortools.Error) {
+	return service.CreateCourseWithContext(context.Background(), config)
+}
+
+func (service *Service) CreateCourseWithContext(ctx context.Context, config *CreateObjectConfig) (*Course, *errortools.Error) {
 	endpoint := "objects/0-410"
 	course := Course{}
 
@@ -130,7 +136,7 @@ func (service *Service) CreateCourse(config *CreateObjectConfig) (*Course, *erro
 		ResponseModel: &course,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -138,6 +144,9 @@ func (service *Service) CreateCourse(config *CreateObjectConfig) (*Course, *erro
 	return &course, nil
 }
 
+// BatchCoursesResponse is the response of a batch request of courses
+//
+// Deprecated: the batch functions return a BatchResult
 type BatchCoursesResponse struct {
 	CompletedAt *time.Time        `json:"completedAt"`
 	NumErrors   int               `json:"numErrors"`
@@ -166,71 +175,59 @@ type BatchCoursesResponse struct {
 	Status string `json:"status"`
 }
 
+// BatchCreateCourses returns the created courses, the inputs that failed are only logged
+//
+// Deprecated: use BatchCreateCoursesWithResult, which also returns the inputs that failed
 func (service *Service) BatchCreateCourses(config *BatchObjectsConfig) (*[]Course, *errortools.Error) {
-	var courses []Course
-
-	for _, batch := range service.batches(len(config.Inputs)) {
-		var r BatchCoursesResponse
+	result, e := service.BatchCreateCoursesWithResultWithContext(context.Background(), config)
+	if e != nil {
+		return nil, e
+	}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(fmt.Sprintf("objects/%s/batch/create", config.ObjectType)),
-			BodyModel:     BatchObjectsConfig{Inputs: config.Inputs[batch.startIndex:batch.endIndex]},
-			ResponseModel: &r,
-		}
+	return &result.Successes, nil
+}
 
-		_, response, e := service.httpRequest(&requestConfig)
-		if response != nil {
-			if response.StatusCode == http.StatusMultiStatus {
-				fmt.Println(r.Errors)
-				goto ok
-			}
-		}
-		if e != nil {
-			return nil, e
-		}
-	ok:
-		courses = append(courses, r.Results...)
+func (service *Service) BatchCreateCoursesWithResult(config *BatchObjectsConfig) (*BatchResult[Course], *errortools.Error) {
+	return service.BatchCreateCoursesWithResultWithContext(context.Background(), config)
+}
 
-		fmt.Println("batch", batch.startIndex)
+func (service *Service) BatchCreateCoursesWithResultWithContext(ctx context.Context, config *BatchObjectsConfig) (*BatchResult[Course], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
 	}
 
-	return &courses, nil
+	return batchObjects[Course](ctx, service, ObjectType(config.ObjectType), "create", config)
 }
 
+// BatchUpdateCourses returns the updated courses, the inputs that failed are only logged
+//
+// Deprecated: use BatchUpdateCoursesWithResult, which also returns the inputs that failed
 func (service *Service) BatchUpdateCourses(config *BatchObjectsConfig) (*[]Course, *errortools.Error) {
-	var courses []Course
-
-	for _, batch := range service.batches(len(config.Inputs)) {
-		var r BatchCoursesResponse
+	result, e := service.BatchUpdateCoursesWithResultWithContext(context.Background(), config)
+	if e != nil {
+		return nil, e
+	}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(fmt.Sprintf("objects/%s/batch/update", config.ObjectType)),
-			BodyModel:     BatchObjectsConfig{Inputs: config.Inputs[batch.startIndex:batch.endIndex]},
-			ResponseModel: &r,
-		}
+	return &result.Successes, nil
+}
 
-		_, response, e := service.httpRequest(&requestConfig)
-		if response != nil {
-			if response.StatusCode == http.StatusMultiStatus {
-				fmt.Println(r.Errors)
-				goto ok
-			}
-		}
-		if e != nil {
-			return nil, e
-		}
-	ok:
-		courses = append(courses, r.Results...)
+func (service *Service) BatchUpdateCoursesWithResult(config *BatchObjectsConfig) (*BatchResult[Course], *errortools.Error) {
+	return service.BatchUpdateCoursesWithResultWithContext(context.Background(), config)
+}
 
-		fmt.Println("batch", batch.startIndex)
+func (service *Service) BatchUpdateCoursesWithResultWithContext(ctx context.Context, config *BatchObjectsConfig) (*BatchResult[Course], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
 	}
 
-	return &courses, nil
+	return batchObjects[Course](ctx, service, ObjectType(config.ObjectType), "update", config)
 }
 
 func (service *Service) UpdateCourse(config *UpdateObjectConfig) (*Course, *errortools.Error) {
+	return service.UpdateCourseWithContext(context.Background(), config)
+}
+
+func (service *Service) UpdateCourseWithContext(ctx context.Context, config *UpdateObjectConfig) (*Course, *errortools.Error) {
 	endpoint := "objects/0-410"
 	course := Course{}
 
@@ -241,7 +238,7 @@ func (service *Service) UpdateCourse(config *UpdateObjectConfig) (*Course, *erro
 		ResponseModel: &course,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -250,16 +247,20 @@ func (service *Service) UpdateCourse(config *UpdateObjectConfig) (*Course, *erro
 }
 
 func (service *Service) BatchArchiveCourses(courseIds []string) *errortools.Error {
+	return service.BatchArchiveCoursesWithContext(context.Background(), courseIds)
+}
+
+func (service *Service) BatchArchiveCoursesWithContext(ctx context.Context, courseIds []string) *errortools.Error {
 	var maxItemsPerBatch = 100
 	var index = 0
 	for len(courseIds) > index {
 		if len(courseIds) > index+maxItemsPerBatch {
-			e := service.batchArchiveCourses(courseIds[index : index+maxItemsPerBatch])
+			e := service.batchArchiveCourses(ctx, courseIds[index:index+maxItemsPerBatch])
 			if e != nil {
 				return e
 			}
 		} else {
-			e := service.batchArchiveCourses(courseIds[index:])
+			e := service.batchArchiveCourses(ctx, courseIds[index:])
 			if e != nil {
 				return e
 			}
@@ -271,7 +272,7 @@ func (service *Service) BatchArchiveCourses(courseIds []string) *errortools.Erro
 	return nil
 }
 
-func (service *Service) batchArchiveCourses(courseIds []string) *errortools.Error {
+func (service *Service) batchArchiveCourses(ctx context.Context, courseIds []string) *errortools.Error {
 	var body struct {
 		Inputs []struct {
 			Id string `json:"id"`
@@ -290,6 +291,6 @@ func (service *Service) batchArchiveCourses(courseIds []string) *errortools.Erro
 		BodyModel: body,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	return e
 }
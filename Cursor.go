@@ -0,0 +1,77 @@
+package hubspot
+
+import (
+	"fmt"
+	"net/url"
+
+	errortools "github.com/leapforce-libraries/go_errortools"
+)
+
+// Cursor is the position of a paginated request.
+// It serialises to JSON, so a long running export can checkpoint it and resume with the IterXFrom function it was created for.
+// The cursor advances once every record of a page has been yielded, so after resuming at most one page is delivered twice.
+// A Cursor must not be used by more than one iteration at a time.
+type Cursor struct {
+	ObjectType   string               `json:"objectType"`
+	ParentId     string               `json:"parentId,omitempty"` // form or list the records belong to
+	Query        url.Values           `json:"query,omitempty"`
+	Search       *SearchObjectsConfig `json:"search,omitempty"`
+	After        string               `json:"after,omitempty"`  // Paging.Next.After of the last completed page
+	Offset       int64                `json:"offset,omitempty"` // offset or vid-offset of the last completed page of legacy endpoints
+	Done         bool                 `json:"done,omitempty"`
+	Total        int64                `json:"total,omitempty"` // total of the search, as reported by HubSpot
+	Split        *SplitCursor         `json:"split,omitempty"`
+	Associations *AssociationsCursor  `json:"associations,omitempty"`
+}
+
+// SplitCursor is the position of a search split by SplitBy
+type SplitCursor struct {
+	By      string   `json:"by"`
+	From    string   `json:"from,omitempty"`    // lower bound of the current range
+	Skip    []string `json:"skip,omitempty"`    // ids with value From yielded by the previous range
+	Last    string   `json:"last,omitempty"`    // value of the last object yielded
+	LastIds []string `json:"lastIds,omitempty"` // ids with value Last yielded in the current range
+}
+
+// AssociationsCursor is the position of GetAssociations, of which Cursor.ParentId is the object id, or BatchGetAssociations
+type AssociationsCursor struct {
+	ToObjectType string                      `json:"toObjectType"`
+	Inputs       []BatchGetAssociationsInput `json:"inputs,omitempty"` // inputs of BatchGetAssociations still to be read, with the page to continue at
+}
+
+// NewSearchCursor returns a cursor at the start of a search, or at config.After
+func NewSearchCursor(objectType ObjectType, config *SearchObjectsConfig) *Cursor {
+	cursor := Cursor{ObjectType: string(objectType)}
+
+	if config != nil {
+		search := *config
+		search.After = nil
+		cursor.Search = &search
+
+		if config.After != nil {
+			cursor.After = *config.After
+		}
+
+		if config.SplitBy != nil && *config.SplitBy != "" {
+			cursor.After = ""
+			cursor.Split = &SplitCursor{By: *config.SplitBy}
+		}
+	}
+
+	return &cursor
+}
+
+// check validates that cursor can be used for objectType, an empty objectType accepts any
+func (cursor *Cursor) check(objectType string) *errortools.Error {
+	if cursor == nil {
+		return errortools.ErrorMessage("Cursor must not be a nil pointer")
+	}
+	if cursor.ObjectType == "" {
+		return errortools.ErrorMessage("Cursor has no ObjectType")
+	}
+	if objectType != "" && cursor.ObjectType != objectType {
+		return errortools.ErrorMessage(fmt.Sprintf("Cursor of %s cannot be used for %s", cursor.ObjectType, objectType))
+	}
+
+	return nil
+}
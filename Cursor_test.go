@@ -0,0 +1,142 @@
+package hubspot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// resumeCursor returns cursor after a JSON round-trip, as after a checkpoint to disk
+func resumeCursor(t *testing.T, cursor *Cursor) *Cursor {
+	t.Helper()
+
+	checkpoint, err := json.Marshal(cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resumed Cursor
+	if err := json.Unmarshal(checkpoint, &resumed); err != nil {
+		t.Fatal(err)
+	}
+
+	return &resumed
+}
+
+func TestCursorResumeAfterError(t *testing.T) {
+	server := pagedServer{n: 45, pageSize: 10, fail: 3}
+	service := newTestService(t, server.handle, nil)
+
+	limit := uint(10)
+	cursor := NewContactsCursor(&GetContactsConfig{Limit: &limit})
+
+	ids := []string{}
+	for contact, err := range service.IterContactsFrom(context.Background(), cursor) {
+		if err != nil {
+			break
+		}
+		ids = append(ids, contact.Id)
+	}
+	if len(ids) != 20 || cursor.After != "20" || cursor.Done {
+		t.Fatalf("got %v contacts and cursor at %q, want 20 contacts and the cursor at 20", len(ids), cursor.After)
+	}
+
+	server.mutex.Lock()
+	server.fail = 0
+	server.mutex.Unlock()
+	resumed := resumeCursor(t, cursor)
+	for contact, err := range service.IterContactsFrom(context.Background(), resumed) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, contact.Id)
+	}
+
+	checkAllOnce(t, ids, 45)
+	if !resumed.Done {
+		t.Error("expected the resumed cursor to be done")
+	}
+	if query := resumed.Query.Get("limit"); query != "10" {
+		t.Errorf("got limit %q after resuming, want 10", query)
+	}
+
+	// a done cursor requests nothing
+	requests := len(server.requests)
+	for range service.IterContactsFrom(context.Background(), resumed) {
+		t.Fatal("expected no contacts from a done cursor")
+	}
+	if len(server.requests) != requests {
+		t.Errorf("got %v requests for a done cursor, want none", len(server.requests)-requests)
+	}
+}
+
+func TestCursorResumeOffset(t *testing.T) {
+	var offsets []string
+
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		offsets = append(offsets, r.URL.Query().Get("offset"))
+
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		lists := []string{}
+		for id := offset + 1; id <= min(offset+2, 5); id++ {
+			lists = append(lists, fmt.Sprintf(`{"listId":%v,"name":"list %v"}`, id, id))
+		}
+		writeJson(w, http.StatusOK, fmt.Sprintf(`{"lists":[%s],"has-more":%v,"offset":%v}`, strings.Join(lists, ","), offset+2 < 5, offset+2))
+	}, nil)
+
+	cursor := NewContactListsCursor(nil)
+	ids := []int64{}
+	for list, err := range service.IterContactListsFrom(context.Background(), cursor) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, *list.ListId)
+		if len(ids) == 3 {
+			break
+		}
+	}
+
+	// the interrupted second page is delivered again
+	resumed := resumeCursor(t, cursor)
+	if resumed.Offset != 2 {
+		t.Fatalf("got offset %v, want 2", resumed.Offset)
+	}
+	for list, err := range service.IterContactListsFrom(context.Background(), resumed) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, *list.ListId)
+	}
+
+	if fmt.Sprint(ids) != "[1 2 3 3 4 5]" {
+		t.Errorf("got lists %v, want [1 2 3 3 4 5]", ids)
+	}
+	if strings.Join(offsets, ",") != ",2,2,4" {
+		t.Errorf("got offsets %q, want \"\", 2, 2 and 4", offsets)
+	}
+	if !resumed.Done {
+		t.Error("expected the resumed cursor to be done")
+	}
+}
+
+func TestCursorObjectType(t *testing.T) {
+	service := newTestService(t, nil, nil)
+
+	var errs []error
+	for _, err := range service.IterCompaniesFrom(context.Background(), NewContactsCursor(nil)) {
+		errs = append(errs, err)
+	}
+	for _, err := range service.IterContactsFrom(context.Background(), nil) {
+		errs = append(errs, err)
+	}
+
+	if len(errs) != 2 || errs[0] == nil || errs[1] == nil {
+		t.Fatalf("got %v, want an error for each cursor", errs)
+	}
+	if !strings.Contains(errs[0].Error(), "Cursor of contacts cannot be used for companies") {
+		t.Errorf("got error %v, want that the cursor is of contacts", errs[0])
+	}
+}
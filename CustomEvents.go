@@ -1,6 +1,7 @@
 package hubspot
 
 import (
+	"context"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
 	"net/http"
@@ -15,6 +16,10 @@ type SendEventDataConfig struct {
 }
 
 func (service *Service) SendEventData(config *SendEventDataConfig) *errortools.Error {
+	return service.SendEventDataWithContext(context.Background(), config)
+}
+
+func (service *Service) SendEventDataWithContext(ctx context.Context, config *SendEventDataConfig) *errortools.Error {
 	endpoint := "send"
 
 	requestConfig := go_http.RequestConfig{
@@ -23,6 +28,6 @@ func (service *Service) SendEventData(config *SendEventDataConfig) *errortools.E
 		BodyModel: config,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	return e
 }
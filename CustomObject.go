@@ -1,8 +1,10 @@
 package hubspot
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"net/http"
 	"net/url"
 	"strings"
@@ -37,6 +39,10 @@ type GetCustomObjectConfig struct {
 
 // GetCustomObject returns a specific custom object
 func (service *Service) GetCustomObject(config *GetCustomObjectConfig) (*CustomObject, *errortools.Error) {
+	return service.GetCustomObjectWithContext(context.Background(), config)
+}
+
+func (service *Service) GetCustomObjectWithContext(ctx context.Context, config *GetCustomObjectConfig) (*CustomObject, *errortools.Error) {
 	values := url.Values{}
 	endpoint := fmt.Sprintf("objects/%s", config.ObjectType)
 
@@ -75,7 +81,7 @@ func (service *Service) GetCustomObject(config *GetCustomObjectConfig) (*CustomO
 		ResponseModel: &customObject,
 	}
 
-	_, response, e := service.httpRequest(&requestConfig)
+	_, response, e := service.httpRequest(ctx, &requestConfig)
 	if response != nil {
 		if response.StatusCode == http.StatusNotFound {
 			return nil, nil
@@ -100,10 +106,36 @@ type GetCustomObjectsConfig struct {
 
 // GetCustomObjects returns all customObjects
 func (service *Service) GetCustomObjects(config *GetCustomObjectsConfig) (*[]CustomObject, *errortools.Error) {
+	return service.GetCustomObjectsWithContext(context.Background(), config)
+}
+
+func (service *Service) GetCustomObjectsWithContext(ctx context.Context, config *GetCustomObjectsConfig) (*[]CustomObject, *errortools.Error) {
+	return collect(service.iterCustomObjects(ctx, config, config != nil && config.After != nil))
+}
+
+// IterCustomObjects streams all custom objects of config.ObjectType page by page, starting at config.After
+func (service *Service) IterCustomObjects(ctx context.Context, config *GetCustomObjectsConfig) iter.Seq2[CustomObject, error] {
+	return iterErrors(service.iterCustomObjects(ctx, config, false))
+}
+
+func (service *Service) iterCustomObjects(ctx context.Context, config *GetCustomObjectsConfig, singlePage bool) iter.Seq2[CustomObject, *errortools.Error] {
+	if config == nil {
+		return iterError[CustomObject](errortools.ErrorMessage("Config is nil"))
+	}
+
+	return func(yield func(CustomObject, *errortools.Error) bool) {
+		service.iterCustomObjectsFrom(ctx, NewCustomObjectsCursor(config), singlePage)(yield)
+	}
+}
+
+// NewCustomObjectsCursor returns a cursor at the start of GetCustomObjects, or at config.After
+func NewCustomObjectsCursor(config *GetCustomObjectsConfig) *Cursor {
 	values := url.Values{}
-	endpoint := fmt.Sprintf("objects/%s", config.ObjectType)
+	objectType := ""
 
 	if config != nil {
+		objectType = config.ObjectType
+
 		if config.Limit != nil {
 			values.Set("limit", fmt.Sprintf("%v", *config.Limit))
 		}
@@ -136,51 +168,36 @@ func (service *Service) GetCustomObjects(config *GetCustomObjectsConfig) (*[]Cus
 	}
 
 	after := ""
-	if config.After != nil {
+	if config != nil && config.After != nil {
 		after = *config.After
 	}
 
-	customObjects := []CustomObject{}
-
-	for {
-		customObjectsResponse := CustomObjectsResponse{}
-
-		if after != "" {
-			values.Set("after", after)
-		}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodGet,
-			Url:           service.urlCrm(fmt.Sprintf("%s?%s", endpoint, values.Encode())),
-			ResponseModel: &customObjectsResponse,
-		}
-
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
-
-		customObjects = append(customObjects, customObjectsResponse.Results...)
-
-		if config.After != nil { // explicit after parameter requested
-			break
-		}
-
-		if customObjectsResponse.Paging == nil {
-			break
-		}
+	return &Cursor{ObjectType: objectType, Query: values, After: after}
+}
 
-		if customObjectsResponse.Paging.Next.After == "" {
-			break
-		}
+// IterCustomObjectsFrom resumes IterCustomObjects at cursor
+func (service *Service) IterCustomObjectsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[CustomObject, error] {
+	return iterErrors(service.iterCustomObjectsFrom(ctx, cursor, false))
+}
 
-		after = customObjectsResponse.Paging.Next.After
+func (service *Service) iterCustomObjectsFrom(ctx context.Context, cursor *Cursor, singlePage bool) iter.Seq2[CustomObject, *errortools.Error] {
+	if e := cursor.check(""); e != nil {
+		return iterError[CustomObject](e)
 	}
 
-	return &customObjects, nil
+	return iterPages[CustomObject](ctx, service, cursor, singlePage, func(after string) go_http.RequestConfig {
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlCrm(fmt.Sprintf("objects/%s?%s", cursor.ObjectType, encodeWithParam(cursor.Query, "after", after))),
+		}
+	})
 }
 
 func (service *Service) CreateCustomObject(config *CreateObjectConfig) (*CustomObject, *errortools.Error) {
+	return service.CreateCustomObjectWithContext(context.Background(), config)
+}
+
+func (service *Service) CreateCustomObjectWithContext(ctx context.Context, config *CreateObjectConfig) (*CustomObject, *errortools.Error) {
 	endpoint := fmt.Sprintf("objects/%s", config.ObjectType)
 	customObject := CustomObject{}
 
@@ -191,7 +208,7 @@ func (service *Service) CreateCustomObject(config *CreateObjectConfig) (*CustomO
 		ResponseModel: &customObject,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -200,6 +217,10 @@ func (service *Service) CreateCustomObject(config *CreateObjectConfig) (*CustomO
 }
 
 func (service *Service) UpdateCustomObject(config *UpdateObjectConfig) (*CustomObject, *errortools.Error) {
+	return service.UpdateCustomObjectWithContext(context.Background(), config)
+}
+
+func (service *Service) UpdateCustomObjectWithContext(ctx context.Context, config *UpdateObjectConfig) (*CustomObject, *errortools.Error) {
 	endpoint := fmt.Sprintf("objects/%s", config.ObjectType)
 
 	customObject := CustomObject{}
@@ -211,7 +232,7 @@ func (service *Service) UpdateCustomObject(config *UpdateObjectConfig) (*CustomO
 		ResponseModel: &customObject,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -220,16 +241,20 @@ func (service *Service) UpdateCustomObject(config *UpdateObjectConfig) (*CustomO
 }
 
 func (service *Service) BatchArchiveCustomObjects(objectType string, customObjectIds []string) *errortools.Error {
+	return service.BatchArchiveCustomObjectsWithContext(context.Background(), objectType, customObjectIds)
+}
+
+func (service *Service) BatchArchiveCustomObjectsWithContext(ctx context.Context, objectType string, customObjectIds []string) *errortools.Error {
 	var maxItemsPerBatch = 100
 	var index = 0
 	for len(customObjectIds) > index {
 		if len(customObjectIds) > index+maxItemsPerBatch {
-			e := service.batchArchiveCustomObjects(objectType, customObjectIds[index:index+maxItemsPerBatch])
+			e := service.batchArchiveCustomObjects(ctx, objectType, customObjectIds[index:index+maxItemsPerBatch])
 			if e != nil {
 				return e
 			}
 		} else {
-			e := service.batchArchiveCustomObjects(objectType, customObjectIds[index:])
+			e := service.batchArchiveCustomObjects(ctx, objectType, customObjectIds[index:])
 			if e != nil {
 				return e
 			}
@@ -241,7 +266,7 @@ func (service *Service) BatchArchiveCustomObjects(objectType string, customObjec
 	return nil
 }
 
-func (service *Service) batchArchiveCustomObjects(objectType string, customObjectIds []string) *errortools.Error {
+func (service *Service) batchArchiveCustomObjects(ctx context.Context, objectType string, customObjectIds []string) *errortools.Error {
 	var body struct {
 		Inputs []struct {
 			Id string `json:"id"`
@@ -260,10 +285,13 @@ func (service *Service) batchArchiveCustomObjects(objectType string, customObjec
 		BodyModel: body,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	return e
 }
 
+// BatchCustomObjectsResponse is the response of a batch request of customObjects
+//
+// Deprecated: the batch functions return a BatchResult
 type BatchCustomObjectsResponse struct {
 	CompletedAt *time.Time        `json:"completedAt"`
 	NumErrors   int               `json:"numErrors"`
@@ -292,66 +320,50 @@ type BatchCustomObjectsResponse struct {
 	Status string `json:"status"`
 }
 
+// BatchCreateCustomObjects returns the created custom objects, the inputs that failed are only logged
+//
+// Deprecated: use BatchCreateCustomObjectsWithResult, which also returns the inputs that failed
 func (service *Service) BatchCreateCustomObjects(config *BatchObjectsConfig) (*[]CustomObject, *errortools.Error) {
-	var customObjects []CustomObject
-
-	for _, batch := range service.batches(len(config.Inputs)) {
-		var r BatchCustomObjectsResponse
+	result, e := service.BatchCreateCustomObjectsWithResultWithContext(context.Background(), config)
+	if e != nil {
+		return nil, e
+	}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(fmt.Sprintf("objects/%s/batch/create", config.ObjectType)),
-			BodyModel:     BatchObjectsConfig{Inputs: config.Inputs[batch.startIndex:batch.endIndex]},
-			ResponseModel: &r,
-		}
+	return &result.Successes, nil
+}
 
-		_, response, e := service.httpRequest(&requestConfig)
-		if response != nil {
-			if response.StatusCode == http.StatusMultiStatus {
-				fmt.Println(r.Errors)
-				goto ok
-			}
-		}
-		if e != nil {
-			return nil, e
-		}
-	ok:
-		customObjects = append(customObjects, r.Results...)
+func (service *Service) BatchCreateCustomObjectsWithResult(config *BatchObjectsConfig) (*BatchResult[CustomObject], *errortools.Error) {
+	return service.BatchCreateCustomObjectsWithResultWithContext(context.Background(), config)
+}
 
-		fmt.Println("batch", batch.startIndex)
+func (service *Service) BatchCreateCustomObjectsWithResultWithContext(ctx context.Context, config *BatchObjectsConfig) (*BatchResult[CustomObject], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
 	}
 
-	return &customObjects, nil
+	return batchObjects[CustomObject](ctx, service, ObjectType(config.ObjectType), "create", config)
 }
 
+// BatchUpdateCustomObjects returns the updated custom objects, the inputs that failed are only logged
+//
+// Deprecated: use BatchUpdateCustomObjectsWithResult, which also returns the inputs that failed
 func (service *Service) BatchUpdateCustomObjects(config *BatchObjectsConfig) (*[]CustomObject, *errortools.Error) {
-	var customObjects []CustomObject
-
-	for _, batch := range service.batches(len(config.Inputs)) {
-		var r BatchCustomObjectsResponse
+	result, e := service.BatchUpdateCustomObjectsWithResultWithContext(context.Background(), config)
+	if e != nil {
+		return nil, e
+	}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(fmt.Sprintf("objects/%s/batch/update", config.ObjectType)),
-			BodyModel:     BatchObjectsConfig{Inputs: config.Inputs[batch.startIndex:batch.endIndex]},
-			ResponseModel: &r,
-		}
+	return &result.Successes, nil
+}
 
-		_, response, e := service.httpRequest(&requestConfig)
-		if response != nil {
-			if response.StatusCode == http.StatusMultiStatus {
-				fmt.Println(r.Errors)
-				goto ok
-			}
-		}
-		if e != nil {
-			return nil, e
-		}
-	ok:
-		customObjects = append(customObjects, r.Results...)
+func (service *Service) BatchUpdateCustomObjectsWithResult(config *BatchObjectsConfig) (*BatchResult[CustomObject], *errortools.Error) {
+	return service.BatchUpdateCustomObjectsWithResultWithContext(context.Background(), config)
+}
 
-		fmt.Println("batch", batch.startIndex)
+func (service *Service) BatchUpdateCustomObjectsWithResultWithContext(ctx context.Context, config *BatchObjectsConfig) (*BatchResult[CustomObject], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
 	}
 
-	return &customObjects, nil
+	return batchObjects[CustomObject](ctx, service, ObjectType(config.ObjectType), "update", config)
 }
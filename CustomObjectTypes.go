@@ -1,6 +1,7 @@
 package hubspot
 
 import (
+	"context"
 	"fmt"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
@@ -49,6 +50,10 @@ type CustomObjectType struct {
 }
 
 func (service *Service) GetCustomObjectTypes() (*[]CustomObjectType, *errortools.Error) {
+	return service.GetCustomObjectTypesWithContext(context.Background())
+}
+
+func (service *Service) GetCustomObjectTypesWithContext(ctx context.Context) (*[]CustomObjectType, *errortools.Error) {
 	var response CustomObjectTypesResponse
 
 	requestConfig := go_http.RequestConfig{
@@ -57,7 +62,7 @@ func (service *Service) GetCustomObjectTypes() (*[]CustomObjectType, *errortools
 		ResponseModel: &response,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -66,6 +71,10 @@ func (service *Service) GetCustomObjectTypes() (*[]CustomObjectType, *errortools
 }
 
 func (service *Service) CreateCustomObjectType(schema *CustomObjectTypeSchema) (*CustomObjectType, *errortools.Error) {
+	return service.CreateCustomObjectTypeWithContext(context.Background(), schema)
+}
+
+func (service *Service) CreateCustomObjectTypeWithContext(ctx context.Context, schema *CustomObjectTypeSchema) (*CustomObjectType, *errortools.Error) {
 	var customObjectType CustomObjectType
 
 	requestConfig := go_http.RequestConfig{
@@ -75,7 +84,7 @@ func (service *Service) CreateCustomObjectType(schema *CustomObjectTypeSchema) (
 		ResponseModel: &customObjectType,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -84,6 +93,10 @@ func (service *Service) CreateCustomObjectType(schema *CustomObjectTypeSchema) (
 }
 
 func (service *Service) UpdateCustomObjectType(objectTypeId string, schema *CustomObjectTypeSchema) (*CustomObjectType, *errortools.Error) {
+	return service.UpdateCustomObjectTypeWithContext(context.Background(), objectTypeId, schema)
+}
+
+func (service *Service) UpdateCustomObjectTypeWithContext(ctx context.Context, objectTypeId string, schema *CustomObjectTypeSchema) (*CustomObjectType, *errortools.Error) {
 	var customObjectType CustomObjectType
 
 	requestConfig := go_http.RequestConfig{
@@ -93,7 +106,7 @@ func (service *Service) UpdateCustomObjectType(objectTypeId string, schema *Cust
 		ResponseModel: &customObjectType,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -1,11 +1,13 @@
 package hubspot
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
 	h_types "github.com/leapforce-libraries/go_hubspot/types"
+	"iter"
 	"net/http"
 	"net/url"
 	"strings"
@@ -39,8 +41,27 @@ type GetDealsConfig struct {
 
 // GetDeals returns all deals
 func (service *Service) GetDeals(config *GetDealsConfig) (*[]Deal, *errortools.Error) {
+	return service.GetDealsWithContext(context.Background(), config)
+}
+
+func (service *Service) GetDealsWithContext(ctx context.Context, config *GetDealsConfig) (*[]Deal, *errortools.Error) {
+	return collect(service.iterDeals(ctx, config, config != nil && config.After != nil))
+}
+
+// IterDeals streams all deals page by page, starting at config.After
+func (service *Service) IterDeals(ctx context.Context, config *GetDealsConfig) iter.Seq2[Deal, error] {
+	return iterErrors(service.iterDeals(ctx, config, false))
+}
+
+func (service *Service) iterDeals(ctx context.Context, config *GetDealsConfig, singlePage bool) iter.Seq2[Deal, *errortools.Error] {
+	return func(yield func(Deal, *errortools.Error) bool) {
+		service.iterDealsFrom(ctx, NewDealsCursor(config), singlePage)(yield)
+	}
+}
+
+// NewDealsCursor returns a cursor at the start of GetDeals, or at config.After
+func NewDealsCursor(config *GetDealsConfig) *Cursor {
 	values := url.Values{}
-	endpoint := "objects/deals"
 
 	if config != nil {
 		if config.Limit != nil {
@@ -75,51 +96,36 @@ func (service *Service) GetDeals(config *GetDealsConfig) (*[]Deal, *errortools.E
 	}
 
 	after := ""
-	if config.After != nil {
+	if config != nil && config.After != nil {
 		after = *config.After
 	}
 
-	deals := []Deal{}
-
-	for {
-		dealsResponse := DealsResponse{}
-
-		if after != "" {
-			values.Set("after", after)
-		}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodGet,
-			Url:           service.urlCrm(fmt.Sprintf("%s?%s", endpoint, values.Encode())),
-			ResponseModel: &dealsResponse,
-		}
-
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
-
-		deals = append(deals, dealsResponse.Results...)
-
-		if config.After != nil { // explicit after parameter requested
-			break
-		}
-
-		if dealsResponse.Paging == nil {
-			break
-		}
+	return &Cursor{ObjectType: string(ObjectTypeDeals), Query: values, After: after}
+}
 
-		if dealsResponse.Paging.Next.After == "" {
-			break
-		}
+// IterDealsFrom resumes IterDeals at cursor
+func (service *Service) IterDealsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[Deal, error] {
+	return iterErrors(service.iterDealsFrom(ctx, cursor, false))
+}
 
-		after = dealsResponse.Paging.Next.After
+func (service *Service) iterDealsFrom(ctx context.Context, cursor *Cursor, singlePage bool) iter.Seq2[Deal, *errortools.Error] {
+	if e := cursor.check(string(ObjectTypeDeals)); e != nil {
+		return iterError[Deal](e)
 	}
 
-	return &deals, nil
+	return iterPages[Deal](ctx, service, cursor, singlePage, func(after string) go_http.RequestConfig {
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlCrm(fmt.Sprintf("objects/%s?%s", cursor.ObjectType, encodeWithParam(cursor.Query, "after", after))),
+		}
+	})
 }
 
 func (service *Service) CreateDeal(config *CreateObjectConfig) (*Deal, *errortools.Error) {
+	return service.CreateDealWithContext(context.Background(), config)
+}
+
+func (service *Service) CreateDealWithContext(ctx context.Context, config *CreateObjectConfig) (*Deal, *errortools.Error) {
 	endpoint := "objects/deals"
 	deal := Deal{}
 
@@ -130,7 +136,7 @@ func (service *Service) CreateDeal(config *CreateObjectConfig) (*Deal, *errortoo
 		ResponseModel: &deal,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -138,6 +144,9 @@ func (service *Service) CreateDeal(config *CreateObjectConfig) (*Deal, *errortoo
 	return &deal, nil
 }
 
+// BatchDealsResponse is the response of a batch request of deals
+//
+// Deprecated: the batch functions return a BatchResult
 type BatchDealsResponse struct {
 	CompletedAt *time.Time        `json:"completedAt"`
 	NumErrors   int               `json:"numErrors"`
@@ -166,71 +175,59 @@ type BatchDealsResponse struct {
 	Status string `json:"status"`
 }
 
+// BatchCreateDeals returns the created deals, the inputs that failed are only logged
+//
+// Deprecated: use BatchCreateDealsWithResult, which also returns the inputs that failed
 func (service *Service) BatchCreateDeals(config *BatchObjectsConfig) (*[]Deal, *errortools.Error) {
-	var deals []Deal
-
-	for _, batch := range service.batches(len(config.Inputs)) {
-		var r BatchDealsResponse
+	result, e := service.BatchCreateDealsWithResultWithContext(context.Background(), config)
+	if e != nil {
+		return nil, e
+	}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(fmt.Sprintf("objects/%s/batch/create", config.ObjectType)),
-			BodyModel:     BatchObjectsConfig{Inputs: config.Inputs[batch.startIndex:batch.endIndex]},
-			ResponseModel: &r,
-		}
+	return &result.Successes, nil
+}
 
-		_, response, e := service.httpRequest(&requestConfig)
-		if response != nil {
-			if response.StatusCode == http.StatusMultiStatus {
-				fmt.Println(r.Errors)
-				goto ok
-			}
-		}
-		if e != nil {
-			return nil, e
-		}
-	ok:
-		deals = append(deals, r.Results...)
+func (service *Service) BatchCreateDealsWithResult(config *BatchObjectsConfig) (*BatchResult[Deal], *errortools.Error) {
+	return service.BatchCreateDealsWithResultWithContext(context.Background(), config)
+}
 
-		fmt.Println("batch", batch.startIndex)
+func (service *Service) BatchCreateDealsWithResultWithContext(ctx context.Context, config *BatchObjectsConfig) (*BatchResult[Deal], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
 	}
 
-	return &deals, nil
+	return batchObjects[Deal](ctx, service, ObjectType(config.ObjectType), "create", config)
 }
 
+// BatchUpdateDeals returns the updated deals, the inputs that failed are only logged
+//
+// Deprecated: use BatchUpdateDealsWithResult, which also returns the inputs that failed
 func (service *Service) BatchUpdateDeals(config *BatchObjectsConfig) (*[]Deal, *errortools.Error) {
-	var deals []Deal
-
-	for _, batch := range service.batches(len(config.Inputs)) {
-		var r BatchDealsResponse
+	result, e := service.BatchUpdateDealsWithResultWithContext(context.Background(), config)
+	if e != nil {
+		return nil, e
+	}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(fmt.Sprintf("objects/%s/batch/update", config.ObjectType)),
-			BodyModel:     BatchObjectsConfig{Inputs: config.Inputs[batch.startIndex:batch.endIndex]},
-			ResponseModel: &r,
-		}
+	return &result.Successes, nil
+}
 
-		_, response, e := service.httpRequest(&requestConfig)
-		if response != nil {
-			if response.StatusCode == http.StatusMultiStatus {
-				fmt.Println(r.Errors)
-				goto ok
-			}
-		}
-		if e != nil {
-			return nil, e
-		}
-	ok:
-		deals = append(deals, r.Results...)
+func (service *Service) BatchUpdateDealsWithResult(config *BatchObjectsConfig) (*BatchResult[Deal], *errortools.Error) {
+	return service.BatchUpdateDealsWithResultWithContext(context.Background(), config)
+}
 
-		fmt.Println("batch", batch.startIndex)
+func (service *Service) BatchUpdateDealsWithResultWithContext(ctx context.Context, config *BatchObjectsConfig) (*BatchResult[Deal], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
 	}
 
-	return &deals, nil
+	return batchObjects[Deal](ctx, service, ObjectType(config.ObjectType), "update", config)
 }
 
 func (service *Service) UpdateDeal(config *UpdateObjectConfig) (*Deal, *errortools.Error) {
+	return service.UpdateDealWithContext(context.Background(), config)
+}
+
+func (service *Service) UpdateDealWithContext(ctx context.Context, config *UpdateObjectConfig) (*Deal, *errortools.Error) {
 	endpoint := "objects/deals"
 	deal := Deal{}
 
@@ -241,7 +238,7 @@ func (service *Service) UpdateDeal(config *UpdateObjectConfig) (*Deal, *errortoo
 		ResponseModel: &deal,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -250,16 +247,20 @@ func (service *Service) UpdateDeal(config *UpdateObjectConfig) (*Deal, *errortoo
 }
 
 func (service *Service) BatchArchiveDeals(dealIds []string) *errortools.Error {
+	return service.BatchArchiveDealsWithContext(context.Background(), dealIds)
+}
+
+func (service *Service) BatchArchiveDealsWithContext(ctx context.Context, dealIds []string) *errortools.Error {
 	var maxItemsPerBatch = 100
 	var index = 0
 	for len(dealIds) > index {
 		if len(dealIds) > index+maxItemsPerBatch {
-			e := service.batchArchiveDeals(dealIds[index : index+maxItemsPerBatch])
+			e := service.batchArchiveDeals(ctx, dealIds[index:index+maxItemsPerBatch])
 			if e != nil {
 				return e
 			}
 		} else {
-			e := service.batchArchiveDeals(dealIds[index:])
+			e := service.batchArchiveDeals(ctx, dealIds[index:])
 			if e != nil {
 				return e
 			}
@@ -271,7 +272,7 @@ func (service *Service) BatchArchiveDeals(dealIds []string) *errortools.Error {
 	return nil
 }
 
-func (service *Service) batchArchiveDeals(dealIds []string) *errortools.Error {
+func (service *Service) batchArchiveDeals(ctx context.Context, dealIds []string) *errortools.Error {
 	var body struct {
 		Inputs []struct {
 			Id string `json:"id"`
@@ -290,6 +291,6 @@ func (service *Service) batchArchiveDeals(dealIds []string) *errortools.Error {
 		BodyModel: body,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	return e
 }
@@ -15,6 +15,7 @@ type EmailAssociation struct {
 	Types []AssociationTypeV4 `json:"types"`
 }
 
+// NewEmailAssociation returns the association of an email with toId, e.g. NewEmailAssociation(contactId, AssociationCategoryHubSpotDefined, AssociationTypeEmailToContact)
 func NewEmailAssociation(toId string, category string, typeId int64) EmailAssociation {
 	return EmailAssociation{
 		To: struct {
@@ -1,8 +1,10 @@
 package hubspot
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"net/http"
 	"net/url"
 	"strings"
@@ -51,12 +53,35 @@ type ListEngagementsConfig struct {
 
 // ListEngagements returns all engagements
 func (service *Service) ListEngagements(config *ListEngagementsConfig) (*[]Engagement, *errortools.Error) {
+	return service.ListEngagementsWithContext(context.Background(), config)
+}
+
+func (service *Service) ListEngagementsWithContext(ctx context.Context, config *ListEngagementsConfig) (*[]Engagement, *errortools.Error) {
+	return collect(service.iterEngagements(ctx, config, config != nil && config.After != nil))
+}
+
+// IterEngagements streams all engagements of config.Type page by page, starting at config.After
+func (service *Service) IterEngagements(ctx context.Context, config *ListEngagementsConfig) iter.Seq2[Engagement, error] {
+	return iterErrors(service.iterEngagements(ctx, config, false))
+}
+
+func (service *Service) iterEngagements(ctx context.Context, config *ListEngagementsConfig, singlePage bool) iter.Seq2[Engagement, *errortools.Error] {
+	if config == nil {
+		return iterError[Engagement](errortools.ErrorMessage("Config must nog be nil"))
+	}
+
+	return func(yield func(Engagement, *errortools.Error) bool) {
+		service.iterEngagementsFrom(ctx, NewEngagementsCursor(config), singlePage)(yield)
+	}
+}
+
+// NewEngagementsCursor returns a cursor at the start of ListEngagements, or at config.After
+func NewEngagementsCursor(config *ListEngagementsConfig) *Cursor {
 	if config == nil {
-		return nil, errortools.ErrorMessage("Config must nog be nil")
+		return &Cursor{}
 	}
 
 	values := url.Values{}
-	endpoint := fmt.Sprintf("objects/%v", config.Type)
 
 	after := ""
 
@@ -89,44 +114,25 @@ func (service *Service) ListEngagements(config *ListEngagementsConfig) (*[]Engag
 		after = *config.After
 	}
 
-	var engagements []Engagement
-
-	for {
-		engagementsResponse := EngagementsResponse{}
-
-		if after != "" {
-			values.Set("after", after)
-		}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodGet,
-			Url:           service.urlCrm(fmt.Sprintf("%s?%s", endpoint, values.Encode())),
-			ResponseModel: &engagementsResponse,
-		}
-
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
-
-		engagements = append(engagements, engagementsResponse.Results...)
-
-		if config.After != nil { // explicit after parameter requested
-			break
-		}
-
-		if engagementsResponse.Paging == nil {
-			break
-		}
+	return &Cursor{ObjectType: string(config.Type), Query: values, After: after}
+}
 
-		if engagementsResponse.Paging.Next.After == "" {
-			break
-		}
+// IterEngagementsFrom resumes IterEngagements at cursor
+func (service *Service) IterEngagementsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[Engagement, error] {
+	return iterErrors(service.iterEngagementsFrom(ctx, cursor, false))
+}
 
-		after = engagementsResponse.Paging.Next.After
+func (service *Service) iterEngagementsFrom(ctx context.Context, cursor *Cursor, singlePage bool) iter.Seq2[Engagement, *errortools.Error] {
+	if e := cursor.check(""); e != nil {
+		return iterError[Engagement](e)
 	}
 
-	return &engagements, nil
+	return iterPages[Engagement](ctx, service, cursor, singlePage, func(after string) go_http.RequestConfig {
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlCrm(fmt.Sprintf("objects/%s?%s", cursor.ObjectType, encodeWithParam(cursor.Query, "after", after))),
+		}
+	})
 }
 
 type CreateEngagementConfig struct {
@@ -136,6 +142,10 @@ type CreateEngagementConfig struct {
 }
 
 func (service *Service) CreateEngagement(config *CreateEngagementConfig) (*Engagement, *errortools.Error) {
+	return service.CreateEngagementWithContext(context.Background(), config)
+}
+
+func (service *Service) CreateEngagementWithContext(ctx context.Context, config *CreateEngagementConfig) (*Engagement, *errortools.Error) {
 	endpoint := fmt.Sprintf("objects/%v", config.Type)
 	engagement := Engagement{}
 
@@ -146,7 +156,7 @@ func (service *Service) CreateEngagement(config *CreateEngagementConfig) (*Engag
 		ResponseModel: &engagement,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -161,6 +171,10 @@ type UpdateEngagementConfig struct {
 }
 
 func (service *Service) UpdateEngagement(config *UpdateEngagementConfig) (*Engagement, *errortools.Error) {
+	return service.UpdateEngagementWithContext(context.Background(), config)
+}
+
+func (service *Service) UpdateEngagementWithContext(ctx context.Context, config *UpdateEngagementConfig) (*Engagement, *errortools.Error) {
 	endpoint := fmt.Sprintf("objects/%v", config.Type)
 	engagement := Engagement{}
 
@@ -185,7 +199,7 @@ func (service *Service) UpdateEngagement(config *UpdateEngagementConfig) (*Engag
 		ResponseModel: &engagement,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -194,16 +208,20 @@ func (service *Service) UpdateEngagement(config *UpdateEngagementConfig) (*Engag
 }
 
 func (service *Service) BatchArchiveEngagements(engagementType EngagementType, engagementIds []string) *errortools.Error {
+	return service.BatchArchiveEngagementsWithContext(context.Background(), engagementType, engagementIds)
+}
+
+func (service *Service) BatchArchiveEngagementsWithContext(ctx context.Context, engagementType EngagementType, engagementIds []string) *errortools.Error {
 	var maxItemsPerBatch = 100
 	var index = 0
 	for len(engagementIds) > index {
 		if len(engagementIds) > index+maxItemsPerBatch {
-			e := service.batchArchiveEngagements(engagementType, engagementIds[index:index+maxItemsPerBatch])
+			e := service.batchArchiveEngagements(ctx, engagementType, engagementIds[index:index+maxItemsPerBatch])
 			if e != nil {
 				return e
 			}
 		} else {
-			e := service.batchArchiveEngagements(engagementType, engagementIds[index:])
+			e := service.batchArchiveEngagements(ctx, engagementType, engagementIds[index:])
 			if e != nil {
 				return e
 			}
@@ -215,7 +233,7 @@ func (service *Service) BatchArchiveEngagements(engagementType EngagementType, e
 	return nil
 }
 
-func (service *Service) batchArchiveEngagements(engagementType EngagementType, engagementIds []string) *errortools.Error {
+func (service *Service) batchArchiveEngagements(ctx context.Context, engagementType EngagementType, engagementIds []string) *errortools.Error {
 	var body struct {
 		Inputs []struct {
 			Id string `json:"id"`
@@ -234,71 +252,44 @@ func (service *Service) batchArchiveEngagements(engagementType EngagementType, e
 		BodyModel: body,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	return e
 }
 
 // SearchEngagements returns a specific engagement
 func (service *Service) SearchEngagements(objectType ObjectType, config *SearchObjectsConfig) (*[]Engagement, *errortools.Error) {
-	if config == nil {
-		return nil, errortools.ErrorMessage("Config is nil")
-	}
-
-	endpoint := fmt.Sprintf("objects/%s/search", objectType)
-
-	engagementsResponse := EngagementsResponse{}
-
-	requestConfig := go_http.RequestConfig{
-		Method:        http.MethodPost,
-		Url:           service.urlCrm(endpoint),
-		BodyModel:     config,
-		ResponseModel: &engagementsResponse,
-	}
-
-	_, _, e := service.httpRequest(&requestConfig)
-	if e != nil {
-		return nil, e
-	}
-
-	after := config.After
-
-	var engagements []Engagement
-
-	for {
-		engagementsResponse := EngagementsResponse{}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(endpoint),
-			BodyModel:     config,
-			ResponseModel: &engagementsResponse,
-		}
-
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
-
-		engagements = append(engagements, engagementsResponse.Results...)
+	return service.SearchEngagementsWithContext(context.Background(), objectType, config)
+}
 
-		if after != nil { // explicit after parameter requested
-			break
-		}
+func (service *Service) SearchEngagementsWithContext(ctx context.Context, objectType ObjectType, config *SearchObjectsConfig) (*[]Engagement, *errortools.Error) {
+	return collect(iterSearch[Engagement](ctx, service, NewSearchCursor(objectType, config), objectType, config != nil && config.After != nil))
+}
 
-		if engagementsResponse.Paging == nil {
-			break
-		}
+// SearchEngagementsWithTotal returns the engagements matching config and the total HubSpot reports for the search
+func (service *Service) SearchEngagementsWithTotal(objectType ObjectType, config *SearchObjectsConfig) (*SearchResult[Engagement], *errortools.Error) {
+	return service.SearchEngagementsWithTotalWithContext(context.Background(), objectType, config)
+}
 
-		if engagementsResponse.Paging.Next.After == "" {
-			break
-		}
+func (service *Service) SearchEngagementsWithTotalWithContext(ctx context.Context, objectType ObjectType, config *SearchObjectsConfig) (*SearchResult[Engagement], *errortools.Error) {
+	cursor := NewSearchCursor(objectType, config)
+	return collectSearch(cursor, iterSearch[Engagement](ctx, service, cursor, objectType, config != nil && config.After != nil))
+}
 
-		config.After = &engagementsResponse.Paging.Next.After
-	}
+// IterSearchEngagements streams all engagements matching config page by page, starting at config.After
+func (service *Service) IterSearchEngagements(ctx context.Context, objectType ObjectType, config *SearchObjectsConfig) iter.Seq2[Engagement, error] {
+	return iterErrors(func(yield func(Engagement, *errortools.Error) bool) {
+		iterSearch[Engagement](ctx, service, NewSearchCursor(objectType, config), objectType, false)(yield)
+	})
+}
 
-	return &engagements, nil
+// IterSearchEngagementsFrom resumes IterSearchEngagements at a cursor created by NewSearchCursor
+func (service *Service) IterSearchEngagementsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[Engagement, error] {
+	return iterErrors(iterSearch[Engagement](ctx, service, cursor, "", false))
 }
 
+// BatchEngagementsResponse is the response of a batch request of engagements
+//
+// Deprecated: the batch functions return a BatchResult
 type BatchEngagementsResponse struct {
 	CompletedAt *time.Time        `json:"completedAt"`
 	NumErrors   int               `json:"numErrors"`
@@ -327,66 +318,50 @@ type BatchEngagementsResponse struct {
 	Status string `json:"status"`
 }
 
+// BatchCreateEngagements returns the created engagements, the inputs that failed are only logged
+//
+// Deprecated: use BatchCreateEngagementsWithResult, which also returns the inputs that failed
 func (service *Service) BatchCreateEngagements(config *BatchObjectsConfig) (*[]Engagement, *errortools.Error) {
-	var engagements []Engagement
-
-	for _, batch := range service.batches(len(config.Inputs)) {
-		var r BatchEngagementsResponse
+	result, e := service.BatchCreateEngagementsWithResultWithContext(context.Background(), config)
+	if e != nil {
+		return nil, e
+	}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(fmt.Sprintf("objects/%s/batch/create", config.ObjectType)),
-			BodyModel:     BatchObjectsConfig{Inputs: config.Inputs[batch.startIndex:batch.endIndex]},
-			ResponseModel: &r,
-		}
+	return &result.Successes, nil
+}
 
-		_, response, e := service.httpRequest(&requestConfig)
-		if response != nil {
-			if response.StatusCode == http.StatusMultiStatus {
-				fmt.Println(r.Errors)
-				goto ok
-			}
-		}
-		if e != nil {
-			return nil, e
-		}
-	ok:
-		engagements = append(engagements, r.Results...)
+func (service *Service) BatchCreateEngagementsWithResult(config *BatchObjectsConfig) (*BatchResult[Engagement], *errortools.Error) {
+	return service.BatchCreateEngagementsWithResultWithContext(context.Background(), config)
+}
 
-		fmt.Println("batch", batch.startIndex)
+func (service *Service) BatchCreateEngagementsWithResultWithContext(ctx context.Context, config *BatchObjectsConfig) (*BatchResult[Engagement], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
 	}
 
-	return &engagements, nil
+	return batchObjects[Engagement](ctx, service, ObjectType(config.ObjectType), "create", config)
 }
 
+// BatchUpdateEngagements returns the updated engagements, the inputs that failed are only logged
+//
+// Deprecated: use BatchUpdateEngagementsWithResult, which also returns the inputs that failed
 func (service *Service) BatchUpdateEngagements(config *BatchObjectsConfig) (*[]Engagement, *errortools.Error) {
-	var engagements []Engagement
-
-	for _, batch := range service.batches(len(config.Inputs)) {
-		var r BatchEngagementsResponse
+	result, e := service.BatchUpdateEngagementsWithResultWithContext(context.Background(), config)
+	if e != nil {
+		return nil, e
+	}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(fmt.Sprintf("objects/%s/batch/update", config.ObjectType)),
-			BodyModel:     BatchObjectsConfig{Inputs: config.Inputs[batch.startIndex:batch.endIndex]},
-			ResponseModel: &r,
-		}
+	return &result.Successes, nil
+}
 
-		_, response, e := service.httpRequest(&requestConfig)
-		if response != nil {
-			if response.StatusCode == http.StatusMultiStatus {
-				fmt.Println(r.Errors)
-				goto ok
-			}
-		}
-		if e != nil {
-			return nil, e
-		}
-	ok:
-		engagements = append(engagements, r.Results...)
+func (service *Service) BatchUpdateEngagementsWithResult(config *BatchObjectsConfig) (*BatchResult[Engagement], *errortools.Error) {
+	return service.BatchUpdateEngagementsWithResultWithContext(context.Background(), config)
+}
 
-		fmt.Println("batch", batch.startIndex)
+func (service *Service) BatchUpdateEngagementsWithResultWithContext(ctx context.Context, config *BatchObjectsConfig) (*BatchResult[Engagement], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
 	}
 
-	return &engagements, nil
+	return batchObjects[Engagement](ctx, service, ObjectType(config.ObjectType), "update", config)
 }
@@ -1,11 +1,14 @@
 package hubspot
 
 import (
+	"context"
 	"fmt"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
+	"iter"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 )
 
@@ -62,6 +65,26 @@ type GetRecentEngagementsConfig struct {
 }
 
 func (service *Service) GetRecentEngagements(config *GetRecentEngagementsConfig) (*[]EngagementOld, *errortools.Error) {
+	return service.GetRecentEngagementsWithContext(context.Background(), config)
+}
+
+func (service *Service) GetRecentEngagementsWithContext(ctx context.Context, config *GetRecentEngagementsConfig) (*[]EngagementOld, *errortools.Error) {
+	return collect(service.iterRecentEngagements(ctx, config))
+}
+
+// IterRecentEngagements streams recently modified engagements page by page
+func (service *Service) IterRecentEngagements(ctx context.Context, config *GetRecentEngagementsConfig) iter.Seq2[EngagementOld, error] {
+	return iterErrors(service.iterRecentEngagements(ctx, config))
+}
+
+func (service *Service) iterRecentEngagements(ctx context.Context, config *GetRecentEngagementsConfig) iter.Seq2[EngagementOld, *errortools.Error] {
+	return func(yield func(EngagementOld, *errortools.Error) bool) {
+		service.iterRecentEngagementsFrom(ctx, NewRecentEngagementsCursor(config))(yield)
+	}
+}
+
+// NewRecentEngagementsCursor returns a cursor at the start of GetRecentEngagements
+func NewRecentEngagementsCursor(config *GetRecentEngagementsConfig) *Cursor {
 	values := url.Values{}
 	count := uint(100)
 	if config != nil {
@@ -74,40 +97,34 @@ func (service *Service) GetRecentEngagements(config *GetRecentEngagementsConfig)
 	}
 	values.Set("count", fmt.Sprintf("%v", count))
 
-	var engagements []EngagementOld
-
-	var offset uint = 0
+	return &Cursor{ObjectType: "recent_engagements", Query: values}
+}
 
-	for {
-		if offset+count > 10000 {
-			// Recently modified engagements result set is limited to 10000 items, please adjust your count & offset parameters so that their sum doesn't exceed 10000.
-			break
-		}
-		if offset > 0 {
-			values.Set("offset", fmt.Sprintf("%v", offset))
-		}
+// IterRecentEngagementsFrom resumes IterRecentEngagements at cursor
+func (service *Service) IterRecentEngagementsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[EngagementOld, error] {
+	return iterErrors(service.iterRecentEngagementsFrom(ctx, cursor))
+}
 
-		var engagementsResponse EngagementOldsResponse
+func (service *Service) iterRecentEngagementsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[EngagementOld, *errortools.Error] {
+	if e := cursor.check("recent_engagements"); e != nil {
+		return iterError[EngagementOld](e)
+	}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodGet,
-			Url:           fmt.Sprintf("https://api.hubapi.com/engagements/v1/engagements/recent/modified?%s", values.Encode()),
-			ResponseModel: &engagementsResponse,
-		}
+	count, _ := strconv.ParseInt(cursor.Query.Get("count"), 10, 64)
 
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
+	return iterOffsetPages(ctx, service, cursor, false, func(offset int64) go_http.RequestConfig {
+		_offset := ""
+		if offset > 0 {
+			_offset = fmt.Sprintf("%v", offset)
 		}
 
-		engagements = append(engagements, engagementsResponse.Results...)
-
-		if !engagementsResponse.HasMore {
-			break
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlEngagements(fmt.Sprintf("engagements/recent/modified?%s", encodeWithParam(cursor.Query, "offset", _offset))),
 		}
-
-		offset = uint(engagementsResponse.Offset)
-	}
-
-	return &engagements, nil
+	}, func(response *EngagementOldsResponse) ([]EngagementOld, bool, int64) {
+		// Recently modified engagements result set is limited to 10000 items, please adjust your count & offset parameters so that their sum doesn't exceed 10000.
+		hasMore := response.HasMore && response.Offset+count <= 10000
+		return response.Results, hasMore, response.Offset
+	})
 }
@@ -1,17 +1,32 @@
 package hubspot
 
+import "encoding/json"
+
 // ErrorResponse stores general API error response
 type ErrorResponse struct {
-	Status        string            `json:"status"`
-	Message       string            `json:"message"`
-	CorrelationId string            `json:"correlationId"`
-	Category      string            `json:"category"`
-	Links         map[string]string `json:"links"`
+	Status        string                     `json:"status"`
+	Message       string                     `json:"message"`
+	CorrelationId string                     `json:"correlationId"`
+	Category      string                     `json:"category"`
+	SubCategory   json.RawMessage            `json:"subCategory"`
+	PolicyName    string                     `json:"policyName"`
+	Context       map[string]json.RawMessage `json:"context"`
+	Errors        []ErrorDetail              `json:"errors"`
+	Links         map[string]string          `json:"links"`
+}
+
+type ErrorDetail struct {
+	Message     string                     `json:"message"`
+	Code        string                     `json:"code"`
+	In          string                     `json:"in"`
+	SubCategory string                     `json:"subCategory"`
+	Context     map[string]json.RawMessage `json:"context"`
 }
 
 type PropertyError struct {
-	IsValid bool   `json:"isValid"`
-	Message string `json:"message"`
-	Error   string `json:"error"`
-	Name    string `json:"name"`
+	IsValid             bool     `json:"isValid"`
+	Message             string   `json:"message"`
+	Error               string   `json:"error"`
+	Name                string   `json:"name"`
+	ObjectWriteTraceIds []string `json:"objectWriteTraceIds,omitempty"` // the batch inputs holding the invalid value, if HubSpot identified them
 }
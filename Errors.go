@@ -0,0 +1,261 @@
+package hubspot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	errortools "github.com/leapforce-libraries/go_errortools"
+)
+
+// ApiError is an error response returned by HubSpot.
+// Use AsError to obtain it from the *errortools.Error a Service method returns.
+type ApiError struct {
+	StatusCode    int
+	Message       string
+	Category      string
+	SubCategory   string
+	CorrelationId string
+	Details       []ErrorDetail
+}
+
+func (err *ApiError) Error() string {
+	if err.Category == "" {
+		return fmt.Sprintf("hubspot: %v %s", err.StatusCode, err.Message)
+	}
+	return fmt.Sprintf("hubspot: %v %s: %s", err.StatusCode, err.Category, err.Message)
+}
+
+// RateLimitError is returned when the request was rejected by a rate limit (429)
+type RateLimitError struct {
+	*ApiError
+	PolicyName string        // e.g. DAILY or TEN_SECONDLY_ROLLING
+	RetryAfter time.Duration // zero if HubSpot did not tell
+}
+
+func (err *RateLimitError) Unwrap() error { return err.ApiError }
+
+// NotFoundError is returned when the requested object does not exist (404)
+type NotFoundError struct {
+	*ApiError
+}
+
+func (err *NotFoundError) Unwrap() error { return err.ApiError }
+
+// ConflictError is returned when the request conflicts with an existing object,
+// for instance a duplicate value for a unique property (409)
+type ConflictError struct {
+	*ApiError
+}
+
+func (err *ConflictError) Unwrap() error { return err.ApiError }
+
+// ValidationError is returned when the request contains invalid input (400)
+type ValidationError struct {
+	*ApiError
+	PropertyErrors []PropertyError
+}
+
+func (err *ValidationError) Unwrap() error { return err.ApiError }
+
+// MissingScopesError is returned when the token lacks scopes required for the request (403)
+type MissingScopesError struct {
+	*ApiError
+	MissingScopes []string
+}
+
+func (err *MissingScopesError) Unwrap() error { return err.ApiError }
+
+// AuthExpiredError is returned when the access token or api key is expired or invalid (401)
+type AuthExpiredError struct {
+	*ApiError
+}
+
+func (err *AuthExpiredError) Unwrap() error { return err.ApiError }
+
+// newApiError builds the typed error for a failed response from the decoded ErrorResponse
+func newApiError(response *http.Response, errorResponse *ErrorResponse) error {
+	apiError := &ApiError{
+		StatusCode: response.StatusCode,
+	}
+	if errorResponse != nil {
+		apiError.Message = errorResponse.Message
+		apiError.Category = errorResponse.Category
+		apiError.SubCategory = rawString(errorResponse.SubCategory)
+		apiError.CorrelationId = errorResponse.CorrelationId
+		apiError.Details = errorResponse.Errors
+	}
+
+	switch {
+	case response.StatusCode == http.StatusTooManyRequests || apiError.Category == "RATE_LIMITS":
+		err := &RateLimitError{ApiError: apiError}
+		if errorResponse != nil {
+			err.PolicyName = errorResponse.PolicyName
+		}
+		err.RetryAfter, _ = retryAfter(response.Header)
+		return err
+	case response.StatusCode == http.StatusUnauthorized || apiError.Category == "INVALID_AUTHENTICATION" || apiError.Category == "EXPIRED_AUTHENTICATION":
+		return &AuthExpiredError{ApiError: apiError}
+	case apiError.Category == "MISSING_SCOPES":
+		return &MissingScopesError{ApiError: apiError, MissingScopes: missingScopes(errorResponse)}
+	case response.StatusCode == http.StatusNotFound || apiError.Category == "OBJECT_NOT_FOUND":
+		return &NotFoundError{ApiError: apiError}
+	case response.StatusCode == http.StatusConflict || apiError.Category == "CONFLICT" || apiError.Category == "OBJECT_ALREADY_EXISTS":
+		return &ConflictError{ApiError: apiError}
+	case response.StatusCode == http.StatusBadRequest || apiError.Category == "VALIDATION_ERROR":
+		validationError := &ValidationError{ApiError: apiError}
+		if errorResponse != nil {
+			validationError.PropertyErrors = propertyErrors(errorResponse.Message, errorResponse.Errors, errorResponse.Context)
+		}
+		return validationError
+	}
+
+	return apiError
+}
+
+// propertyErrors returns the invalid property values of a validation error, from the code and context.propertyName of its details.
+// Without these it falls back to the json array of property errors HubSpot embeds in the message, whatever language the message is in.
+// The objectWriteTraceIds in the context of a detail, or else of the error, identify the inputs holding the invalid values.
+func propertyErrors(message string, details []ErrorDetail, context map[string]json.RawMessage) []PropertyError {
+	traceIds := rawStrings(context["objectWriteTraceId"])
+
+	var propertyErrors []PropertyError
+
+	for _, detail := range details {
+		if detail.Code == "" {
+			continue
+		}
+
+		names := rawStrings(detail.Context["propertyName"])
+		if name := rawString(detail.Context["propertyName"]); len(names) == 0 && name != "" {
+			names = []string{name}
+		}
+
+		detailTraceIds := rawStrings(detail.Context["objectWriteTraceId"])
+		if len(detailTraceIds) == 0 {
+			detailTraceIds = traceIds
+		}
+
+		for _, name := range names {
+			propertyErrors = append(propertyErrors, PropertyError{
+				Message:             detail.Message,
+				Error:               detail.Code,
+				Name:                name,
+				ObjectWriteTraceIds: detailTraceIds,
+			})
+		}
+	}
+	if len(propertyErrors) > 0 {
+		return propertyErrors
+	}
+
+	i := strings.Index(message, "[{")
+	if i < 0 {
+		return nil
+	}
+
+	err := json.Unmarshal([]byte(message[i:]), &propertyErrors)
+	if err != nil {
+		return nil
+	}
+
+	for i := range propertyErrors {
+		propertyErrors[i].ObjectWriteTraceIds = traceIds
+	}
+
+	return propertyErrors
+}
+
+func missingScopes(errorResponse *ErrorResponse) []string {
+	if errorResponse == nil {
+		return nil
+	}
+
+	var scopes []string
+	for _, detail := range errorResponse.Errors {
+		scopes = append(scopes, rawStrings(detail.Context["missingScopes"])...)
+		scopes = append(scopes, rawStrings(detail.Context["requiredGranularScopes"])...)
+	}
+	scopes = append(scopes, rawStrings(errorResponse.Context["missingScopes"])...)
+
+	return scopes
+}
+
+// rawStrings returns a json string array, or nil for any other json value
+func rawStrings(raw json.RawMessage) []string {
+	var s []string
+	if json.Unmarshal(raw, &s) != nil {
+		return nil
+	}
+	return s
+}
+
+// rawString returns a json string value unquoted, and any other json value as is
+func rawString(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+
+	return string(raw)
+}
+
+// causeKey is the context key of the cause of an *errortools.Error.
+// errortools.Error cannot wrap an error, but it does report the request that failed,
+// so the cause travels in the context of that request.
+type causeKey struct{}
+
+// withCause sets err as the cause of e, which reports request or, without one, an empty request
+func withCause(e *errortools.Error, request *http.Request, err error) *errortools.Error {
+	if e == nil || err == nil {
+		return e
+	}
+
+	if request == nil {
+		request = &http.Request{URL: &url.URL{}, Header: http.Header{}}
+	}
+	e.SetRequest(request.WithContext(context.WithValue(request.Context(), causeKey{}, err)))
+
+	return e
+}
+
+// newError returns an *errortools.Error for err reporting request, AsError returns err itself
+func newError(request *http.Request, err error) *errortools.Error {
+	return withCause(errortools.ErrorMessage(err), request, err)
+}
+
+// unsentRequest returns the request an error reports when it failed before it was sent
+func unsentRequest(method string, requestUrl string) *http.Request {
+	request, err := http.NewRequest(method, requestUrl, nil)
+	if err != nil {
+		return nil
+	}
+	return request
+}
+
+// AsError returns the typed error for e, to be inspected with errors.Is and errors.As.
+// For errors returned by HubSpot this is an *ApiError or one of the more specific
+// *RateLimitError, *NotFoundError, *ConflictError, *ValidationError, *MissingScopesError or *AuthExpiredError.
+// A cancelled or expired context returns the context error, and a RateLimiter out of daily requests ErrDailyLimitReached.
+func AsError(e *errortools.Error) error {
+	if e == nil {
+		return nil
+	}
+
+	if request := e.Request(); request != nil {
+		if err, ok := request.Context().Value(causeKey{}).(error); ok {
+			return err
+		}
+	}
+
+	return errors.New(e.Message())
+}
@@ -0,0 +1,101 @@
+package hubspot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	errortools "github.com/leapforce-libraries/go_errortools"
+)
+
+func TestAsErrorApiErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		check      func(err error) bool
+	}{
+		{"validation", http.StatusBadRequest, `{"status":"error","message":"Property values were not valid","category":"VALIDATION_ERROR"}`, func(err error) bool {
+			var validationError *ValidationError
+			return errors.As(err, &validationError) && validationError.StatusCode == http.StatusBadRequest
+		}},
+		{"rate limit", http.StatusTooManyRequests, `{"status":"error","message":"You have reached your daily limit.","category":"RATE_LIMITS","policyName":"DAILY"}`, func(err error) bool {
+			var rateLimitError *RateLimitError
+			return errors.As(err, &rateLimitError) && rateLimitError.PolicyName == "DAILY"
+		}},
+		{"conflict", http.StatusConflict, `{"status":"error","message":"Contact already exists","category":"CONFLICT"}`, func(err error) bool {
+			var conflictError *ConflictError
+			var apiError *ApiError
+			return errors.As(err, &conflictError) && errors.As(err, &apiError) && apiError.Message == "Contact already exists"
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-HubSpot-RateLimit-Daily-Remaining", "0")
+				writeJson(w, test.statusCode, test.body)
+			}, nil)
+
+			_, e := NewObjects[Object](service, ObjectTypeContacts).Get(&GetObjectConfig{ObjectId: "1"})
+			if e == nil {
+				t.Fatal("expected an error")
+			}
+			if err := AsError(e); !test.check(err) {
+				t.Errorf("unexpected error %T: %v", err, err)
+			}
+		})
+	}
+}
+
+func TestAsErrorDailyLimitReached(t *testing.T) {
+	rateLimiter, e := NewRateLimiter(&RateLimiterConfig{Burst: 10, DailyLimit: 1})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusOK, `{"id":"1"}`)
+	}, &ServiceConfig{RateLimiter: rateLimiter})
+
+	contacts := NewObjects[Object](service, ObjectTypeContacts)
+
+	if _, e := contacts.Get(&GetObjectConfig{ObjectId: "1"}); e != nil {
+		t.Fatal(e.Message())
+	}
+
+	_, e = contacts.Get(&GetObjectConfig{ObjectId: "1"})
+	if !errors.Is(AsError(e), ErrDailyLimitReached) {
+		t.Errorf("expected ErrDailyLimitReached, got %v", AsError(e))
+	}
+}
+
+func TestAsErrorContext(t *testing.T) {
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusOK, `{"id":"1"}`)
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, e := NewObjects[Object](service, ObjectTypeContacts).GetWithContext(ctx, &GetObjectConfig{ObjectId: "1"})
+	if !errors.Is(AsError(e), context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", AsError(e))
+	}
+	if e.Request() == nil || e.Request().URL.Path != "/crm/v3/objects/contacts/1" {
+		t.Errorf("expected the error to report the request that was not sent, got %v", e.Request())
+	}
+}
+
+func TestAsErrorOther(t *testing.T) {
+	if AsError(nil) != nil {
+		t.Error("expected nil")
+	}
+	if err := AsError(newError(nil, context.DeadlineExceeded)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if err := AsError(errortools.ErrorMessage("failed")); err == nil || err.Error() != "failed" {
+		t.Errorf("expected the message as error, got %v", err)
+	}
+}
@@ -0,0 +1,310 @@
+package hubspot
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	errortools "github.com/leapforce-libraries/go_errortools"
+	go_http "github.com/leapforce-libraries/go_http"
+)
+
+const (
+	objectIdProperty         string = "hs_object_id"
+	defaultExportSegments    int    = 16
+	defaultExportConcurrency int    = 4
+	defaultExportLimit       uint   = 100
+)
+
+// ExportObjectsConfig configures a parallel export of all objects of a type.
+// The range of SegmentBy is split in Segments, which are paged through concurrently by at most Concurrency searches at a time.
+//
+// A SegmentBy other than hs_object_id can change during the export, which has consequences:
+// objects without a value for it are not exported, and neither are objects whose value moves into a segment that has been exported already.
+// An object moving into a segment still to be exported is found twice, so the ids of all exported objects are kept in memory to skip it the second time.
+type ExportObjectsConfig struct {
+	ObjectType   string // ObjectType or fullyQualifiedName of a custom object
+	Properties   *[]string
+	FilterGroups *[]FilterGroup
+	SegmentBy    *string // numeric or datetime property to split on, defaults to hs_object_id, e.g. hs_lastmodifieddate (lastmodifieddate for contacts)
+	Segments     *int    // defaults to 16
+	Concurrency  *int    // defaults to 4, keep in mind the search endpoints allow 5 requests per second
+	Limit        *uint   // page size, defaults to 100, max 200
+}
+
+type exportSegment struct {
+	from int64 // inclusive
+	to   int64 // exclusive
+}
+
+type exportResult struct {
+	object Object
+	e      *errortools.Error
+}
+
+// ExportObjects returns all objects of config.ObjectType, in no particular order
+func (service *Service) ExportObjects(config *ExportObjectsConfig) (*[]Object, *errortools.Error) {
+	return service.ExportObjectsWithContext(context.Background(), config)
+}
+
+func (service *Service) ExportObjectsWithContext(ctx context.Context, config *ExportObjectsConfig) (*[]Object, *errortools.Error) {
+	return collect(service.iterExportObjects(ctx, config))
+}
+
+// IterExportObjects streams all objects of config.ObjectType as the segments are paged through, without duplicates.
+// See ExportObjectsConfig for the objects missed when SegmentBy is not hs_object_id.
+func (service *Service) IterExportObjects(ctx context.Context, config *ExportObjectsConfig) iter.Seq2[Object, error] {
+	return iterErrors(service.iterExportObjects(ctx, config))
+}
+
+func (service *Service) iterExportObjects(ctx context.Context, config *ExportObjectsConfig) iter.Seq2[Object, *errortools.Error] {
+	if config == nil {
+		return iterError[Object](errortools.ErrorMessage("Config is nil"))
+	}
+	if config.ObjectType == "" {
+		return iterError[Object](errortools.ErrorMessage("ObjectType must not be empty"))
+	}
+	// the filter groups of the segment searches hold up to three filters more than config.FilterGroups
+	if e := (&SearchObjectsConfig{FilterGroups: exportFilterGroups(config, exportSegment{}, objectIdProperty)}).validate(); e != nil {
+		return iterError[Object](e)
+	}
+
+	concurrency := defaultExportConcurrency
+	if config.Concurrency != nil && *config.Concurrency > 0 {
+		concurrency = *config.Concurrency
+	}
+
+	return func(yield func(Object, *errortools.Error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		segments, e := service.exportSegments(ctx, config)
+		if e != nil {
+			yield(Object{}, e)
+			return
+		}
+
+		segmentsChannel := make(chan exportSegment)
+		results := make(chan exportResult)
+
+		go func() {
+			defer close(segmentsChannel)
+			for _, segment := range segments {
+				select {
+				case segmentsChannel <- segment:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		var wg sync.WaitGroup
+		for range concurrency {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for segment := range segmentsChannel {
+					if !service.exportSegment(ctx, config, segment, results) {
+						return
+					}
+				}
+			}()
+		}
+
+		go func() {
+			wg.Wait()
+			close(results)
+		}()
+
+		// objects move between segments when SegmentBy changes during the export, ids never change
+		var seen map[string]struct{}
+		if exportSegmentBy(config) != objectIdProperty {
+			seen = make(map[string]struct{})
+		}
+
+		for result := range results {
+			if result.e != nil {
+				yield(Object{}, result.e)
+				return
+			}
+
+			if seen != nil {
+				if _, ok := seen[result.object.Id]; ok {
+					continue
+				}
+				seen[result.object.Id] = struct{}{}
+			}
+
+			if !yield(result.object, nil) {
+				return
+			}
+		}
+	}
+}
+
+// exportSegments splits the range of SegmentBy values of the objects to export
+func (service *Service) exportSegments(ctx context.Context, config *ExportObjectsConfig) ([]exportSegment, *errortools.Error) {
+	lowest, found, e := service.exportBound(ctx, config, SortAscending)
+	if e != nil {
+		return nil, e
+	}
+	if !found {
+		return nil, nil
+	}
+
+	highest, _, e := service.exportBound(ctx, config, SortDescending)
+	if e != nil {
+		return nil, e
+	}
+	highest++ // last segment must include the highest value
+
+	count := int64(defaultExportSegments)
+	if config.Segments != nil && *config.Segments > 0 {
+		count = int64(*config.Segments)
+	}
+	if count > highest-lowest {
+		count = highest - lowest
+	}
+
+	size := (highest - lowest + count - 1) / count
+
+	var segments []exportSegment
+	for from := lowest; from < highest; from += size {
+		segments = append(segments, exportSegment{from: from, to: from + size})
+	}
+	segments[len(segments)-1].to = highest
+
+	return segments, nil
+}
+
+// exportBound returns the lowest value of SegmentBy, or the highest if direction is descending
+func (service *Service) exportBound(ctx context.Context, config *ExportObjectsConfig, direction SortDirection) (int64, bool, *errortools.Error) {
+	segmentBy := exportSegmentBy(config)
+	limit := uint(1)
+
+	response := pageResponse[Object]{}
+
+	requestConfig := go_http.RequestConfig{
+		Method: http.MethodPost,
+		Url:    service.urlCrm(fmt.Sprintf("objects/%s/search", config.ObjectType)),
+		BodyModel: SearchObjectsConfig{
+			Limit:        &limit,
+			FilterGroups: config.FilterGroups,
+			Sorts:        &[]Sort{{PropertyName: segmentBy, Direction: direction}},
+			Properties:   &[]string{segmentBy},
+		},
+		ResponseModel: &response,
+	}
+
+	_, _, e := service.httpRequest(ctx, &requestConfig)
+	if e != nil {
+		return 0, false, e
+	}
+
+	if len(response.Results) == 0 {
+		return 0, false, nil
+	}
+
+	value := response.Results[0].Properties[segmentBy]
+	if segmentBy == objectIdProperty && value == "" {
+		value = response.Results[0].Id
+	}
+
+	bound, err := parseExportValue(value)
+	if err != nil {
+		return 0, false, errortools.ErrorMessage(fmt.Sprintf("Cannot export %s by %s: %s", config.ObjectType, segmentBy, err.Error()))
+	}
+
+	return bound, true, nil
+}
+
+// exportSegment pages through a segment by hs_object_id, so a segment is not limited to the 10,000 results a search returns.
+// It returns false if the export must stop.
+func (service *Service) exportSegment(ctx context.Context, config *ExportObjectsConfig, segment exportSegment, results chan<- exportResult) bool {
+	limit := defaultExportLimit
+	if config.Limit != nil {
+		limit = *config.Limit
+	}
+
+	lastId := ""
+
+	for {
+		response := pageResponse[Object]{}
+
+		requestConfig := go_http.RequestConfig{
+			Method: http.MethodPost,
+			Url:    service.urlCrm(fmt.Sprintf("objects/%s/search", config.ObjectType)),
+			BodyModel: SearchObjectsConfig{
+				Limit:        &limit,
+				FilterGroups: exportFilterGroups(config, segment, lastId),
+				Sorts:        &[]Sort{{PropertyName: objectIdProperty, Direction: SortAscending}},
+				Properties:   config.Properties,
+			},
+			ResponseModel: &response,
+		}
+
+		_, _, e := service.httpRequest(ctx, &requestConfig)
+		if e != nil {
+			select {
+			case results <- exportResult{e: e}:
+			case <-ctx.Done():
+			}
+			return false
+		}
+
+		for _, object := range response.Results {
+			select {
+			case results <- exportResult{object: object}:
+			case <-ctx.Done():
+				return false
+			}
+			lastId = object.Id
+		}
+
+		if response.Paging == nil || response.Paging.Next.After == "" || lastId == "" {
+			return true
+		}
+	}
+}
+
+// exportFilterGroups adds the bounds of segment and the last exported id to every filter group of config
+func exportFilterGroups(config *ExportObjectsConfig, segment exportSegment, lastId string) *[]FilterGroup {
+	segmentBy := exportSegmentBy(config)
+
+	filterGroups := copyFilterGroups(config.FilterGroups)
+
+	for i := range filterGroups {
+		filterGroups[i].AddPropertyFilter(OperatorGte, segmentBy, fmt.Sprintf("%v", segment.from), "")
+		filterGroups[i].AddPropertyFilter(OperatorLt, segmentBy, fmt.Sprintf("%v", segment.to), "")
+		if lastId != "" {
+			filterGroups[i].AddPropertyFilter(OperatorGt, objectIdProperty, lastId, "")
+		}
+	}
+
+	return &filterGroups
+}
+
+func exportSegmentBy(config *ExportObjectsConfig) string {
+	if config.SegmentBy != nil && *config.SegmentBy != "" {
+		return *config.SegmentBy
+	}
+	return objectIdProperty
+}
+
+// parseExportValue parses a numeric value, or a datetime value as milliseconds since epoch like search filters expect
+func parseExportValue(value string) (int64, error) {
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return 0, fmt.Errorf("value %q is neither a number nor a datetime", value)
+	}
+
+	return t.UnixMilli(), nil
+}
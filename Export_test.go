@@ -0,0 +1,144 @@
+package hubspot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// exportServer answers the searches of an export on objects with ids 1 to len(values) and property modified,
+// a value of 0 means the object has no value for modified
+type exportServer struct {
+	mutex  sync.Mutex
+	values []int
+	moves  map[int][2]int // when the object with the key id is exported, the object with the first id gets the second value
+}
+
+func (server *exportServer) value(id int, property string) (int, bool) {
+	if property == objectIdProperty {
+		return id, true
+	}
+	value := server.values[id-1]
+	return value, value != 0
+}
+
+func (server *exportServer) handle(w http.ResponseWriter, r *http.Request) {
+	var config SearchObjectsConfig
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		writeJson(w, http.StatusBadRequest, `{"status":"error","message":"invalid body"}`)
+		return
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	sort := (*config.Sorts)[0]
+
+	ids := []int{}
+	for id := 1; id <= len(server.values); id++ {
+		if _, ok := server.value(id, sort.PropertyName); !ok {
+			continue
+		}
+		matches := true
+		if config.FilterGroups != nil {
+			for _, filter := range *(*config.FilterGroups)[0].Filters {
+				value, ok := server.value(id, filter.PropertyName)
+				bound, _ := strconv.Atoi(filter.Value)
+				switch filter.Operator {
+				case OperatorGte:
+					matches = matches && ok && value >= bound
+				case OperatorLt:
+					matches = matches && ok && value < bound
+				case OperatorGt:
+					matches = matches && ok && value > bound
+				}
+			}
+		}
+		if matches {
+			ids = append(ids, id)
+		}
+	}
+
+	slices.SortStableFunc(ids, func(a int, b int) int {
+		valueA, _ := server.value(a, sort.PropertyName)
+		valueB, _ := server.value(b, sort.PropertyName)
+		if sort.Direction == SortDescending {
+			return valueB - valueA
+		}
+		return valueA - valueB
+	})
+
+	limit := int(*config.Limit)
+	paging := ""
+	if len(ids) > limit {
+		ids = ids[:limit]
+		paging = fmt.Sprintf(`,"paging":{"next":{"after":"%v"}}`, limit)
+	}
+
+	results := []string{}
+	for _, id := range ids {
+		results = append(results, fmt.Sprintf(`{"id":"%v","properties":{"modified":"%v"}}`, id, server.values[id-1]))
+		if config.FilterGroups != nil {
+			if move, ok := server.moves[id]; ok {
+				server.values[move[0]-1] = move[1]
+			}
+		}
+	}
+
+	writeJson(w, http.StatusOK, fmt.Sprintf(`{"results":[%s]%s}`, strings.Join(results, ","), paging))
+}
+
+func exportIds(t *testing.T, service *Service, config *ExportObjectsConfig) []int {
+	t.Helper()
+
+	ids := []int{}
+	for object, err := range service.IterExportObjects(context.Background(), config) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		id, _ := strconv.Atoi(object.Id)
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	return ids
+}
+
+func TestExportObjects(t *testing.T) {
+	server := exportServer{values: increasing(250)}
+	service := newTestService(t, server.handle, nil)
+
+	limit := uint(20)
+	ids := exportIds(t, service, &ExportObjectsConfig{ObjectType: "contacts", Segments: intPointer(4), Concurrency: intPointer(2), Limit: &limit})
+
+	if !slices.Equal(ids, increasing(250)) {
+		t.Errorf("got %v objects, want each of the 250 objects once: %v", len(ids), ids)
+	}
+}
+
+func TestExportObjectsSegmentByChanges(t *testing.T) {
+	// segments by modified are [1,26), [26,51), [51,76) and [76,101), object 101 has no value
+	values := append(increasing(100), 0)
+	server := exportServer{
+		values: values,
+		moves: map[int][2]int{
+			10: {10, 90}, // into a segment still to be exported, found again
+			11: {30, 5},  // into a segment exported already, missed
+		},
+	}
+	service := newTestService(t, server.handle, nil)
+
+	segmentBy := "modified"
+	ids := exportIds(t, service, &ExportObjectsConfig{ObjectType: "contacts", SegmentBy: &segmentBy, Segments: intPointer(4), Concurrency: intPointer(1)})
+
+	want := slices.DeleteFunc(increasing(100), func(id int) bool { return id == 30 })
+	if !slices.Equal(ids, want) {
+		t.Errorf("got %v, want all objects with a value once except the one moved into an exported segment", ids)
+	}
+}
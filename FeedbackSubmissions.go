@@ -1,9 +1,11 @@
 package hubspot
 
 import (
+	"context"
 	"fmt"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
+	"iter"
 	"net/http"
 	"net/url"
 	"time"
@@ -85,41 +87,48 @@ type GetFeedbackSubmissionsConfig struct {
 }
 
 func (service *Service) GetFeedbackSubmissions(config *GetFeedbackSubmissionsConfig) (*[]FeedbackSubmission, *errortools.Error) {
-	values := url.Values{}
-	if config != nil {
-		values.Set("properties", config.Properties)
-	}
-
-	var feedbackSubmissions []FeedbackSubmission
-
-	var after = ""
+	return service.GetFeedbackSubmissionsWithContext(context.Background(), config)
+}
 
-	for {
-		if after != "" {
-			values.Set("after", after)
-		}
+func (service *Service) GetFeedbackSubmissionsWithContext(ctx context.Context, config *GetFeedbackSubmissionsConfig) (*[]FeedbackSubmission, *errortools.Error) {
+	return collect(service.iterFeedbackSubmissions(ctx, config))
+}
 
-		var feedbackSubmissionsResponse FeedbackSubmissionsResponse
+// IterFeedbackSubmissions streams all feedback submissions page by page
+func (service *Service) IterFeedbackSubmissions(ctx context.Context, config *GetFeedbackSubmissionsConfig) iter.Seq2[FeedbackSubmission, error] {
+	return iterErrors(service.iterFeedbackSubmissions(ctx, config))
+}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodGet,
-			Url:           service.urlCrm(fmt.Sprintf("objects/feedback_submissions?%s", values.Encode())),
-			ResponseModel: &feedbackSubmissionsResponse,
-		}
+func (service *Service) iterFeedbackSubmissions(ctx context.Context, config *GetFeedbackSubmissionsConfig) iter.Seq2[FeedbackSubmission, *errortools.Error] {
+	return func(yield func(FeedbackSubmission, *errortools.Error) bool) {
+		service.iterFeedbackSubmissionsFrom(ctx, NewFeedbackSubmissionsCursor(config))(yield)
+	}
+}
 
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
+// NewFeedbackSubmissionsCursor returns a cursor at the start of GetFeedbackSubmissions
+func NewFeedbackSubmissionsCursor(config *GetFeedbackSubmissionsConfig) *Cursor {
+	values := url.Values{}
+	if config != nil {
+		values.Set("properties", config.Properties)
+	}
 
-		feedbackSubmissions = append(feedbackSubmissions, feedbackSubmissionsResponse.Results...)
+	return &Cursor{ObjectType: string(ObjectTypeFeedbackSubmissions), Query: values}
+}
 
-		if feedbackSubmissionsResponse.Paging.Next.After == "" {
-			break
-		}
+// IterFeedbackSubmissionsFrom resumes IterFeedbackSubmissions at cursor
+func (service *Service) IterFeedbackSubmissionsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[FeedbackSubmission, error] {
+	return iterErrors(service.iterFeedbackSubmissionsFrom(ctx, cursor))
+}
 
-		after = feedbackSubmissionsResponse.Paging.Next.After
+func (service *Service) iterFeedbackSubmissionsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[FeedbackSubmission, *errortools.Error] {
+	if e := cursor.check(string(ObjectTypeFeedbackSubmissions)); e != nil {
+		return iterError[FeedbackSubmission](e)
 	}
 
-	return &feedbackSubmissions, nil
+	return iterPages[FeedbackSubmission](ctx, service, cursor, false, func(after string) go_http.RequestConfig {
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlCrm(fmt.Sprintf("objects/%s?%s", cursor.ObjectType, encodeWithParam(cursor.Query, "after", after))),
+		}
+	})
 }
@@ -2,13 +2,13 @@ package hubspot
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
 	h_types "github.com/leapforce-libraries/go_hubspot/types"
 	"io"
-	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"net/url"
@@ -59,8 +59,12 @@ type UploadFileOptions struct {
 	DuplicateValidationScope    *string `json:"duplicateValidationScope,omitempty"`
 }
 
-// UploadFile uploads a file to Hubspot
+// UploadFile uploads a file to Hubspot, a rejected upload is retried by the RetryPolicy like any other POST
 func (service *Service) UploadFile(config *UploadFileConfig) (*File, *errortools.Error) {
+	return service.UploadFileWithContext(context.Background(), config)
+}
+
+func (service *Service) UploadFileWithContext(ctx context.Context, config *UploadFileConfig) (*File, *errortools.Error) {
 	endpoint := "files"
 
 	var file File
@@ -109,43 +113,58 @@ func (service *Service) UploadFile(config *UploadFileConfig) (*File, *errortools
 		return nil, errortools.ErrorMessage(err)
 	}
 
-	re, err := http.NewRequest(http.MethodPost, service.urlFiles(endpoint), body)
-	if err != nil {
-		return nil, errortools.ErrorMessage(err)
-	}
-	re.Header.Add("Content-Type", writer.FormDataContentType())
-	if service.authorizationMode == authorizationModeAccessToken {
-		re.Header.Add("Authorization", fmt.Sprintf("Bearer %s", service.accessToken))
-	} else {
+	if service.authorizationMode != authorizationModeAccessToken {
 		return nil, errortools.ErrorMessage("Method only allowed with access token")
 	}
 
-	client := &http.Client{}
-	res, err := client.Do(re)
-	if err != nil {
-		return nil, errortools.ErrorMessage(err)
-	}
-
-	br, err := ioutil.ReadAll(res.Body)
-	defer res.Body.Close()
-	if err != nil {
-		return nil, errortools.ErrorMessage(err)
-	}
+	_, _, e := service.retry(ctx, http.MethodPost, service.urlFiles(endpoint), func() (*http.Request, *http.Response, *errortools.Error) {
+		re, err := http.NewRequestWithContext(ctx, http.MethodPost, service.urlFiles(endpoint), bytes.NewReader(body.Bytes()))
+		if err != nil {
+			return nil, nil, errortools.ErrorMessage(err)
+		}
+		re.Header.Add("Content-Type", writer.FormDataContentType())
+		re.Header.Add("Authorization", fmt.Sprintf("Bearer %s", service.accessToken))
 
-	err = json.Unmarshal(br, &file)
-	if err != nil {
-		return nil, errortools.ErrorMessage(err)
-	}
+		service.requestCount.Add(1)
+		res, err := service.httpClient.Do(re)
+		if err != nil {
+			return re, nil, newError(re, err)
+		}
+		defer res.Body.Close()
 
-	if file.Id == "" {
-		err = json.Unmarshal(br, &service.errorResponse)
+		br, err := io.ReadAll(res.Body)
 		if err != nil {
-			return nil, errortools.ErrorMessage(err)
+			return re, res, newError(re, err)
+		}
+
+		success := res.StatusCode >= 200 && res.StatusCode < 300
+		if success {
+			err = json.Unmarshal(br, &file)
+			if err != nil {
+				return re, res, errortools.ErrorMessage(err)
+			}
+			if file.Id != "" {
+				return re, res, nil
+			}
 		}
 
-		if service.errorResponse.Status == "error" {
-			return nil, errortools.ErrorMessagef("error: %s", service.errorResponse.Message)
+		var errorResponse ErrorResponse
+		err = json.Unmarshal(br, &errorResponse)
+		if err != nil && !success {
+			return re, res, withCause(errortools.ErrorMessagef("upload failed with status %v", res.StatusCode), re, newApiError(res, nil))
 		}
+		if success && (err != nil || errorResponse.Status != "error") {
+			return re, res, nil
+		}
+
+		service.setLastErrorResponse(&errorResponse)
+
+		e := errortools.ErrorMessagef("error: %s", errorResponse.Message)
+		withCause(e, re, newApiError(res, &errorResponse))
+		return re, res, e
+	})
+	if e != nil {
+		return nil, e
 	}
 
 	return &file, nil
@@ -158,6 +177,10 @@ type GetFileConfig struct {
 
 // GetFile retrieves a file from Hubspot
 func (service *Service) GetFile(config *GetFileConfig) (*File, *errortools.Error) {
+	return service.GetFileWithContext(context.Background(), config)
+}
+
+func (service *Service) GetFileWithContext(ctx context.Context, config *GetFileConfig) (*File, *errortools.Error) {
 	if config == nil {
 		return nil, errortools.ErrorMessage("GetFileConfig must not be a nil pointer")
 	}
@@ -179,7 +202,7 @@ func (service *Service) GetFile(config *GetFileConfig) (*File, *errortools.Error
 		ResponseModel: &file,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
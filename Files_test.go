@@ -0,0 +1,60 @@
+package hubspot
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestUploadFileRetries(t *testing.T) {
+	var requests atomic.Int32
+
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("invalid multipart body: %v", err)
+		}
+		file, _, err := r.FormFile("file")
+		if err == nil {
+			b, _ := io.ReadAll(file)
+			if string(b) != "content" {
+				t.Errorf("unexpected file content %q", b)
+			}
+		}
+
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			writeJson(w, http.StatusTooManyRequests, `{"status":"error","message":"too many requests","category":"RATE_LIMITS"}`)
+			return
+		}
+		writeJson(w, http.StatusCreated, `{"id":"123","name":"file.txt"}`)
+	}, nil)
+
+	file, e := service.UploadFile(&UploadFileConfig{File: []byte("content"), FileName: "file.txt", Options: UploadFileOptions{Access: "PRIVATE"}})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if file.Id != "123" || requests.Load() != 2 {
+		t.Errorf("expected file 123 after 2 requests, got %q after %d", file.Id, requests.Load())
+	}
+}
+
+func TestUploadFileError(t *testing.T) {
+	var requests atomic.Int32
+
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		writeJson(w, http.StatusBadRequest, `{"status":"error","message":"invalid options","category":"VALIDATION_ERROR"}`)
+	}, nil)
+
+	_, e := service.UploadFile(&UploadFileConfig{File: []byte("content"), FileName: "file.txt"})
+
+	var validationError *ValidationError
+	if !errors.As(AsError(e), &validationError) || validationError.Message != "invalid options" {
+		t.Errorf("expected a validation error, got %v", AsError(e))
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected a rejected upload not to be retried, got %d requests", requests.Load())
+	}
+}
@@ -1,9 +1,11 @@
 package hubspot
 
 import (
+	"context"
 	"fmt"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
+	"iter"
 	"net/http"
 	"net/url"
 )
@@ -33,8 +35,27 @@ type GetFormSubmissionsConfig struct {
 
 // GetFormSubmissions returns all formSubmissions
 func (service *Service) GetFormSubmissions(formId string, config *GetFormSubmissionsConfig) (*[]FormSubmission, *errortools.Error) {
+	return service.GetFormSubmissionsWithContext(context.Background(), formId, config)
+}
+
+func (service *Service) GetFormSubmissionsWithContext(ctx context.Context, formId string, config *GetFormSubmissionsConfig) (*[]FormSubmission, *errortools.Error) {
+	return collect(service.iterFormSubmissions(ctx, formId, config, config != nil && config.After != nil))
+}
+
+// IterFormSubmissions streams all submissions of a form page by page, starting at config.After
+func (service *Service) IterFormSubmissions(ctx context.Context, formId string, config *GetFormSubmissionsConfig) iter.Seq2[FormSubmission, error] {
+	return iterErrors(service.iterFormSubmissions(ctx, formId, config, false))
+}
+
+func (service *Service) iterFormSubmissions(ctx context.Context, formId string, config *GetFormSubmissionsConfig, singlePage bool) iter.Seq2[FormSubmission, *errortools.Error] {
+	return func(yield func(FormSubmission, *errortools.Error) bool) {
+		service.iterFormSubmissionsFrom(ctx, NewFormSubmissionsCursor(formId, config), singlePage)(yield)
+	}
+}
+
+// NewFormSubmissionsCursor returns a cursor at the start of GetFormSubmissions, or at config.After
+func NewFormSubmissionsCursor(formId string, config *GetFormSubmissionsConfig) *Cursor {
 	values := url.Values{}
-	endpoint := "submissions/forms"
 
 	if config != nil {
 		if config.Limit != nil {
@@ -43,46 +64,27 @@ func (service *Service) GetFormSubmissions(formId string, config *GetFormSubmiss
 	}
 
 	after := ""
-	if config.After != nil {
+	if config != nil && config.After != nil {
 		after = *config.After
 	}
 
-	formSubmissions := []FormSubmission{}
-
-	for {
-		formSubmissionsResponse := FormSubmissionsResponse{}
-
-		if after != "" {
-			values.Set("after", after)
-		}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodGet,
-			Url:           service.urlFormIntegrations(fmt.Sprintf("%s/%s?%s", endpoint, formId, values.Encode())),
-			ResponseModel: &formSubmissionsResponse,
-		}
-
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
-
-		formSubmissions = append(formSubmissions, formSubmissionsResponse.Results...)
-
-		if config.After != nil { // explicit after parameter requested
-			break
-		}
-
-		if formSubmissionsResponse.Paging == nil {
-			break
-		}
+	return &Cursor{ObjectType: "form_submissions", ParentId: formId, Query: values, After: after}
+}
 
-		if formSubmissionsResponse.Paging.Next.After == "" {
-			break
-		}
+// IterFormSubmissionsFrom resumes IterFormSubmissions at cursor
+func (service *Service) IterFormSubmissionsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[FormSubmission, error] {
+	return iterErrors(service.iterFormSubmissionsFrom(ctx, cursor, false))
+}
 
-		after = formSubmissionsResponse.Paging.Next.After
+func (service *Service) iterFormSubmissionsFrom(ctx context.Context, cursor *Cursor, singlePage bool) iter.Seq2[FormSubmission, *errortools.Error] {
+	if e := cursor.check("form_submissions"); e != nil {
+		return iterError[FormSubmission](e)
 	}
 
-	return &formSubmissions, nil
+	return iterPages[FormSubmission](ctx, service, cursor, singlePage, func(after string) go_http.RequestConfig {
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlFormIntegrations(fmt.Sprintf("submissions/forms/%s?%s", cursor.ParentId, encodeWithParam(cursor.Query, "after", after))),
+		}
+	})
 }
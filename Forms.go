@@ -1,6 +1,7 @@
 package hubspot
 
 import (
+	"context"
 	"fmt"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
@@ -64,6 +65,10 @@ type Form struct {
 
 // GetForm returns a specific form
 func (service *Service) GetForm(formId string) (*Form, *errortools.Error) {
+	return service.GetFormWithContext(context.Background(), formId)
+}
+
+func (service *Service) GetFormWithContext(ctx context.Context, formId string) (*Form, *errortools.Error) {
 	endpoint := "forms"
 
 	form := Form{}
@@ -74,7 +79,7 @@ func (service *Service) GetForm(formId string) (*Form, *errortools.Error) {
 		ResponseModel: &form,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
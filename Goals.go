@@ -1,15 +1,18 @@
 package hubspot
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
 	h_types "github.com/leapforce-libraries/go_hubspot/types"
 	go_types "github.com/leapforce-libraries/go_types"
+	"iter"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 type GoalsResponse struct {
@@ -27,11 +30,12 @@ type goal struct {
 	Associations map[string]AssociationsSet `json:"associations"`
 }
 type Goal struct {
-	Id         string
-	Properties GoalProperties
-	CreatedAt  h_types.DateTimeString
-	UpdatedAt  h_types.DateTimeString
-	Archived   bool
+	Id              string
+	Properties      GoalProperties
+	TypedProperties GoalTypedProperties
+	CreatedAt       h_types.DateTimeString
+	UpdatedAt       h_types.DateTimeString
+	Archived        bool
 	//Associations     map[string]AssociationsSet
 }
 
@@ -46,6 +50,18 @@ type GoalProperties struct {
 	TargetAmount  *go_types.Float64String   `json:"hs_target_amount,omitempty"`
 }
 
+// GoalTypedProperties holds the properties of GoalProperties decoded by their hubspot tags
+type GoalTypedProperties struct {
+	CreatedByUserId *int64 `hubspot:"hs_created_by_user_id"`
+	//CreatedDate      *time.Time `hubspot:"hs_createdate"`
+	EndDateTime *time.Time `hubspot:"hs_end_datetime"`
+	GoalName    *string    `hubspot:"hs_goal_name"`
+	//LastModifiedDate *time.Time `hubspot:"hs_lastmodifieddate"`
+	StartDateTime *time.Time `hubspot:"hs_start_datetime"`
+	ObjectId      *int64     `hubspot:"hs_object_id"`
+	TargetAmount  *float64   `hubspot:"hs_target_amount"`
+}
+
 type GetGoalsConfig struct {
 	Limit      *uint
 	After      *string
@@ -56,30 +72,41 @@ type GetGoalsConfig struct {
 
 // GetGoals returns all goals
 func (service *Service) GetGoals(config *GetGoalsConfig) (*[]Goal, *errortools.Error) {
+	return service.GetGoalsWithContext(context.Background(), config)
+}
+
+func (service *Service) GetGoalsWithContext(ctx context.Context, config *GetGoalsConfig) (*[]Goal, *errortools.Error) {
+	return collect(service.iterGoals(ctx, config, config != nil && config.After != nil))
+}
+
+// IterGoals streams all goals page by page, starting at config.After
+func (service *Service) IterGoals(ctx context.Context, config *GetGoalsConfig) iter.Seq2[Goal, error] {
+	return iterErrors(service.iterGoals(ctx, config, false))
+}
+
+func (service *Service) iterGoals(ctx context.Context, config *GetGoalsConfig, singlePage bool) iter.Seq2[Goal, *errortools.Error] {
+	return func(yield func(Goal, *errortools.Error) bool) {
+		service.iterGoalsFrom(ctx, NewGoalsCursor(config), singlePage)(yield)
+	}
+}
+
+// NewGoalsCursor returns a cursor at the start of GetGoals, or at config.After
+func NewGoalsCursor(config *GetGoalsConfig) *Cursor {
 	values := url.Values{}
-	endpoint := "objects/goal_targets"
+
+	properties := PropertyNames(GoalTypedProperties{})
+	if config != nil && config.Properties != nil {
+		properties = *config.Properties
+	}
+	if len(properties) > 0 {
+		values.Set("properties", strings.Join(properties, ","))
+	}
 
 	if config != nil {
 		if config.Limit != nil {
 			values.Set("limit", fmt.Sprintf("%v", *config.Limit))
 		}
 
-		var _properties []string
-		if config.Properties != nil {
-			if len(*config.Properties) > 0 {
-				for _, p := range *config.Properties {
-					_properties = append(_properties, string(p))
-				}
-			}
-		}
-		if config.Properties != nil {
-			if len(*config.Properties) > 0 {
-				_properties = append(_properties, *config.Properties...)
-			}
-		}
-		if len(_properties) > 0 {
-			values.Set("properties", strings.Join(_properties, ","))
-		}
 		/*if config.Associations != nil {
 			if len(*config.Associations) > 0 {
 				_associations := []string{}
@@ -95,57 +122,51 @@ func (service *Service) GetGoals(config *GetGoalsConfig) (*[]Goal, *errortools.E
 	}
 
 	after := ""
-	if config.After != nil {
+	if config != nil && config.After != nil {
 		after = *config.After
 	}
 
-	var goals []Goal
-
-	for {
-		goalsResponse := GoalsResponse{}
+	return &Cursor{ObjectType: "goal_targets", Query: values, After: after}
+}
 
-		if after != "" {
-			values.Set("after", after)
-		}
+// IterGoalsFrom resumes IterGoals at cursor
+func (service *Service) IterGoalsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[Goal, error] {
+	return iterErrors(service.iterGoalsFrom(ctx, cursor, false))
+}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodGet,
-			Url:           service.urlCrm(fmt.Sprintf("%s?%s", endpoint, values.Encode())),
-			ResponseModel: &goalsResponse,
-		}
+func (service *Service) iterGoalsFrom(ctx context.Context, cursor *Cursor, singlePage bool) iter.Seq2[Goal, *errortools.Error] {
+	if e := cursor.check("goal_targets"); e != nil {
+		return iterError[Goal](e)
+	}
 
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
+	goals := iterPages[goal](ctx, service, cursor, singlePage, func(after string) go_http.RequestConfig {
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlCrm(fmt.Sprintf("objects/goal_targets?%s", encodeWithParam(cursor.Query, "after", after))),
 		}
+	})
 
-		for _, d := range goalsResponse.Results {
-			goal_, e := getGoal(&d, config.Properties)
+	return func(yield func(Goal, *errortools.Error) bool) {
+		for d, e := range goals {
 			if e != nil {
-				return nil, e
+				yield(Goal{}, e)
+				return
 			}
-			goals = append(goals, *goal_)
-		}
 
-		if config.After != nil { // explicit after parameter requested
-			break
-		}
-
-		if goalsResponse.Paging == nil {
-			break
-		}
+			goal_, e := getGoal(&d)
+			if e != nil {
+				yield(Goal{}, e)
+				return
+			}
 
-		if goalsResponse.Paging.Next.After == "" {
-			break
+			if !yield(*goal_, nil) {
+				return
+			}
 		}
-
-		after = goalsResponse.Paging.Next.After
 	}
-
-	return &goals, nil
 }
 
-func getGoal(goal *goal, properties *[]string) (*Goal, *errortools.Error) {
+func getGoal(goal *goal) (*Goal, *errortools.Error) {
 	goal_ := Goal{
 		Id:         goal.Id,
 		CreatedAt:  goal.CreatedAt,
@@ -155,20 +176,21 @@ func getGoal(goal *goal, properties *[]string) (*Goal, *errortools.Error) {
 	}
 
 	if goal.Properties != nil {
-		p := GoalProperties{}
-		err := json.Unmarshal(goal.Properties, &p)
+		err := json.Unmarshal(goal.Properties, &goal_.Properties)
 		if err != nil {
 			return nil, errortools.ErrorMessage(err)
 		}
-		goal_.Properties = p
-	}
 
-	if properties != nil {
-		p1 := make(map[string]string)
-		err := json.Unmarshal(goal.Properties, &p1)
+		properties := make(map[string]string)
+		err = json.Unmarshal(goal.Properties, &properties)
 		if err != nil {
 			return nil, errortools.ErrorMessage(err)
 		}
+
+		e := UnmarshalProperties(properties, &goal_.TypedProperties)
+		if e != nil {
+			return nil, e
+		}
 	}
 
 	return &goal_, nil
@@ -0,0 +1,149 @@
+package hubspot
+
+import (
+	"errors"
+	"maps"
+	"slices"
+
+	errortools "github.com/leapforce-libraries/go_errortools"
+)
+
+// maxInvalidPropertyRetries is the number of times a batch is sent again after recovering from invalid property values
+const maxInvalidPropertyRetries int = 10
+
+// InvalidPropertyAction is how a batch write recovers from an invalid property value
+type InvalidPropertyAction string
+
+// InvalidPropertyDrop leaves the property out, so an update keeps the value HubSpot has stored,
+// while InvalidPropertyClear and InvalidPropertyMove send it empty, which clears the stored value.
+const (
+	InvalidPropertyFail       InvalidPropertyAction = "fail"        // the batch fails
+	InvalidPropertyDrop       InvalidPropertyAction = "drop"        // the property is removed from the input
+	InvalidPropertyClear      InvalidPropertyAction = "clear"       // the property is set to an empty value
+	InvalidPropertyMove       InvalidPropertyAction = "move"        // the value is moved to the Fallback property and the property is set to an empty value
+	InvalidPropertyDropRecord InvalidPropertyAction = "drop_record" // the input is left out of the batch and reported as a BatchFailure
+)
+
+type InvalidPropertyRecovery struct {
+	Action   InvalidPropertyAction
+	Fallback string // property InvalidPropertyMove moves the value to
+}
+
+// InvalidPropertyPolicy returns the recovery for an invalid property value of objectType.
+// When a batch is rejected because of invalid property values, the policy is applied to the inputs holding them and the batch is sent again.
+type InvalidPropertyPolicy func(objectType ObjectType, propertyError PropertyError) InvalidPropertyRecovery
+
+// InvalidPropertyRules returns a policy that looks up the recovery by error code, e.g. INVALID_EMAIL,
+// or by error code and property name, e.g. INVALID_OPTION:lifecyclestage, which takes precedence.
+// Invalid values without a rule fail the batch.
+func InvalidPropertyRules(rules map[string]InvalidPropertyRecovery) InvalidPropertyPolicy {
+	return func(objectType ObjectType, propertyError PropertyError) InvalidPropertyRecovery {
+		if recovery, ok := rules[propertyError.Error+":"+propertyError.Name]; ok {
+			return recovery
+		}
+		if recovery, ok := rules[propertyError.Error]; ok {
+			return recovery
+		}
+		return InvalidPropertyRecovery{Action: InvalidPropertyFail}
+	}
+}
+
+// recoverInvalidProperties applies policy to the inputs of body holding the invalid property values e reports.
+// It returns the failures of the dropped inputs, and false if the batch cannot be recovered.
+func (service *Service) recoverInvalidProperties(policy InvalidPropertyPolicy, objectType ObjectType, body *BatchObjectsConfig, indexes batchInputIndexes, e *errortools.Error) ([]BatchFailure, bool) {
+	if policy == nil {
+		return nil, false
+	}
+
+	var validationError *ValidationError
+	if !errors.As(AsError(e), &validationError) || len(validationError.PropertyErrors) == 0 {
+		return nil, false
+	}
+
+	var failures []BatchFailure
+	dropped := make(map[int]bool)
+
+	for _, propertyError := range validationError.PropertyErrors {
+		recovery := policy(objectType, propertyError)
+
+		service.logger.Warn("invalid property value", "objectType", objectType, "property", propertyError.Name, "error", propertyError.Error, "message", propertyError.Message, "action", recovery.Action)
+
+		inputs := invalidInputs(body.Inputs, propertyError)
+		if len(inputs) == 0 {
+			return nil, false
+		}
+
+		switch recovery.Action {
+		case InvalidPropertyDrop, InvalidPropertyClear, InvalidPropertyMove:
+			if recovery.Action == InvalidPropertyMove && (recovery.Fallback == "" || recovery.Fallback == propertyError.Name) {
+				return nil, false
+			}
+			for _, i := range inputs {
+				// the properties map is shared with the caller's input
+				properties := maps.Clone(body.Inputs[i].Properties)
+				switch recovery.Action {
+				case InvalidPropertyDrop:
+					delete(properties, propertyError.Name)
+				case InvalidPropertyClear:
+					properties[propertyError.Name] = ""
+				case InvalidPropertyMove:
+					properties[recovery.Fallback] = properties[propertyError.Name]
+					properties[propertyError.Name] = ""
+				}
+				body.Inputs[i].Properties = properties
+			}
+		case InvalidPropertyDropRecord:
+			for _, i := range inputs {
+				if dropped[i] {
+					continue
+				}
+				dropped[i] = true
+
+				failure := indexes.inputFailure(body.Inputs[i], validationError.ApiError)
+				failure.Message = propertyError.Message
+				failure.PropertyErrors = []PropertyError{propertyError}
+				failures = append(failures, failure)
+			}
+		default:
+			return nil, false
+		}
+	}
+
+	if len(dropped) > 0 {
+		inputs := []BatchObjectInput{}
+		for i, input := range body.Inputs {
+			if !dropped[i] {
+				inputs = append(inputs, input)
+			}
+		}
+		body.Inputs = inputs
+	}
+
+	return failures, true
+}
+
+// invalidInputs returns the indexes of the inputs holding the value propertyError refers to, identified by their objectWriteTraceId.
+// If HubSpot did not identify the inputs, only a single input holding the property is unambiguous.
+func invalidInputs(inputs []BatchObjectInput, propertyError PropertyError) []int {
+	var indexes []int
+
+	if len(propertyError.ObjectWriteTraceIds) > 0 {
+		for i, input := range inputs {
+			if input.ObjectWriteTraceId != nil && slices.Contains(propertyError.ObjectWriteTraceIds, *input.ObjectWriteTraceId) {
+				indexes = append(indexes, i)
+			}
+		}
+		return indexes
+	}
+
+	for i, input := range inputs {
+		if _, ok := input.Properties[propertyError.Name]; ok {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) != 1 {
+		return nil
+	}
+
+	return indexes
+}
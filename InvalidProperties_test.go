@@ -0,0 +1,158 @@
+package hubspot
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// batchServer answers a batch write with respond, and records the inputs of every request
+type batchServer struct {
+	mutex    sync.Mutex
+	requests [][]BatchObjectInput
+	respond  func(request int, inputs []BatchObjectInput) (int, string)
+}
+
+func (server *batchServer) handle(w http.ResponseWriter, r *http.Request) {
+	var body BatchObjectsConfig
+	json.NewDecoder(r.Body).Decode(&body)
+
+	server.mutex.Lock()
+	server.requests = append(server.requests, body.Inputs)
+	request := len(server.requests)
+	server.mutex.Unlock()
+
+	statusCode, response := server.respond(request, body.Inputs)
+	writeJson(w, statusCode, response)
+}
+
+// batchCreated returns the response to a batch create of inputs, in reverse order like HubSpot may
+func batchCreated(inputs []BatchObjectInput) string {
+	var response BatchResponse[map[string]any]
+	for _, input := range slices.Backward(inputs) {
+		response.Results = append(response.Results, map[string]any{"id": "id" + *input.ObjectWriteTraceId, "objectWriteTraceId": *input.ObjectWriteTraceId, "properties": input.Properties})
+	}
+	b, _ := json.Marshal(response)
+	return string(b)
+}
+
+func emailInputs(emails ...string) []BatchObjectInput {
+	var inputs []BatchObjectInput
+	for _, email := range emails {
+		inputs = append(inputs, BatchObjectInput{Properties: map[string]string{"email": email, "firstname": "x"}})
+	}
+	return inputs
+}
+
+const invalidEmailDetail = `{"status":"error","message":"E-mailadres b@ is ongeldig","category":"VALIDATION_ERROR","errors":[{"message":"E-mailadres b@ is ongeldig","code":"INVALID_EMAIL","context":{"propertyName":["email"],"objectWriteTraceId":["1"]}}]}`
+
+func TestInvalidPropertyActions(t *testing.T) {
+	tests := []struct {
+		action   InvalidPropertyAction
+		expected map[string]string
+	}{
+		{InvalidPropertyDrop, map[string]string{"firstname": "x"}},
+		{InvalidPropertyClear, map[string]string{"email": "", "firstname": "x"}},
+		{InvalidPropertyMove, map[string]string{"email": "", "invalid_email": "b@", "firstname": "x"}},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.action), func(t *testing.T) {
+			server := batchServer{respond: func(request int, inputs []BatchObjectInput) (int, string) {
+				if request == 1 {
+					return http.StatusBadRequest, invalidEmailDetail
+				}
+				return http.StatusCreated, batchCreated(inputs)
+			}}
+			service := newTestService(t, server.handle, nil)
+
+			// the message of the error quotes b@, which is part of the other emails too
+			config := BatchObjectsConfig{
+				Inputs: emailInputs("ab@c.d", "b@", "b@c.d"),
+				InvalidPropertyPolicy: InvalidPropertyRules(map[string]InvalidPropertyRecovery{
+					"INVALID_EMAIL": {Action: test.action, Fallback: "invalid_email"},
+				}),
+			}
+
+			result, e := NewObjects[Object](service, ObjectTypeContacts).BatchCreate(&config)
+			if e != nil {
+				t.Fatal(e.Message())
+			}
+			if len(result.Successes) != 3 || len(server.requests) != 2 {
+				t.Fatalf("expected 3 objects in 2 requests, got %d in %d", len(result.Successes), len(server.requests))
+			}
+
+			retried := server.requests[1]
+			if !maps.Equal(retried[1].Properties, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, retried[1].Properties)
+			}
+			if retried[0].Properties["email"] != "ab@c.d" || retried[2].Properties["email"] != "b@c.d" {
+				t.Errorf("expected the other inputs to be untouched, got %+v", retried)
+			}
+			if config.Inputs[1].Properties["email"] != "b@" {
+				t.Error("expected the input of the caller to be untouched")
+			}
+		})
+	}
+}
+
+func TestInvalidPropertyDropRecord(t *testing.T) {
+	server := batchServer{respond: func(request int, inputs []BatchObjectInput) (int, string) {
+		if request == 1 {
+			return http.StatusBadRequest, invalidEmailDetail
+		}
+		return http.StatusCreated, batchCreated(inputs)
+	}}
+	service := newTestService(t, server.handle, nil)
+
+	result, e := NewObjects[Object](service, ObjectTypeContacts).BatchCreate(&BatchObjectsConfig{
+		Inputs:                emailInputs("a@c.d", "b@", "c@d.e"),
+		InvalidPropertyPolicy: InvalidPropertyRules(map[string]InvalidPropertyRecovery{"INVALID_EMAIL:email": {Action: InvalidPropertyDropRecord}}),
+	})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if len(result.Successes) != 2 || len(result.Failures) != 1 {
+		t.Fatalf("expected 2 successes and 1 failure, got %+v", result)
+	}
+	if failure := result.Failures[0]; failure.InputIndex != 1 || failure.PropertyErrors[0].Error != "INVALID_EMAIL" {
+		t.Errorf("unexpected failure %+v", failure)
+	}
+	if !slices.Equal(result.SuccessIndexes, []int{2, 0}) || result.Successes[0].Properties["email"] != "c@d.e" {
+		t.Errorf("unexpected successes %+v at %v", result.Successes, result.SuccessIndexes)
+	}
+}
+
+func TestInvalidPropertyUnidentified(t *testing.T) {
+	// a message with embedded property errors but without the inputs holding them
+	const invalidEmailMessage = `{"status":"error","message":"Eigenschapswaarden waren niet geldig: [{\"isValid\":false,\"message\":\"E-mailadres b@ is ongeldig\",\"error\":\"INVALID_EMAIL\",\"name\":\"email\"}]","category":"VALIDATION_ERROR"}`
+
+	tests := []struct {
+		name     string
+		inputs   []BatchObjectInput
+		requests int
+	}{
+		{"one input with the property", append(emailInputs("b@"), BatchObjectInput{Properties: map[string]string{"firstname": "y"}}), 2},
+		{"several inputs with the property", emailInputs("ab@c.d", "b@"), 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := batchServer{respond: func(request int, inputs []BatchObjectInput) (int, string) {
+				if request == 1 {
+					return http.StatusBadRequest, invalidEmailMessage
+				}
+				return http.StatusCreated, batchCreated(inputs)
+			}}
+			service := newTestService(t, server.handle, nil)
+
+			_, e := service.BatchCreateContacts(&BatchObjectsConfig{ObjectType: string(ObjectTypeContacts), Inputs: test.inputs}, "")
+			if (e == nil) != (test.requests == 2) || len(server.requests) != test.requests {
+				t.Errorf("expected %d requests, got %d (error %v)", test.requests, len(server.requests), e)
+			}
+		})
+	}
+}
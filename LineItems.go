@@ -1,11 +1,13 @@
 package hubspot
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
 	h_types "github.com/leapforce-libraries/go_hubspot/types"
+	"iter"
 	"net/http"
 	"net/url"
 	"strings"
@@ -39,8 +41,27 @@ type GetLineItemsConfig struct {
 
 // GetLineItems returns all lineItems
 func (service *Service) GetLineItems(config *GetLineItemsConfig) (*[]LineItem, *errortools.Error) {
+	return service.GetLineItemsWithContext(context.Background(), config)
+}
+
+func (service *Service) GetLineItemsWithContext(ctx context.Context, config *GetLineItemsConfig) (*[]LineItem, *errortools.Error) {
+	return collect(service.iterLineItems(ctx, config, config != nil && config.After != nil))
+}
+
+// IterLineItems streams all line items page by page, starting at config.After
+func (service *Service) IterLineItems(ctx context.Context, config *GetLineItemsConfig) iter.Seq2[LineItem, error] {
+	return iterErrors(service.iterLineItems(ctx, config, false))
+}
+
+func (service *Service) iterLineItems(ctx context.Context, config *GetLineItemsConfig, singlePage bool) iter.Seq2[LineItem, *errortools.Error] {
+	return func(yield func(LineItem, *errortools.Error) bool) {
+		service.iterLineItemsFrom(ctx, NewLineItemsCursor(config), singlePage)(yield)
+	}
+}
+
+// NewLineItemsCursor returns a cursor at the start of GetLineItems, or at config.After
+func NewLineItemsCursor(config *GetLineItemsConfig) *Cursor {
 	values := url.Values{}
-	endpoint := "objects/line_items"
 
 	if config != nil {
 		if config.Limit != nil {
@@ -75,51 +96,36 @@ func (service *Service) GetLineItems(config *GetLineItemsConfig) (*[]LineItem, *
 	}
 
 	after := ""
-	if config.After != nil {
+	if config != nil && config.After != nil {
 		after = *config.After
 	}
 
-	lineItems := []LineItem{}
-
-	for {
-		lineItemsResponse := LineItemsResponse{}
-
-		if after != "" {
-			values.Set("after", after)
-		}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodGet,
-			Url:           service.urlCrm(fmt.Sprintf("%s?%s", endpoint, values.Encode())),
-			ResponseModel: &lineItemsResponse,
-		}
-
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
-
-		lineItems = append(lineItems, lineItemsResponse.Results...)
-
-		if config.After != nil { // explicit after parameter requested
-			break
-		}
-
-		if lineItemsResponse.Paging == nil {
-			break
-		}
+	return &Cursor{ObjectType: string(ObjectTypeLineItems), Query: values, After: after}
+}
 
-		if lineItemsResponse.Paging.Next.After == "" {
-			break
-		}
+// IterLineItemsFrom resumes IterLineItems at cursor
+func (service *Service) IterLineItemsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[LineItem, error] {
+	return iterErrors(service.iterLineItemsFrom(ctx, cursor, false))
+}
 
-		after = lineItemsResponse.Paging.Next.After
+func (service *Service) iterLineItemsFrom(ctx context.Context, cursor *Cursor, singlePage bool) iter.Seq2[LineItem, *errortools.Error] {
+	if e := cursor.check(string(ObjectTypeLineItems)); e != nil {
+		return iterError[LineItem](e)
 	}
 
-	return &lineItems, nil
+	return iterPages[LineItem](ctx, service, cursor, singlePage, func(after string) go_http.RequestConfig {
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlCrm(fmt.Sprintf("objects/%s?%s", cursor.ObjectType, encodeWithParam(cursor.Query, "after", after))),
+		}
+	})
 }
 
 func (service *Service) CreateLineItem(config *CreateObjectConfig) (*LineItem, *errortools.Error) {
+	return service.CreateLineItemWithContext(context.Background(), config)
+}
+
+func (service *Service) CreateLineItemWithContext(ctx context.Context, config *CreateObjectConfig) (*LineItem, *errortools.Error) {
 	endpoint := "objects/line_items"
 	lineItem := LineItem{}
 
@@ -130,7 +136,7 @@ func (service *Service) CreateLineItem(config *CreateObjectConfig) (*LineItem, *
 		ResponseModel: &lineItem,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -138,6 +144,9 @@ func (service *Service) CreateLineItem(config *CreateObjectConfig) (*LineItem, *
 	return &lineItem, nil
 }
 
+// BatchLineItemsResponse is the response of a batch request of lineItems
+//
+// Deprecated: the batch functions return a BatchResult
 type BatchLineItemsResponse struct {
 	CompletedAt *time.Time        `json:"completedAt"`
 	NumErrors   int               `json:"numErrors"`
@@ -166,71 +175,59 @@ type BatchLineItemsResponse struct {
 	Status string `json:"status"`
 }
 
+// BatchCreateLineItems returns the created line items, the inputs that failed are only logged
+//
+// Deprecated: use BatchCreateLineItemsWithResult, which also returns the inputs that failed
 func (service *Service) BatchCreateLineItems(config *BatchObjectsConfig) (*[]LineItem, *errortools.Error) {
-	var lineItems []LineItem
-
-	for _, batch := range service.batches(len(config.Inputs)) {
-		var r BatchLineItemsResponse
+	result, e := service.BatchCreateLineItemsWithResultWithContext(context.Background(), config)
+	if e != nil {
+		return nil, e
+	}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(fmt.Sprintf("objects/%s/batch/create", config.ObjectType)),
-			BodyModel:     BatchObjectsConfig{Inputs: config.Inputs[batch.startIndex:batch.endIndex]},
-			ResponseModel: &r,
-		}
+	return &result.Successes, nil
+}
 
-		_, response, e := service.httpRequest(&requestConfig)
-		if response != nil {
-			if response.StatusCode == http.StatusMultiStatus {
-				fmt.Println(r.Errors)
-				goto ok
-			}
-		}
-		if e != nil {
-			return nil, e
-		}
-	ok:
-		lineItems = append(lineItems, r.Results...)
+func (service *Service) BatchCreateLineItemsWithResult(config *BatchObjectsConfig) (*BatchResult[LineItem], *errortools.Error) {
+	return service.BatchCreateLineItemsWithResultWithContext(context.Background(), config)
+}
 
-		fmt.Println("batch", batch.startIndex)
+func (service *Service) BatchCreateLineItemsWithResultWithContext(ctx context.Context, config *BatchObjectsConfig) (*BatchResult[LineItem], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
 	}
 
-	return &lineItems, nil
+	return batchObjects[LineItem](ctx, service, ObjectType(config.ObjectType), "create", config)
 }
 
+// BatchUpdateLineItems returns the updated line items, the inputs that failed are only logged
+//
+// Deprecated: use BatchUpdateLineItemsWithResult, which also returns the inputs that failed
 func (service *Service) BatchUpdateLineItems(config *BatchObjectsConfig) (*[]LineItem, *errortools.Error) {
-	var lineItems []LineItem
-
-	for _, batch := range service.batches(len(config.Inputs)) {
-		var r BatchLineItemsResponse
+	result, e := service.BatchUpdateLineItemsWithResultWithContext(context.Background(), config)
+	if e != nil {
+		return nil, e
+	}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(fmt.Sprintf("objects/%s/batch/update", config.ObjectType)),
-			BodyModel:     BatchObjectsConfig{Inputs: config.Inputs[batch.startIndex:batch.endIndex]},
-			ResponseModel: &r,
-		}
+	return &result.Successes, nil
+}
 
-		_, response, e := service.httpRequest(&requestConfig)
-		if response != nil {
-			if response.StatusCode == http.StatusMultiStatus {
-				fmt.Println(r.Errors)
-				goto ok
-			}
-		}
-		if e != nil {
-			return nil, e
-		}
-	ok:
-		lineItems = append(lineItems, r.Results...)
+func (service *Service) BatchUpdateLineItemsWithResult(config *BatchObjectsConfig) (*BatchResult[LineItem], *errortools.Error) {
+	return service.BatchUpdateLineItemsWithResultWithContext(context.Background(), config)
+}
 
-		fmt.Println("batch", batch.startIndex)
+func (service *Service) BatchUpdateLineItemsWithResultWithContext(ctx context.Context, config *BatchObjectsConfig) (*BatchResult[LineItem], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
 	}
 
-	return &lineItems, nil
+	return batchObjects[LineItem](ctx, service, ObjectType(config.ObjectType), "update", config)
 }
 
 func (service *Service) UpdateLineItem(config *UpdateObjectConfig) (*LineItem, *errortools.Error) {
+	return service.UpdateLineItemWithContext(context.Background(), config)
+}
+
+func (service *Service) UpdateLineItemWithContext(ctx context.Context, config *UpdateObjectConfig) (*LineItem, *errortools.Error) {
 	endpoint := "objects/line_items"
 	lineItem := LineItem{}
 
@@ -241,7 +238,7 @@ func (service *Service) UpdateLineItem(config *UpdateObjectConfig) (*LineItem, *
 		ResponseModel: &lineItem,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -250,16 +247,20 @@ func (service *Service) UpdateLineItem(config *UpdateObjectConfig) (*LineItem, *
 }
 
 func (service *Service) BatchArchiveLineItems(lineItemIds []string) *errortools.Error {
+	return service.BatchArchiveLineItemsWithContext(context.Background(), lineItemIds)
+}
+
+func (service *Service) BatchArchiveLineItemsWithContext(ctx context.Context, lineItemIds []string) *errortools.Error {
 	var maxItemsPerBatch = 100
 	var index = 0
 	for len(lineItemIds) > index {
 		if len(lineItemIds) > index+maxItemsPerBatch {
-			e := service.batchArchiveLineItems(lineItemIds[index : index+maxItemsPerBatch])
+			e := service.batchArchiveLineItems(ctx, lineItemIds[index:index+maxItemsPerBatch])
 			if e != nil {
 				return e
 			}
 		} else {
-			e := service.batchArchiveLineItems(lineItemIds[index:])
+			e := service.batchArchiveLineItems(ctx, lineItemIds[index:])
 			if e != nil {
 				return e
 			}
@@ -271,7 +272,7 @@ func (service *Service) BatchArchiveLineItems(lineItemIds []string) *errortools.
 	return nil
 }
 
-func (service *Service) batchArchiveLineItems(lineItemIds []string) *errortools.Error {
+func (service *Service) batchArchiveLineItems(ctx context.Context, lineItemIds []string) *errortools.Error {
 	var body struct {
 		Inputs []struct {
 			Id string `json:"id"`
@@ -290,6 +291,6 @@ func (service *Service) batchArchiveLineItems(lineItemIds []string) *errortools.
 		BodyModel: body,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	return e
 }
@@ -1,9 +1,11 @@
 package hubspot
 
 import (
+	"context"
 	"fmt"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
+	"iter"
 	"net/http"
 	"net/url"
 	"time"
@@ -27,55 +29,63 @@ type GetListMembershipsConfig struct {
 
 // GetListMemberships returns all listMemberships
 func (service *Service) GetListMemberships(config *GetListMembershipsConfig) (*[]ListMembership, *errortools.Error) {
+	return service.GetListMembershipsWithContext(context.Background(), config)
+}
+
+func (service *Service) GetListMembershipsWithContext(ctx context.Context, config *GetListMembershipsConfig) (*[]ListMembership, *errortools.Error) {
+	return collect(service.iterListMemberships(ctx, config, config != nil && config.After != nil))
+}
+
+// IterListMemberships streams all memberships of a list page by page, starting at config.After
+func (service *Service) IterListMemberships(ctx context.Context, config *GetListMembershipsConfig) iter.Seq2[ListMembership, error] {
+	return iterErrors(service.iterListMemberships(ctx, config, false))
+}
+
+func (service *Service) iterListMemberships(ctx context.Context, config *GetListMembershipsConfig, singlePage bool) iter.Seq2[ListMembership, *errortools.Error] {
+	if config == nil {
+		return iterError[ListMembership](errortools.ErrorMessage("Config is nil"))
+	}
+
+	return func(yield func(ListMembership, *errortools.Error) bool) {
+		service.iterListMembershipsFrom(ctx, NewListMembershipsCursor(config), singlePage)(yield)
+	}
+}
+
+// NewListMembershipsCursor returns a cursor at the start of GetListMemberships, or at config.After
+func NewListMembershipsCursor(config *GetListMembershipsConfig) *Cursor {
 	values := url.Values{}
+	listId := ""
 
 	if config != nil {
+		listId = fmt.Sprintf("%v", config.ListId)
+
 		if config.Limit != nil {
 			values.Set("limit", fmt.Sprintf("%v", *config.Limit))
 		}
 	}
 
 	after := ""
-	if config.After != nil {
+	if config != nil && config.After != nil {
 		after = *config.After
 	}
 
-	var listMemberships []ListMembership
-
-	for {
-		listMembershipsResponse := ListMembershipsResponse{}
-
-		if after != "" {
-			values.Set("after", after)
-		}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodGet,
-			Url:           service.urlCrm(fmt.Sprintf("lists/%v/memberships?%s", config.ListId, values.Encode())),
-			ResponseModel: &listMembershipsResponse,
-		}
-
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
-
-		listMemberships = append(listMemberships, listMembershipsResponse.Results...)
-
-		if config.After != nil { // explicit after parameter requested
-			break
-		}
-
-		if listMembershipsResponse.Paging == nil {
-			break
-		}
+	return &Cursor{ObjectType: "list_memberships", ParentId: listId, Query: values, After: after}
+}
 
-		if listMembershipsResponse.Paging.Next.After == "" {
-			break
-		}
+// IterListMembershipsFrom resumes IterListMemberships at cursor
+func (service *Service) IterListMembershipsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[ListMembership, error] {
+	return iterErrors(service.iterListMembershipsFrom(ctx, cursor, false))
+}
 
-		after = listMembershipsResponse.Paging.Next.After
+func (service *Service) iterListMembershipsFrom(ctx context.Context, cursor *Cursor, singlePage bool) iter.Seq2[ListMembership, *errortools.Error] {
+	if e := cursor.check("list_memberships"); e != nil {
+		return iterError[ListMembership](e)
 	}
 
-	return &listMemberships, nil
+	return iterPages[ListMembership](ctx, service, cursor, singlePage, func(after string) go_http.RequestConfig {
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlCrm(fmt.Sprintf("lists/%s/memberships?%s", cursor.ParentId, encodeWithParam(cursor.Query, "after", after))),
+		}
+	})
 }
@@ -1,8 +1,10 @@
 package hubspot
 
 import (
+	"context"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
+	"iter"
 	"net/http"
 	"time"
 )
@@ -42,6 +44,19 @@ type SearchListsConfig struct {
 
 // SearchLists returns all lists
 func (service *Service) SearchLists(config *SearchListsConfig) (*[]List, *errortools.Error) {
+	return service.SearchListsWithContext(context.Background(), config)
+}
+
+func (service *Service) SearchListsWithContext(ctx context.Context, config *SearchListsConfig) (*[]List, *errortools.Error) {
+	return collect(service.iterSearchLists(ctx, config))
+}
+
+// IterSearchLists streams all lists page by page, starting at config.Offset
+func (service *Service) IterSearchLists(ctx context.Context, config *SearchListsConfig) iter.Seq2[List, error] {
+	return iterErrors(service.iterSearchLists(ctx, config))
+}
+
+func (service *Service) iterSearchLists(ctx context.Context, config *SearchListsConfig) iter.Seq2[List, *errortools.Error] {
 	endpoint := "lists/search"
 
 	var config_ SearchListsConfig
@@ -49,31 +64,26 @@ func (service *Service) SearchLists(config *SearchListsConfig) (*[]List, *errort
 		config_ = *config
 	}
 
-	var lists []List
-
-	for {
-		listsResponse := SearchListsResponse{}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(endpoint),
-			BodyModel:     config_,
-			ResponseModel: &listsResponse,
-		}
-
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
+	return func(yield func(List, *errortools.Error) bool) {
+		cursor := Cursor{ObjectType: "lists"}
+		if config_.Offset != nil {
+			cursor.Offset = int64(*config_.Offset)
 		}
 
-		lists = append(lists, listsResponse.Lists...)
+		iterOffsetPages(ctx, service, &cursor, false, func(offset int64) go_http.RequestConfig {
+			body := config_
+			if offset > 0 {
+				_offset := uint32(offset)
+				body.Offset = &_offset
+			}
 
-		if !listsResponse.HasMore {
-			break
-		}
-
-		config_.Offset = &listsResponse.Offset
+			return go_http.RequestConfig{
+				Method:    http.MethodPost,
+				Url:       service.urlCrm(endpoint),
+				BodyModel: body,
+			}
+		}, func(response *SearchListsResponse) ([]List, bool, int64) {
+			return response.Lists, response.HasMore, int64(response.Offset)
+		})(yield)
 	}
-
-	return &lists, nil
 }
@@ -1,8 +1,13 @@
 package hubspot
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"iter"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	errortools "github.com/leapforce-libraries/go_errortools"
@@ -24,13 +29,16 @@ type BatchGetObjectsInput struct {
 }
 
 type BatchGetObjectsConfig struct {
-	ObjectType            string
+	ObjectType            string                 `json:"-"`
 	PropertiesWithHistory []string               `json:"propertiesWithHistory,omitempty"`
 	IdProperty            string                 `json:"idProperty,omitempty"`
 	Inputs                []BatchGetObjectsInput `json:"inputs"`
 	Properties            []string               `json:"properties,omitempty"`
 }
 
+// BatchGetObjectsResponse is the response of a batch read of objects
+//
+// Deprecated: the batch functions of objects return a BatchResult
 type BatchGetObjectsResponse struct {
 	CompletedAt time.Time         `json:"completedAt"`
 	RequestedAt time.Time         `json:"requestedAt"`
@@ -40,7 +48,23 @@ type BatchGetObjectsResponse struct {
 	Status      string            `json:"status"`
 }
 
+// BatchGetObjects returns the objects read, the inputs that failed are only logged
+//
+// Deprecated: use BatchGetObjectsWithResult, which also returns the inputs that failed
 func (service *Service) BatchGetObjects(config *BatchGetObjectsConfig) (*[]Object, *errortools.Error) {
+	result, e := service.BatchGetObjectsWithResultWithContext(context.Background(), config)
+	if e != nil || result == nil {
+		return nil, e
+	}
+
+	return &result.Successes, nil
+}
+
+func (service *Service) BatchGetObjectsWithResult(config *BatchGetObjectsConfig) (*BatchResult[Object], *errortools.Error) {
+	return service.BatchGetObjectsWithResultWithContext(context.Background(), config)
+}
+
+func (service *Service) BatchGetObjectsWithResultWithContext(ctx context.Context, config *BatchGetObjectsConfig) (*BatchResult[Object], *errortools.Error) {
 	if config == nil {
 		return nil, nil
 	}
@@ -48,32 +72,443 @@ func (service *Service) BatchGetObjects(config *BatchGetObjectsConfig) (*[]Objec
 		return nil, nil
 	}
 
-	endpoint := fmt.Sprintf("objects/%s/batch/read", config.ObjectType)
+	return batchGetObjects[Object](ctx, service, ObjectType(config.ObjectType), config)
+}
+
+func batchGetObjects[R any](ctx context.Context, service *Service, objectType ObjectType, config *BatchGetObjectsConfig) (*BatchResult[R], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
+	}
 
-	var objects []Object
+	batches := service.batches(len(config.Inputs))
+	results := make([]BatchResult[R], len(batches))
 
-	for _, batch := range service.batches(len(config.Inputs)) {
-		var batchGetObjectsResponse BatchGetObjectsResponse
+	e := service.forEachBatch(ctx, batches, func(ctx context.Context, i int, batch batch) *errortools.Error {
+		body := *config
+		body.Inputs = config.Inputs[batch.startIndex:batch.endIndex]
 
-		requestConfig := go_http.RequestConfig{
-			Method: http.MethodPost,
-			Url:    service.urlCrm(endpoint),
-			BodyModel: BatchGetObjectsConfig{
-				PropertiesWithHistory: config.PropertiesWithHistory,
-				IdProperty:            config.IdProperty,
-				Inputs:                config.Inputs[batch.startIndex:batch.endIndex],
-				Properties:            config.Properties,
-			},
-			ResponseModel: &batchGetObjectsResponse,
+		return sendBatch(ctx, service, objectType, "read", batch, body, config.batchInputIndexes(batch), &results[i])
+	})
+	if e != nil {
+		return nil, e
+	}
+
+	return mergeBatchResults(results), nil
+}
+
+// Objects gives access to the objects of one ObjectType, decoded into T.
+// T is Object, one of the typed objects such as Contact, or any struct matching the object json.
+type Objects[T any] struct {
+	service    *Service
+	objectType ObjectType
+}
+
+// NewObjects returns the API of objectType, which is one of the ObjectType constants, an object type id such as 2-123456 or the fullyQualifiedName of a custom object
+func NewObjects[T any](service *Service, objectType ObjectType) *Objects[T] {
+	return &Objects[T]{service: service, objectType: objectType}
+}
+
+func (objects *Objects[T]) ObjectType() ObjectType {
+	return objects.objectType
+}
+
+// properties returns properties, or the properties of T if properties is nil
+func (objects *Objects[T]) properties(properties *[]string) *[]string {
+	if properties != nil {
+		return properties
+	}
+	if names := defaultProperties[T](); names != nil {
+		return &names
+	}
+	return nil
+}
+
+type ListObjectsConfig struct {
+	Limit                 *uint
+	After                 *string
+	Properties            *[]string
+	PropertiesWithHistory *[]string
+	Associations          *[]string
+	Archived              *bool
+}
+
+// List returns all objects
+func (objects *Objects[T]) List(config *ListObjectsConfig) (*[]T, *errortools.Error) {
+	return objects.ListWithContext(context.Background(), config)
+}
+
+func (objects *Objects[T]) ListWithContext(ctx context.Context, config *ListObjectsConfig) (*[]T, *errortools.Error) {
+	return collect(objects.iterFrom(ctx, objects.NewCursor(config), config != nil && config.After != nil))
+}
+
+// Iter streams all objects page by page, starting at config.After
+func (objects *Objects[T]) Iter(ctx context.Context, config *ListObjectsConfig) iter.Seq2[T, error] {
+	return iterErrors(func(yield func(T, *errortools.Error) bool) {
+		objects.iterFrom(ctx, objects.NewCursor(config), false)(yield)
+	})
+}
+
+// NewCursor returns a cursor at the start of List, or at config.After
+func (objects *Objects[T]) NewCursor(config *ListObjectsConfig) *Cursor {
+	values := url.Values{}
+	after := ""
+
+	if config == nil {
+		config = &ListObjectsConfig{}
+	}
+
+	if config.Limit != nil {
+		values.Set("limit", fmt.Sprintf("%v", *config.Limit))
+	}
+	if properties := objects.properties(config.Properties); properties != nil && len(*properties) > 0 {
+		values.Set("properties", strings.Join(*properties, ","))
+	}
+	if config.PropertiesWithHistory != nil && len(*config.PropertiesWithHistory) > 0 {
+		values.Set("propertiesWithHistory", strings.Join(*config.PropertiesWithHistory, ","))
+	}
+	if config.Associations != nil && len(*config.Associations) > 0 {
+		values.Set("associations", strings.Join(*config.Associations, ","))
+	}
+	if config.Archived != nil {
+		values.Set("archived", fmt.Sprintf("%v", *config.Archived))
+	}
+	if config.After != nil {
+		after = *config.After
+	}
+
+	return &Cursor{ObjectType: string(objects.objectType), Query: values, After: after}
+}
+
+// IterFrom resumes Iter at cursor
+func (objects *Objects[T]) IterFrom(ctx context.Context, cursor *Cursor) iter.Seq2[T, error] {
+	return iterErrors(objects.iterFrom(ctx, cursor, false))
+}
+
+func (objects *Objects[T]) iterFrom(ctx context.Context, cursor *Cursor, singlePage bool) iter.Seq2[T, *errortools.Error] {
+	if e := cursor.check(string(objects.objectType)); e != nil {
+		return iterError[T](e)
+	}
+
+	return iterPages[T](ctx, objects.service, cursor, singlePage, func(after string) go_http.RequestConfig {
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    objects.service.urlCrm(fmt.Sprintf("objects/%s?%s", objects.objectType, encodeWithParam(cursor.Query, "after", after))),
 		}
+	})
+}
+
+type GetObjectConfig struct {
+	ObjectId              string
+	IdProperty            *string
+	Properties            *[]string
+	PropertiesWithHistory *[]string
+	Associations          *[]string
+	Archived              *bool
+}
+
+// Get returns a specific object, or nil if it does not exist
+func (objects *Objects[T]) Get(config *GetObjectConfig) (*T, *errortools.Error) {
+	return objects.GetWithContext(context.Background(), config)
+}
+
+func (objects *Objects[T]) GetWithContext(ctx context.Context, config *GetObjectConfig) (*T, *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
+	}
+
+	values := url.Values{}
+
+	if config.IdProperty != nil {
+		values.Set("idProperty", *config.IdProperty)
+	}
+	if properties := objects.properties(config.Properties); properties != nil && len(*properties) > 0 {
+		values.Set("properties", strings.Join(*properties, ","))
+	}
+	if config.PropertiesWithHistory != nil && len(*config.PropertiesWithHistory) > 0 {
+		values.Set("propertiesWithHistory", strings.Join(*config.PropertiesWithHistory, ","))
+	}
+	if config.Associations != nil && len(*config.Associations) > 0 {
+		values.Set("associations", strings.Join(*config.Associations, ","))
+	}
+	if config.Archived != nil {
+		values.Set("archived", fmt.Sprintf("%v", *config.Archived))
+	}
+
+	var object T
+
+	requestConfig := go_http.RequestConfig{
+		Method:        http.MethodGet,
+		Url:           objects.service.urlCrm(fmt.Sprintf("objects/%s/%s?%s", objects.objectType, config.ObjectId, values.Encode())),
+		ResponseModel: &object,
+	}
+
+	_, response, e := objects.service.httpRequest(ctx, &requestConfig)
+	if response != nil {
+		if response.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+	}
+	if e != nil {
+		return nil, e
+	}
+
+	return &object, nil
+}
+
+// Create creates an object, config.ObjectType is ignored
+func (objects *Objects[T]) Create(config *CreateObjectConfig) (*T, *errortools.Error) {
+	return objects.CreateWithContext(context.Background(), config)
+}
+
+func (objects *Objects[T]) CreateWithContext(ctx context.Context, config *CreateObjectConfig) (*T, *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
+	}
+
+	var object T
+
+	requestConfig := go_http.RequestConfig{
+		Method:        http.MethodPost,
+		Url:           objects.service.urlCrm(fmt.Sprintf("objects/%s", objects.objectType)),
+		BodyModel:     config,
+		ResponseModel: &object,
+	}
+
+	_, _, e := objects.service.httpRequest(ctx, &requestConfig)
+	if e != nil {
+		return nil, e
+	}
+
+	return &object, nil
+}
+
+// Update updates an object, config.ObjectType is ignored
+func (objects *Objects[T]) Update(config *UpdateObjectConfig) (*T, *errortools.Error) {
+	return objects.UpdateWithContext(context.Background(), config)
+}
+
+func (objects *Objects[T]) UpdateWithContext(ctx context.Context, config *UpdateObjectConfig) (*T, *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
+	}
+
+	values := url.Values{}
+
+	if config.IdProperty != nil {
+		values.Set("idProperty", *config.IdProperty)
+	}
+
+	var object T
+
+	requestConfig := go_http.RequestConfig{
+		Method:        http.MethodPatch,
+		Url:           objects.service.urlCrm(fmt.Sprintf("objects/%s/%s?%s", objects.objectType, config.ObjectId, values.Encode())),
+		BodyModel:     config,
+		ResponseModel: &object,
+	}
+
+	_, _, e := objects.service.httpRequest(ctx, &requestConfig)
+	if e != nil {
+		return nil, e
+	}
+
+	return &object, nil
+}
+
+// Archive moves an object to the recycling bin
+func (objects *Objects[T]) Archive(objectId string) *errortools.Error {
+	return objects.ArchiveWithContext(context.Background(), objectId)
+}
+
+func (objects *Objects[T]) ArchiveWithContext(ctx context.Context, objectId string) *errortools.Error {
+	requestConfig := go_http.RequestConfig{
+		Method: http.MethodDelete,
+		Url:    objects.service.urlCrm(fmt.Sprintf("objects/%s/%s", objects.objectType, objectId)),
+	}
+
+	_, _, e := objects.service.httpRequest(ctx, &requestConfig)
+	return e
+}
+
+// Search returns all objects matching config
+func (objects *Objects[T]) Search(config *SearchObjectsConfig) (*[]T, *errortools.Error) {
+	return objects.SearchWithContext(context.Background(), config)
+}
+
+func (objects *Objects[T]) SearchWithContext(ctx context.Context, config *SearchObjectsConfig) (*[]T, *errortools.Error) {
+	return collect(iterSearch[T](ctx, objects.service, objects.NewSearchCursor(config), objects.objectType, config != nil && config.After != nil))
+}
+
+// SearchWithTotal returns all objects matching config and the total HubSpot reports for the search
+func (objects *Objects[T]) SearchWithTotal(config *SearchObjectsConfig) (*SearchResult[T], *errortools.Error) {
+	return objects.SearchWithTotalWithContext(context.Background(), config)
+}
+
+func (objects *Objects[T]) SearchWithTotalWithContext(ctx context.Context, config *SearchObjectsConfig) (*SearchResult[T], *errortools.Error) {
+	cursor := objects.NewSearchCursor(config)
+	return collectSearch(cursor, iterSearch[T](ctx, objects.service, cursor, objects.objectType, config != nil && config.After != nil))
+}
+
+// IterSearch streams all objects matching config page by page, starting at config.After
+func (objects *Objects[T]) IterSearch(ctx context.Context, config *SearchObjectsConfig) iter.Seq2[T, error] {
+	return iterErrors(func(yield func(T, *errortools.Error) bool) {
+		iterSearch[T](ctx, objects.service, objects.NewSearchCursor(config), objects.objectType, false)(yield)
+	})
+}
+
+// NewSearchCursor returns a cursor at the start of Search, or at config.After
+func (objects *Objects[T]) NewSearchCursor(config *SearchObjectsConfig) *Cursor {
+	if config != nil {
+		config_ := *config
+		config_.Properties = objects.properties(config.Properties)
+		config = &config_
+	}
+
+	return NewSearchCursor(objects.objectType, config)
+}
+
+// IterSearchFrom resumes IterSearch at a cursor created by NewSearchCursor
+func (objects *Objects[T]) IterSearchFrom(ctx context.Context, cursor *Cursor) iter.Seq2[T, error] {
+	return iterErrors(iterSearch[T](ctx, objects.service, cursor, objects.objectType, false))
+}
+
+// BatchRead returns the objects with the ids of config.Inputs, config.ObjectType is ignored
+func (objects *Objects[T]) BatchRead(config *BatchGetObjectsConfig) (*BatchResult[T], *errortools.Error) {
+	return objects.BatchReadWithContext(context.Background(), config)
+}
+
+func (objects *Objects[T]) BatchReadWithContext(ctx context.Context, config *BatchGetObjectsConfig) (*BatchResult[T], *errortools.Error) {
+	if config != nil && config.Properties == nil {
+		config_ := *config
+		config_.Properties = defaultProperties[T]()
+		config = &config_
+	}
+
+	return batchGetObjects[T](ctx, objects.service, objects.objectType, config)
+}
+
+// BatchCreate creates the objects of config.Inputs in batches of 100, config.ObjectType is ignored
+func (objects *Objects[T]) BatchCreate(config *BatchObjectsConfig) (*BatchResult[T], *errortools.Error) {
+	return objects.BatchCreateWithContext(context.Background(), config)
+}
+
+func (objects *Objects[T]) BatchCreateWithContext(ctx context.Context, config *BatchObjectsConfig) (*BatchResult[T], *errortools.Error) {
+	return batchObjects[T](ctx, objects.service, objects.objectType, "create", config)
+}
+
+// BatchUpdate updates the objects of config.Inputs in batches of 100, config.ObjectType is ignored
+func (objects *Objects[T]) BatchUpdate(config *BatchObjectsConfig) (*BatchResult[T], *errortools.Error) {
+	return objects.BatchUpdateWithContext(context.Background(), config)
+}
 
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
+func (objects *Objects[T]) BatchUpdateWithContext(ctx context.Context, config *BatchObjectsConfig) (*BatchResult[T], *errortools.Error) {
+	return batchObjects[T](ctx, objects.service, objects.objectType, "update", config)
+}
+
+// BatchUpsert creates or updates the objects of config.Inputs in batches of 100, matching Id against the value of IdProperty.
+// Upserted.New reports whether an object was created.
+func (objects *Objects[T]) BatchUpsert(config *BatchObjectsConfig) (*BatchResult[Upserted[T]], *errortools.Error) {
+	return objects.BatchUpsertWithContext(context.Background(), config)
+}
+
+func (objects *Objects[T]) BatchUpsertWithContext(ctx context.Context, config *BatchObjectsConfig) (*BatchResult[Upserted[T]], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
+	}
+
+	for i, input := range config.Inputs {
+		if input.Id == nil {
+			return nil, errortools.ErrorMessage(fmt.Sprintf("Id of input %v is required for an upsert", i))
+		}
+		if input.IdProperty == nil && config.IdProperty == nil {
+			return nil, errortools.ErrorMessage(fmt.Sprintf("IdProperty of input %v is required for an upsert", i))
 		}
+	}
 
-		objects = append(objects, batchGetObjectsResponse.Results...)
+	return batchObjects[Upserted[T]](ctx, objects.service, objects.objectType, "upsert", config)
+}
+
+// Upserted is an object returned by a batch upsert
+type Upserted[T any] struct {
+	Object T
+	New    bool // true if the object was created, false if an existing object was updated
+}
+
+func (upserted *Upserted[T]) UnmarshalJSON(b []byte) error {
+	var result struct {
+		New bool `json:"new"`
+	}
+	if err := json.Unmarshal(b, &result); err != nil {
+		return err
 	}
+	upserted.New = result.New
+
+	return json.Unmarshal(b, &upserted.Object)
+}
+
+// BatchUpsertObjects creates or updates objects of config.ObjectType by a unique property, such as email for contacts
+func (service *Service) BatchUpsertObjects(config *BatchObjectsConfig) (*BatchResult[Upserted[Object]], *errortools.Error) {
+	return service.BatchUpsertObjectsWithContext(context.Background(), config)
+}
+
+func (service *Service) BatchUpsertObjectsWithContext(ctx context.Context, config *BatchObjectsConfig) (*BatchResult[Upserted[Object]], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
+	}
+
+	return NewObjects[Object](service, ObjectType(config.ObjectType)).BatchUpsertWithContext(ctx, config)
+}
+
+// SearchObjects returns the objects of objectType matching config, objectType is one of the ObjectType constants, an object type id such as 2-123456 or the fullyQualifiedName of a custom object
+func (service *Service) SearchObjects(objectType ObjectType, config *SearchObjectsConfig) (*[]Object, *errortools.Error) {
+	return service.SearchObjectsWithContext(context.Background(), objectType, config)
+}
+
+func (service *Service) SearchObjectsWithContext(ctx context.Context, objectType ObjectType, config *SearchObjectsConfig) (*[]Object, *errortools.Error) {
+	if objectType == "" {
+		return nil, errortools.ErrorMessage("ObjectType must not be empty")
+	}
+
+	return NewObjects[Object](service, objectType).SearchWithContext(ctx, config)
+}
+
+// IterSearchObjects streams the objects of objectType matching config page by page, starting at config.After
+func (service *Service) IterSearchObjects(ctx context.Context, objectType ObjectType, config *SearchObjectsConfig) iter.Seq2[Object, error] {
+	if objectType == "" {
+		return iterErrors(iterError[Object](errortools.ErrorMessage("ObjectType must not be empty")))
+	}
+
+	return NewObjects[Object](service, objectType).IterSearch(ctx, config)
+}
+
+// IterSearchObjectsFrom resumes IterSearchObjects at a cursor created by NewSearchCursor
+func (service *Service) IterSearchObjectsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[Object, error] {
+	return iterErrors(iterSearch[Object](ctx, service, cursor, "", false))
+}
+
+// BatchArchive archives the objects with objectIds in batches of 100
+func (objects *Objects[T]) BatchArchive(objectIds []string) *errortools.Error {
+	return objects.BatchArchiveWithContext(context.Background(), objectIds)
+}
+
+func (objects *Objects[T]) BatchArchiveWithContext(ctx context.Context, objectIds []string) *errortools.Error {
+	return objects.service.forEachBatch(ctx, objects.service.batches(len(objectIds)), func(ctx context.Context, i int, batch batch) *errortools.Error {
+		var body struct {
+			Inputs []BatchGetObjectsInput `json:"inputs"`
+		}
+
+		for _, objectId := range objectIds[batch.startIndex:batch.endIndex] {
+			body.Inputs = append(body.Inputs, BatchGetObjectsInput{Id: objectId})
+		}
+
+		requestConfig := go_http.RequestConfig{
+			Method:    http.MethodPost,
+			Url:       objects.service.urlCrm(fmt.Sprintf("objects/%s/batch/archive", objects.objectType)),
+			BodyModel: body,
+		}
+
+		_, _, e := objects.service.httpRequest(ctx, &requestConfig)
 
-	return &objects, nil
+		return e
+	})
 }
@@ -0,0 +1,195 @@
+package hubspot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// objectsServer keeps the objects of one object type in memory and answers the v3 object endpoints on them,
+// list pages hold two objects
+type objectsServer struct {
+	objectType string
+
+	mutex   sync.Mutex
+	objects map[string]map[string]string
+	nextId  int
+}
+
+func (server *objectsServer) object(id string) string {
+	properties, _ := json.Marshal(server.objects[id])
+	return fmt.Sprintf(`{"id":"%s","properties":%s}`, id, properties)
+}
+
+func (server *objectsServer) handle(w http.ResponseWriter, r *http.Request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	path, ok := strings.CutPrefix(r.URL.Path, "/crm/v3/objects/"+server.objectType)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id := strings.TrimPrefix(path, "/")
+
+	var body struct {
+		Properties   map[string]string `json:"properties"`
+		FilterGroups []FilterGroup     `json:"filterGroups"`
+	}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	ids := []string{}
+	for id := range server.objects {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a string, b string) int {
+		i, _ := strconv.Atoi(a)
+		j, _ := strconv.Atoi(b)
+		return i - j
+	})
+
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		offset, _ := strconv.Atoi(r.URL.Query().Get("after"))
+		results := []string{}
+		for _, id := range ids[offset:min(offset+2, len(ids))] {
+			results = append(results, server.object(id))
+		}
+		paging := ""
+		if offset+2 < len(ids) {
+			paging = fmt.Sprintf(`,"paging":{"next":{"after":"%v"}}`, offset+2)
+		}
+		writeJson(w, http.StatusOK, fmt.Sprintf(`{"results":[%s]%s}`, strings.Join(results, ","), paging))
+	case r.Method == http.MethodPost && id == "":
+		server.nextId++
+		id = strconv.Itoa(server.nextId)
+		server.objects[id] = body.Properties
+		writeJson(w, http.StatusCreated, server.object(id))
+	case r.Method == http.MethodPost && id == "search":
+		results := []string{}
+		for _, id := range ids {
+			for _, filter := range *body.FilterGroups[0].Filters {
+				if filter.Operator == OperatorEq && server.objects[id][filter.PropertyName] == filter.Value {
+					results = append(results, server.object(id))
+				}
+			}
+		}
+		writeJson(w, http.StatusOK, fmt.Sprintf(`{"total":%v,"results":[%s]}`, len(results), strings.Join(results, ",")))
+	case server.objects[id] == nil:
+		writeJson(w, http.StatusNotFound, `{"status":"error","category":"OBJECT_NOT_FOUND","message":"Object not found"}`)
+	case r.Method == http.MethodGet:
+		writeJson(w, http.StatusOK, server.object(id))
+	case r.Method == http.MethodPatch:
+		for name, value := range body.Properties {
+			server.objects[id][name] = value
+		}
+		writeJson(w, http.StatusOK, server.object(id))
+	case r.Method == http.MethodDelete:
+		delete(server.objects, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestObjectsRoundTrip(t *testing.T) {
+	server := objectsServer{objectType: "2-123", objects: map[string]map[string]string{}}
+	service := newTestService(t, server.handle, nil)
+
+	objects := NewObjects[Object](service, "2-123")
+
+	for _, name := range []string{"a", "b", "c"} {
+		object, e := objects.Create(&CreateObjectConfig{Properties: map[string]string{"name": name, "stage": "new"}})
+		if e != nil {
+			t.Fatal(e.Message())
+		}
+		if object.Properties["name"] != name {
+			t.Errorf("got created object %+v, want name %s", object, name)
+		}
+	}
+
+	object, e := objects.Get(&GetObjectConfig{ObjectId: "2"})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if object == nil || object.Id != "2" || object.Properties["name"] != "b" {
+		t.Fatalf("got object %+v, want object 2 named b", object)
+	}
+
+	object, e = objects.Update(&UpdateObjectConfig{ObjectId: "2", Properties: map[string]string{"stage": "won"}})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if object.Properties["stage"] != "won" || object.Properties["name"] != "b" {
+		t.Errorf("got updated object %+v, want stage won", object)
+	}
+
+	list, e := objects.List(nil)
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if len(*list) != 3 || (*list)[2].Id != "3" {
+		t.Errorf("got %+v, want the three objects over two pages", *list)
+	}
+
+	config, e := NewSearchBuilder().Filter("stage", OperatorEq, "won").Build()
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	found, e := objects.Search(config)
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if len(*found) != 1 || (*found)[0].Id != "2" {
+		t.Errorf("got %+v, want object 2", *found)
+	}
+
+	if e := objects.Archive("2"); e != nil {
+		t.Fatal(e.Message())
+	}
+	object, e = objects.Get(&GetObjectConfig{ObjectId: "2"})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if object != nil {
+		t.Errorf("got archived object %+v, want nil", object)
+	}
+	if e := objects.Archive("2"); e == nil {
+		t.Error("expected an error archiving a missing object")
+	}
+}
+
+func TestObjectsTyped(t *testing.T) {
+	server := objectsServer{objectType: "deals", objects: map[string]map[string]string{}}
+	service := newTestService(t, server.handle, nil)
+
+	deals := NewObjects[TypedObject[testDeal]](service, ObjectTypeDeals)
+
+	amount := 12.5
+	properties, e := MarshalProperties(testDeal{Name: "deal", Amount: &amount, Tags: []string{"a", "b"}})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	created, e := deals.Create(&CreateObjectConfig{Properties: properties})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+
+	deal, e := deals.Get(&GetObjectConfig{ObjectId: created.Id})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if deal.Properties.Name != "deal" || *deal.Properties.Amount != 12.5 || !slices.Equal(deal.Properties.Tags, []string{"a", "b"}) {
+		t.Errorf("got deal %+v, want the created deal", deal.Properties)
+	}
+	if deal.Properties.CloseDate != nil {
+		t.Errorf("got close date %v, want nil", deal.Properties.CloseDate)
+	}
+}
@@ -1,7 +1,9 @@
 package hubspot
 
 import (
+	"context"
 	"fmt"
+	"iter"
 	"net/http"
 	"net/url"
 
@@ -43,8 +45,27 @@ type GetOwnersConfig struct {
 // GetOwners returns all owners
 //
 func (service *Service) GetOwners(config *GetOwnersConfig) (*[]Owner, *errortools.Error) {
+	return service.GetOwnersWithContext(context.Background(), config)
+}
+
+func (service *Service) GetOwnersWithContext(ctx context.Context, config *GetOwnersConfig) (*[]Owner, *errortools.Error) {
+	return collect(service.iterOwners(ctx, config, config != nil && config.After != nil))
+}
+
+// IterOwners streams all owners page by page, starting at config.After
+func (service *Service) IterOwners(ctx context.Context, config *GetOwnersConfig) iter.Seq2[Owner, error] {
+	return iterErrors(service.iterOwners(ctx, config, false))
+}
+
+func (service *Service) iterOwners(ctx context.Context, config *GetOwnersConfig, singlePage bool) iter.Seq2[Owner, *errortools.Error] {
+	return func(yield func(Owner, *errortools.Error) bool) {
+		service.iterOwnersFrom(ctx, NewOwnersCursor(config), singlePage)(yield)
+	}
+}
+
+// NewOwnersCursor returns a cursor at the start of GetOwners, or at config.After
+func NewOwnersCursor(config *GetOwnersConfig) *Cursor {
 	values := url.Values{}
-	endpoint := "owners"
 
 	after := ""
 
@@ -60,44 +81,23 @@ func (service *Service) GetOwners(config *GetOwnersConfig) (*[]Owner, *errortool
 		}
 	}
 
-	owners := []Owner{}
-
-	for {
-		ownersResponse := OwnersResponse{}
-
-		if after != "" {
-			values.Set("after", after)
-		}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodGet,
-			Url:           service.urlCrm(fmt.Sprintf("%s?%s", endpoint, values.Encode())),
-			ResponseModel: &ownersResponse,
-		}
-
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
-
-		owners = append(owners, ownersResponse.Results...)
-
-		if config != nil {
-			if config.After != nil { // explicit after parameter requested
-				break
-			}
-		}
-
-		if ownersResponse.Paging == nil {
-			break
-		}
+	return &Cursor{ObjectType: "owners", Query: values, After: after}
+}
 
-		if ownersResponse.Paging.Next.After == "" {
-			break
-		}
+// IterOwnersFrom resumes IterOwners at cursor
+func (service *Service) IterOwnersFrom(ctx context.Context, cursor *Cursor) iter.Seq2[Owner, error] {
+	return iterErrors(service.iterOwnersFrom(ctx, cursor, false))
+}
 
-		after = ownersResponse.Paging.Next.After
+func (service *Service) iterOwnersFrom(ctx context.Context, cursor *Cursor, singlePage bool) iter.Seq2[Owner, *errortools.Error] {
+	if e := cursor.check("owners"); e != nil {
+		return iterError[Owner](e)
 	}
 
-	return &owners, nil
+	return iterPages[Owner](ctx, service, cursor, singlePage, func(after string) go_http.RequestConfig {
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlCrm(fmt.Sprintf("owners?%s", encodeWithParam(cursor.Query, "after", after))),
+		}
+	})
 }
@@ -1,8 +1,150 @@
 package hubspot
 
+import (
+	"context"
+	"iter"
+	"net/url"
+
+	errortools "github.com/leapforce-libraries/go_errortools"
+	go_http "github.com/leapforce-libraries/go_http"
+)
+
 type Paging struct {
 	Next struct {
 		After string `json:"after"`
 		Link  string `json:"link"`
 	} `json:"next"`
 }
+
+type pageResponse[T any] struct {
+	Results []T     `json:"results"`
+	Paging  *Paging `json:"paging"`
+	Total   *int64  `json:"total"` // search endpoints only
+}
+
+// iterPages streams the results of an endpoint paginated by Paging, starting at cursor.After.
+// newRequest returns the request for the page starting at after; if singlePage is set only that page is requested.
+// cursor is advanced after every page that was yielded completely.
+func iterPages[T any](ctx context.Context, service *Service, cursor *Cursor, singlePage bool, newRequest func(after string) go_http.RequestConfig) iter.Seq2[T, *errortools.Error] {
+	return func(yield func(T, *errortools.Error) bool) {
+		for !cursor.Done {
+			response := pageResponse[T]{}
+
+			requestConfig := newRequest(cursor.After)
+			requestConfig.ResponseModel = &response
+
+			_, _, e := service.httpRequest(ctx, &requestConfig)
+			if e != nil {
+				var zero T
+				yield(zero, e)
+				return
+			}
+
+			for _, result := range response.Results {
+				if !yield(result, nil) {
+					return
+				}
+			}
+
+			if response.Total != nil {
+				cursor.Total = *response.Total
+			}
+			cursor.After = ""
+			if response.Paging != nil {
+				cursor.After = response.Paging.Next.After
+			}
+			cursor.Done = cursor.After == ""
+
+			if singlePage {
+				return
+			}
+		}
+	}
+}
+
+// iterOffsetPages streams the results of a legacy endpoint paginated by offset, starting at cursor.Offset.
+// page extracts the results, whether there are more and the next offset from a response.
+func iterOffsetPages[T any, R any](ctx context.Context, service *Service, cursor *Cursor, singlePage bool, newRequest func(offset int64) go_http.RequestConfig, page func(response *R) ([]T, bool, int64)) iter.Seq2[T, *errortools.Error] {
+	return func(yield func(T, *errortools.Error) bool) {
+		for !cursor.Done {
+			response := new(R)
+
+			requestConfig := newRequest(cursor.Offset)
+			requestConfig.ResponseModel = response
+
+			_, _, e := service.httpRequest(ctx, &requestConfig)
+			if e != nil {
+				var zero T
+				yield(zero, e)
+				return
+			}
+
+			results, hasMore, nextOffset := page(response)
+
+			for _, result := range results {
+				if !yield(result, nil) {
+					return
+				}
+			}
+
+			cursor.Offset = nextOffset
+			cursor.Done = !hasMore
+
+			if singlePage {
+				return
+			}
+		}
+	}
+}
+
+// iterErrors converts the errors of seq so they can be inspected with errors.Is and errors.As
+func iterErrors[T any](seq iter.Seq2[T, *errortools.Error]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for v, e := range seq {
+			if e != nil {
+				yield(v, AsError(e))
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
+
+// collect loads all values of seq in a slice
+func collect[T any](seq iter.Seq2[T, *errortools.Error]) (*[]T, *errortools.Error) {
+	values := []T{}
+
+	for v, e := range seq {
+		if e != nil {
+			return nil, e
+		}
+		values = append(values, v)
+	}
+
+	return &values, nil
+}
+
+// encodeWithParam returns values encoded with key set to value, leaving values itself untouched
+func encodeWithParam(values url.Values, key string, value string) string {
+	if value == "" {
+		return values.Encode()
+	}
+
+	values_ := url.Values{}
+	for k, v := range values {
+		values_[k] = v
+	}
+	values_.Set(key, value)
+
+	return values_.Encode()
+}
+
+// iterError returns a sequence that only yields e
+func iterError[T any](e *errortools.Error) iter.Seq2[T, *errortools.Error] {
+	return func(yield func(T, *errortools.Error) bool) {
+		var zero T
+		yield(zero, e)
+	}
+}
@@ -0,0 +1,144 @@
+package hubspot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// pagedServer pages objects with ids 1 to n like the v3 list endpoints, the after of a page is the number of objects before it
+type pagedServer struct {
+	n        int
+	pageSize int
+
+	mutex    sync.Mutex
+	requests []string // after of every request
+	fail     int      // page to answer with a 400, counting from 1, or 0
+}
+
+func (server *pagedServer) handle(w http.ResponseWriter, r *http.Request) {
+	after := r.URL.Query().Get("after")
+
+	server.mutex.Lock()
+	server.requests = append(server.requests, after)
+	fail := server.fail
+	server.mutex.Unlock()
+
+	offset, _ := strconv.Atoi(after)
+	if fail != 0 && offset/server.pageSize+1 == fail {
+		writeJson(w, http.StatusBadRequest, `{"status":"error","category":"VALIDATION_ERROR","message":"invalid page"}`)
+		return
+	}
+
+	results := []string{}
+	for id := offset + 1; id <= min(offset+server.pageSize, server.n); id++ {
+		results = append(results, fmt.Sprintf(`{"id":"%v"}`, id))
+	}
+
+	paging := ""
+	if offset+server.pageSize < server.n {
+		paging = fmt.Sprintf(`,"paging":{"next":{"after":"%v"}}`, offset+server.pageSize)
+	}
+
+	writeJson(w, http.StatusOK, fmt.Sprintf(`{"results":[%s]%s}`, strings.Join(results, ","), paging))
+}
+
+func TestIterContacts(t *testing.T) {
+	server := pagedServer{n: 25, pageSize: 10}
+	service := newTestService(t, server.handle, nil)
+
+	ids := []string{}
+	for contact, err := range service.IterContacts(context.Background(), nil) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, contact.Id)
+	}
+
+	checkAllOnce(t, ids, 25)
+	for i, id := range ids {
+		if id != strconv.Itoa(i+1) {
+			t.Fatalf("got object %s at position %v, want the objects in order", id, i)
+		}
+	}
+	if strings.Join(server.requests, ",") != ",10,20" {
+		t.Errorf("got requests after %q, want \"\", 10 and 20", server.requests)
+	}
+
+	contacts, e := service.GetContacts(nil)
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if len(*contacts) != 25 {
+		t.Errorf("got %v contacts, want 25", len(*contacts))
+	}
+}
+
+func TestIterContactsBreak(t *testing.T) {
+	server := pagedServer{n: 25, pageSize: 10}
+	service := newTestService(t, server.handle, nil)
+
+	count := 0
+	for _, err := range service.IterContacts(context.Background(), nil) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		count++
+		if count == 12 {
+			break
+		}
+	}
+
+	// the third page is never requested
+	if len(server.requests) != 2 {
+		t.Errorf("got %v requests, want 2", len(server.requests))
+	}
+}
+
+func TestIterContactsError(t *testing.T) {
+	server := pagedServer{n: 25, pageSize: 10, fail: 2}
+	service := newTestService(t, server.handle, nil)
+
+	ids := []string{}
+	var err error
+	for contact, err_ := range service.IterContacts(context.Background(), nil) {
+		if err_ != nil {
+			err = err_
+			break
+		}
+		ids = append(ids, contact.Id)
+	}
+
+	var validationError *ValidationError
+	if !errors.As(err, &validationError) {
+		t.Fatalf("got error %v, want the validation error of the second page", err)
+	}
+	checkAllOnce(t, ids, 10)
+
+	if _, e := service.GetContacts(nil); e == nil {
+		t.Error("expected GetContacts to fail")
+	}
+}
+
+func TestGetContactsAfter(t *testing.T) {
+	server := pagedServer{n: 25, pageSize: 10}
+	service := newTestService(t, server.handle, nil)
+
+	// an explicit after returns only the page it points to
+	after := "10"
+	contacts, e := service.GetContacts(&GetContactsConfig{After: &after})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if len(*contacts) != 10 || (*contacts)[0].Id != "11" {
+		t.Errorf("got %v contacts starting at %+v, want contacts 11 to 20", len(*contacts), (*contacts)[0])
+	}
+	if len(server.requests) != 1 {
+		t.Errorf("got %v requests, want 1", len(server.requests))
+	}
+}
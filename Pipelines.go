@@ -1,6 +1,7 @@
 package hubspot
 
 import (
+	"context"
 	"fmt"
 	go_types "github.com/leapforce-libraries/go_types"
 	"net/http"
@@ -55,6 +56,10 @@ type GetPipelinesConfig struct {
 
 // GetPipelines returns all pipelines
 func (service *Service) GetPipelines(config *GetPipelinesConfig) (*[]Pipeline, *errortools.Error) {
+	return service.GetPipelinesWithContext(context.Background(), config)
+}
+
+func (service *Service) GetPipelinesWithContext(ctx context.Context, config *GetPipelinesConfig) (*[]Pipeline, *errortools.Error) {
 	values := url.Values{}
 	endpoint := "pipelines"
 
@@ -72,7 +77,7 @@ func (service *Service) GetPipelines(config *GetPipelinesConfig) (*[]Pipeline, *
 		ResponseModel: &pipelinesResponse,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -87,6 +92,10 @@ type CreatePipelineConfig struct {
 
 // CreatePipeline creates a new pipeline
 func (service *Service) CreatePipeline(config *CreatePipelineConfig) (*Pipeline, *errortools.Error) {
+	return service.CreatePipelineWithContext(context.Background(), config)
+}
+
+func (service *Service) CreatePipelineWithContext(ctx context.Context, config *CreatePipelineConfig) (*Pipeline, *errortools.Error) {
 	var pipelineNew Pipeline
 
 	requestConfig := go_http.RequestConfig{
@@ -96,7 +105,7 @@ func (service *Service) CreatePipeline(config *CreatePipelineConfig) (*Pipeline,
 		ResponseModel: &pipelineNew,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -112,6 +121,10 @@ type UpdatePipelineConfig struct {
 
 // UpdatePipeline updates an existing pipeline
 func (service *Service) UpdatePipeline(config *UpdatePipelineConfig) (*Pipeline, *errortools.Error) {
+	return service.UpdatePipelineWithContext(context.Background(), config)
+}
+
+func (service *Service) UpdatePipelineWithContext(ctx context.Context, config *UpdatePipelineConfig) (*Pipeline, *errortools.Error) {
 	var pipelineNew Pipeline
 
 	requestConfig := go_http.RequestConfig{
@@ -121,7 +134,7 @@ func (service *Service) UpdatePipeline(config *UpdatePipelineConfig) (*Pipeline,
 		ResponseModel: &pipelineNew,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -1,11 +1,13 @@
 package hubspot
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
 	h_types "github.com/leapforce-libraries/go_hubspot/types"
+	"iter"
 	"net/http"
 	"net/url"
 	"strings"
@@ -39,8 +41,27 @@ type GetProductsConfig struct {
 
 // GetProducts returns all products
 func (service *Service) GetProducts(config *GetProductsConfig) (*[]Product, *errortools.Error) {
+	return service.GetProductsWithContext(context.Background(), config)
+}
+
+func (service *Service) GetProductsWithContext(ctx context.Context, config *GetProductsConfig) (*[]Product, *errortools.Error) {
+	return collect(service.iterProducts(ctx, config, config != nil && config.After != nil))
+}
+
+// IterProducts streams all products page by page, starting at config.After
+func (service *Service) IterProducts(ctx context.Context, config *GetProductsConfig) iter.Seq2[Product, error] {
+	return iterErrors(service.iterProducts(ctx, config, false))
+}
+
+func (service *Service) iterProducts(ctx context.Context, config *GetProductsConfig, singlePage bool) iter.Seq2[Product, *errortools.Error] {
+	return func(yield func(Product, *errortools.Error) bool) {
+		service.iterProductsFrom(ctx, NewProductsCursor(config), singlePage)(yield)
+	}
+}
+
+// NewProductsCursor returns a cursor at the start of GetProducts, or at config.After
+func NewProductsCursor(config *GetProductsConfig) *Cursor {
 	values := url.Values{}
-	endpoint := "objects/products"
 
 	if config != nil {
 		if config.Limit != nil {
@@ -75,51 +96,36 @@ func (service *Service) GetProducts(config *GetProductsConfig) (*[]Product, *err
 	}
 
 	after := ""
-	if config.After != nil {
+	if config != nil && config.After != nil {
 		after = *config.After
 	}
 
-	products := []Product{}
-
-	for {
-		productsResponse := ProductsResponse{}
-
-		if after != "" {
-			values.Set("after", after)
-		}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodGet,
-			Url:           service.urlCrm(fmt.Sprintf("%s?%s", endpoint, values.Encode())),
-			ResponseModel: &productsResponse,
-		}
-
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
-
-		products = append(products, productsResponse.Results...)
-
-		if config.After != nil { // explicit after parameter requested
-			break
-		}
-
-		if productsResponse.Paging == nil {
-			break
-		}
+	return &Cursor{ObjectType: string(ObjectTypeProducts), Query: values, After: after}
+}
 
-		if productsResponse.Paging.Next.After == "" {
-			break
-		}
+// IterProductsFrom resumes IterProducts at cursor
+func (service *Service) IterProductsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[Product, error] {
+	return iterErrors(service.iterProductsFrom(ctx, cursor, false))
+}
 
-		after = productsResponse.Paging.Next.After
+func (service *Service) iterProductsFrom(ctx context.Context, cursor *Cursor, singlePage bool) iter.Seq2[Product, *errortools.Error] {
+	if e := cursor.check(string(ObjectTypeProducts)); e != nil {
+		return iterError[Product](e)
 	}
 
-	return &products, nil
+	return iterPages[Product](ctx, service, cursor, singlePage, func(after string) go_http.RequestConfig {
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlCrm(fmt.Sprintf("objects/%s?%s", cursor.ObjectType, encodeWithParam(cursor.Query, "after", after))),
+		}
+	})
 }
 
 func (service *Service) CreateProduct(config *CreateObjectConfig) (*Product, *errortools.Error) {
+	return service.CreateProductWithContext(context.Background(), config)
+}
+
+func (service *Service) CreateProductWithContext(ctx context.Context, config *CreateObjectConfig) (*Product, *errortools.Error) {
 	endpoint := "objects/products"
 	product := Product{}
 
@@ -130,7 +136,7 @@ func (service *Service) CreateProduct(config *CreateObjectConfig) (*Product, *er
 		ResponseModel: &product,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -138,6 +144,9 @@ func (service *Service) CreateProduct(config *CreateObjectConfig) (*Product, *er
 	return &product, nil
 }
 
+// BatchProductsResponse is the response of a batch request of products
+//
+// Deprecated: the batch functions return a BatchResult
 type BatchProductsResponse struct {
 	CompletedAt *time.Time        `json:"completedAt"`
 	NumErrors   int               `json:"numErrors"`
@@ -166,71 +175,59 @@ type BatchProductsResponse struct {
 	Status string `json:"status"`
 }
 
+// BatchCreateProducts returns the created products, the inputs that failed are only logged
+//
+// Deprecated: use BatchCreateProductsWithResult, which also returns the inputs that failed
 func (service *Service) BatchCreateProducts(config *BatchObjectsConfig) (*[]Product, *errortools.Error) {
-	var products []Product
-
-	for _, batch := range service.batches(len(config.Inputs)) {
-		var r BatchProductsResponse
+	result, e := service.BatchCreateProductsWithResultWithContext(context.Background(), config)
+	if e != nil {
+		return nil, e
+	}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(fmt.Sprintf("objects/%s/batch/create", config.ObjectType)),
-			BodyModel:     BatchObjectsConfig{Inputs: config.Inputs[batch.startIndex:batch.endIndex]},
-			ResponseModel: &r,
-		}
+	return &result.Successes, nil
+}
 
-		_, response, e := service.httpRequest(&requestConfig)
-		if response != nil {
-			if response.StatusCode == http.StatusMultiStatus {
-				fmt.Println(r.Errors)
-				goto ok
-			}
-		}
-		if e != nil {
-			return nil, e
-		}
-	ok:
-		products = append(products, r.Results...)
+func (service *Service) BatchCreateProductsWithResult(config *BatchObjectsConfig) (*BatchResult[Product], *errortools.Error) {
+	return service.BatchCreateProductsWithResultWithContext(context.Background(), config)
+}
 
-		fmt.Println("batch", batch.startIndex)
+func (service *Service) BatchCreateProductsWithResultWithContext(ctx context.Context, config *BatchObjectsConfig) (*BatchResult[Product], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
 	}
 
-	return &products, nil
+	return batchObjects[Product](ctx, service, ObjectType(config.ObjectType), "create", config)
 }
 
+// BatchUpdateProducts returns the updated products, the inputs that failed are only logged
+//
+// Deprecated: use BatchUpdateProductsWithResult, which also returns the inputs that failed
 func (service *Service) BatchUpdateProducts(config *BatchObjectsConfig) (*[]Product, *errortools.Error) {
-	var products []Product
-
-	for _, batch := range service.batches(len(config.Inputs)) {
-		var r BatchProductsResponse
+	result, e := service.BatchUpdateProductsWithResultWithContext(context.Background(), config)
+	if e != nil {
+		return nil, e
+	}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(fmt.Sprintf("objects/%s/batch/update", config.ObjectType)),
-			BodyModel:     BatchObjectsConfig{Inputs: config.Inputs[batch.startIndex:batch.endIndex]},
-			ResponseModel: &r,
-		}
+	return &result.Successes, nil
+}
 
-		_, response, e := service.httpRequest(&requestConfig)
-		if response != nil {
-			if response.StatusCode == http.StatusMultiStatus {
-				fmt.Println(r.Errors)
-				goto ok
-			}
-		}
-		if e != nil {
-			return nil, e
-		}
-	ok:
-		products = append(products, r.Results...)
+func (service *Service) BatchUpdateProductsWithResult(config *BatchObjectsConfig) (*BatchResult[Product], *errortools.Error) {
+	return service.BatchUpdateProductsWithResultWithContext(context.Background(), config)
+}
 
-		fmt.Println("batch", batch.startIndex)
+func (service *Service) BatchUpdateProductsWithResultWithContext(ctx context.Context, config *BatchObjectsConfig) (*BatchResult[Product], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
 	}
 
-	return &products, nil
+	return batchObjects[Product](ctx, service, ObjectType(config.ObjectType), "update", config)
 }
 
 func (service *Service) UpdateProduct(config *UpdateObjectConfig) (*Product, *errortools.Error) {
+	return service.UpdateProductWithContext(context.Background(), config)
+}
+
+func (service *Service) UpdateProductWithContext(ctx context.Context, config *UpdateObjectConfig) (*Product, *errortools.Error) {
 	endpoint := "objects/products"
 	product := Product{}
 
@@ -241,7 +238,7 @@ func (service *Service) UpdateProduct(config *UpdateObjectConfig) (*Product, *er
 		ResponseModel: &product,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -250,16 +247,20 @@ func (service *Service) UpdateProduct(config *UpdateObjectConfig) (*Product, *er
 }
 
 func (service *Service) BatchArchiveProducts(productIds []string) *errortools.Error {
+	return service.BatchArchiveProductsWithContext(context.Background(), productIds)
+}
+
+func (service *Service) BatchArchiveProductsWithContext(ctx context.Context, productIds []string) *errortools.Error {
 	var maxItemsPerBatch = 100
 	var index = 0
 	for len(productIds) > index {
 		if len(productIds) > index+maxItemsPerBatch {
-			e := service.batchArchiveProducts(productIds[index : index+maxItemsPerBatch])
+			e := service.batchArchiveProducts(ctx, productIds[index:index+maxItemsPerBatch])
 			if e != nil {
 				return e
 			}
 		} else {
-			e := service.batchArchiveProducts(productIds[index:])
+			e := service.batchArchiveProducts(ctx, productIds[index:])
 			if e != nil {
 				return e
 			}
@@ -271,7 +272,7 @@ func (service *Service) BatchArchiveProducts(productIds []string) *errortools.Er
 	return nil
 }
 
-func (service *Service) batchArchiveProducts(productIds []string) *errortools.Error {
+func (service *Service) batchArchiveProducts(ctx context.Context, productIds []string) *errortools.Error {
 	var body struct {
 		Inputs []struct {
 			Id string `json:"id"`
@@ -290,6 +291,6 @@ func (service *Service) batchArchiveProducts(productIds []string) *errortools.Er
 		BodyModel: body,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	return e
 }
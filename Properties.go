@@ -1,6 +1,7 @@
 package hubspot
 
 import (
+	"context"
 	"fmt"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
@@ -75,6 +76,10 @@ type PropertyOption struct {
 
 // GetProperties returns all properties
 func (service *Service) GetProperties(object string) (*[]Property, *errortools.Error) {
+	return service.GetPropertiesWithContext(context.Background(), object)
+}
+
+func (service *Service) GetPropertiesWithContext(ctx context.Context, object string) (*[]Property, *errortools.Error) {
 	endpoint := "properties"
 	propertiesResponse := PropertiesResponse{}
 
@@ -84,7 +89,7 @@ func (service *Service) GetProperties(object string) (*[]Property, *errortools.E
 		ResponseModel: &propertiesResponse,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -94,6 +99,10 @@ func (service *Service) GetProperties(object string) (*[]Property, *errortools.E
 
 // CreateProperty creates a property
 func (service *Service) CreateProperty(object string, property *Property) (*Property, *errortools.Error) {
+	return service.CreatePropertyWithContext(context.Background(), object, property)
+}
+
+func (service *Service) CreatePropertyWithContext(ctx context.Context, object string, property *Property) (*Property, *errortools.Error) {
 	endpoint := "properties"
 	newProperty := Property{}
 
@@ -104,7 +113,7 @@ func (service *Service) CreateProperty(object string, property *Property) (*Prop
 		ResponseModel: &newProperty,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -114,6 +123,10 @@ func (service *Service) CreateProperty(object string, property *Property) (*Prop
 
 // UpdateProperty updates a property
 func (service *Service) UpdateProperty(object string, property *Property) (*Property, *errortools.Error) {
+	return service.UpdatePropertyWithContext(context.Background(), object, property)
+}
+
+func (service *Service) UpdatePropertyWithContext(ctx context.Context, object string, property *Property) (*Property, *errortools.Error) {
 	endpoint := "properties"
 	updatedProperty := Property{}
 
@@ -124,7 +137,7 @@ func (service *Service) UpdateProperty(object string, property *Property) (*Prop
 		ResponseModel: &updatedProperty,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -144,6 +157,10 @@ type PropertyGroup struct {
 
 // GetPropertyGroups returns all property groups
 func (service *Service) GetPropertyGroups(object string) (*[]PropertyGroup, *errortools.Error) {
+	return service.GetPropertyGroupsWithContext(context.Background(), object)
+}
+
+func (service *Service) GetPropertyGroupsWithContext(ctx context.Context, object string) (*[]PropertyGroup, *errortools.Error) {
 	propertyGroupsResponse := PropertyGroupsResponse{}
 
 	requestConfig := go_http.RequestConfig{
@@ -152,7 +169,7 @@ func (service *Service) GetPropertyGroups(object string) (*[]PropertyGroup, *err
 		ResponseModel: &propertyGroupsResponse,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -162,6 +179,10 @@ func (service *Service) GetPropertyGroups(object string) (*[]PropertyGroup, *err
 
 // CreatePropertyGroup creates a property group
 func (service *Service) CreatePropertyGroup(object string, propertyGroup *PropertyGroup) (*PropertyGroup, *errortools.Error) {
+	return service.CreatePropertyGroupWithContext(context.Background(), object, propertyGroup)
+}
+
+func (service *Service) CreatePropertyGroupWithContext(ctx context.Context, object string, propertyGroup *PropertyGroup) (*PropertyGroup, *errortools.Error) {
 	newPropertyGroup := PropertyGroup{}
 
 	requestConfig := go_http.RequestConfig{
@@ -171,7 +192,7 @@ func (service *Service) CreatePropertyGroup(object string, propertyGroup *Proper
 		ResponseModel: &newPropertyGroup,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -181,12 +202,16 @@ func (service *Service) CreatePropertyGroup(object string, propertyGroup *Proper
 
 // ArchiveProperty archives a property
 func (service *Service) ArchiveProperty(object string, propertyName string) *errortools.Error {
+	return service.ArchivePropertyWithContext(context.Background(), object, propertyName)
+}
+
+func (service *Service) ArchivePropertyWithContext(ctx context.Context, object string, propertyName string) *errortools.Error {
 	requestConfig := go_http.RequestConfig{
 		Method: http.MethodDelete,
 		Url:    service.urlCrm(fmt.Sprintf("properties/%s/%s", object, propertyName)),
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return e
 	}
@@ -196,12 +221,16 @@ func (service *Service) ArchiveProperty(object string, propertyName string) *err
 
 // ArchivePropertyGroup archives a property group
 func (service *Service) ArchivePropertyGroup(object string, propertyGroupName string) *errortools.Error {
+	return service.ArchivePropertyGroupWithContext(context.Background(), object, propertyGroupName)
+}
+
+func (service *Service) ArchivePropertyGroupWithContext(ctx context.Context, object string, propertyGroupName string) *errortools.Error {
 	requestConfig := go_http.RequestConfig{
 		Method: http.MethodDelete,
 		Url:    service.urlCrm(fmt.Sprintf("properties/%s/groups/%s", object, propertyGroupName)),
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return e
 	}
@@ -210,16 +239,20 @@ func (service *Service) ArchivePropertyGroup(object string, propertyGroupName st
 }
 
 func (service *Service) BatchArchiveProperties(object string, propertyNames []string) *errortools.Error {
+	return service.BatchArchivePropertiesWithContext(context.Background(), object, propertyNames)
+}
+
+func (service *Service) BatchArchivePropertiesWithContext(ctx context.Context, object string, propertyNames []string) *errortools.Error {
 	var maxItemsPerBatch = 100
 	var index = 0
 	for len(propertyNames) > index {
 		if len(propertyNames) > index+maxItemsPerBatch {
-			e := service.batchArchiveProperties(object, propertyNames[index:index+maxItemsPerBatch])
+			e := service.batchArchiveProperties(ctx, object, propertyNames[index:index+maxItemsPerBatch])
 			if e != nil {
 				return e
 			}
 		} else {
-			e := service.batchArchiveProperties(object, propertyNames[index:])
+			e := service.batchArchiveProperties(ctx, object, propertyNames[index:])
 			if e != nil {
 				return e
 			}
@@ -231,7 +264,7 @@ func (service *Service) BatchArchiveProperties(object string, propertyNames []st
 	return nil
 }
 
-func (service *Service) batchArchiveProperties(object string, propertyNames []string) *errortools.Error {
+func (service *Service) batchArchiveProperties(ctx context.Context, object string, propertyNames []string) *errortools.Error {
 	var body struct {
 		Inputs []struct {
 			Name string `json:"name"`
@@ -250,7 +283,7 @@ func (service *Service) batchArchiveProperties(object string, propertyNames []st
 		BodyModel: body,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	return e
 }
 
@@ -0,0 +1,361 @@
+package hubspot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	errortools "github.com/leapforce-libraries/go_errortools"
+)
+
+// propertiesTag maps a struct field to a HubSpot property, e.g.
+//
+//	type Deal struct {
+//		Name      string     `hubspot:"dealname"`
+//		Amount    *float64   `hubspot:"amount"`
+//		CloseDate *time.Time `hubspot:"closedate,date"`
+//		Tags      []string   `hubspot:"deal_tags,omitempty"`
+//	}
+//
+// Supported field types are string, bool, integers, floats, time.Time and []string, which is stored as a ;-separated enumeration,
+// or a pointer to one of these. A nil pointer marshals as an empty property, which clears it in HubSpot, and an empty property
+// unmarshals into a nil pointer.
+// An empty name, as in `hubspot:",omitempty"`, maps the field to the property named after the field in lower case.
+// The date option stores the calendar date of a time.Time in its own location instead of a datetime, dates unmarshal as midnight UTC.
+// The omitempty option skips nil pointers and zero values when marshalling, leaving the property as it is.
+const propertiesTag = "hubspot"
+
+const propertyDateTimeLayout = "2006-01-02T15:04:05.000Z"
+
+// TypedObject is an object with its properties decoded into struct P by the hubspot tags of P.
+// As T of Objects[T] the properties of P are requested by default, e.g.
+//
+//	deals := NewObjects[TypedObject[Deal]](service, ObjectTypeDeals)
+type TypedObject[P any] struct {
+	Id                    string
+	Properties            P
+	PropertiesWithHistory map[string][]PropertyHistory
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	Archived              bool
+	ArchivedAt            time.Time
+}
+
+func (object *TypedObject[P]) UnmarshalJSON(b []byte) error {
+	var o Object
+	if err := json.Unmarshal(b, &o); err != nil {
+		return err
+	}
+
+	*object = TypedObject[P]{
+		Id:                    o.Id,
+		PropertiesWithHistory: o.PropertiesWithHistory,
+		CreatedAt:             o.CreatedAt,
+		UpdatedAt:             o.UpdatedAt,
+		Archived:              o.Archived,
+		ArchivedAt:            o.ArchivedAt,
+	}
+
+	if e := UnmarshalProperties(o.Properties, &object.Properties); e != nil {
+		return errors.New(e.Message())
+	}
+
+	return nil
+}
+
+func (object TypedObject[P]) MarshalJSON() ([]byte, error) {
+	properties, e := MarshalProperties(object.Properties)
+	if e != nil {
+		return nil, errors.New(e.Message())
+	}
+
+	return json.Marshal(Object{
+		Id:                    object.Id,
+		Properties:            properties,
+		PropertiesWithHistory: object.PropertiesWithHistory,
+		CreatedAt:             object.CreatedAt,
+		UpdatedAt:             object.UpdatedAt,
+		Archived:              object.Archived,
+		ArchivedAt:            object.ArchivedAt,
+	})
+}
+
+func (TypedObject[P]) propertyNames() []string {
+	var properties P
+	return PropertyNames(properties)
+}
+
+// defaultProperties returns the properties to request for objects of type T if the caller passes none
+func defaultProperties[T any]() []string {
+	var object T
+	if typed, ok := any(object).(interface{ propertyNames() []string }); ok {
+		return typed.propertyNames()
+	}
+	return nil
+}
+
+type propertyField struct {
+	index     []int
+	name      string
+	date      bool
+	omitEmpty bool
+}
+
+var propertyFieldsCache sync.Map
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func propertyFields(t reflect.Type) []propertyField {
+	if fields, ok := propertyFieldsCache.Load(t); ok {
+		return fields.([]propertyField)
+	}
+
+	var fields []propertyField
+
+	for _, f := range reflect.VisibleFields(t) {
+		tag, ok := f.Tag.Lookup(propertiesTag)
+		if !ok || tag == "-" || !f.IsExported() {
+			continue
+		}
+
+		options := strings.Split(tag, ",")
+		field := propertyField{index: f.Index, name: options[0]}
+		if field.name == "" {
+			field.name = strings.ToLower(f.Name)
+		}
+		for _, option := range options[1:] {
+			switch option {
+			case "date":
+				field.date = true
+			case "omitempty":
+				field.omitEmpty = true
+			}
+		}
+		fields = append(fields, field)
+	}
+
+	propertyFieldsCache.Store(t, fields)
+
+	return fields
+}
+
+func structType(v any) (reflect.Type, bool) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t, t != nil && t.Kind() == reflect.Struct
+}
+
+// PropertyNames returns the properties mapped by the hubspot tags of struct v, for the properties parameter of get, list and search requests
+func PropertyNames(v any) []string {
+	t, ok := structType(v)
+	if !ok {
+		return nil
+	}
+
+	var names []string
+	for _, field := range propertyFields(t) {
+		names = append(names, field.name)
+	}
+
+	return names
+}
+
+// MarshalProperties returns the properties of struct v, for CreateObjectConfig, UpdateObjectConfig and BatchObjectInput
+func MarshalProperties(v any) (map[string]string, *errortools.Error) {
+	t, ok := structType(v)
+	if !ok {
+		return nil, errortools.ErrorMessage(fmt.Sprintf("cannot marshal properties of %T, it is not a struct", v))
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if !value.IsValid() {
+		return nil, errortools.ErrorMessage("cannot marshal properties of a nil pointer")
+	}
+
+	properties := make(map[string]string)
+
+	for _, field := range propertyFields(t) {
+		fieldValue := value.FieldByIndex(field.index)
+
+		if field.omitEmpty && fieldValue.IsZero() {
+			continue
+		}
+
+		if fieldValue.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() {
+				properties[field.name] = ""
+				continue
+			}
+			fieldValue = fieldValue.Elem()
+		}
+
+		s, err := formatProperty(fieldValue, field.date)
+		if err != nil {
+			return nil, errortools.ErrorMessage(fmt.Sprintf("property %s: %s", field.name, err.Error()))
+		}
+		properties[field.name] = s
+	}
+
+	return properties, nil
+}
+
+// UnmarshalProperties sets the fields of the struct v points to from properties, fields of missing properties are left untouched
+func UnmarshalProperties(properties map[string]string, v any) *errortools.Error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return errortools.ErrorMessage(fmt.Sprintf("cannot unmarshal properties into %T, it is not a pointer to a struct", v))
+	}
+	value = value.Elem()
+
+	for _, field := range propertyFields(value.Type()) {
+		s, ok := properties[field.name]
+		if !ok {
+			continue
+		}
+
+		fieldValue := value.FieldByIndex(field.index)
+
+		if s == "" {
+			fieldValue.SetZero()
+			continue
+		}
+
+		if fieldValue.Kind() == reflect.Pointer {
+			p := reflect.New(fieldValue.Type().Elem())
+			if err := parseProperty(s, p.Elem()); err != nil {
+				return errortools.ErrorMessage(fmt.Sprintf("property %s: %s", field.name, err.Error()))
+			}
+			fieldValue.Set(p)
+			continue
+		}
+
+		if err := parseProperty(s, fieldValue); err != nil {
+			return errortools.ErrorMessage(fmt.Sprintf("property %s: %s", field.name, err.Error()))
+		}
+	}
+
+	return nil
+}
+
+func formatProperty(v reflect.Value, date bool) (string, error) {
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return "", nil
+		}
+		if date {
+			return t.Format(time.DateOnly), nil
+		}
+		return t.UTC().Format(propertyDateTimeLayout), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.String {
+			values := make([]string, v.Len())
+			for i := range values {
+				values[i] = v.Index(i).String()
+			}
+			return strings.Join(values, ";"), nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
+
+func parseProperty(s string, v reflect.Value) error {
+	if v.Type() == timeType {
+		t, err := parsePropertyTime(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			// number properties may hold integral values as 12.0
+			f, err2 := strconv.ParseFloat(s, 64)
+			if err2 != nil || f != math.Trunc(f) || v.OverflowInt(int64(f)) {
+				return err
+			}
+			i = int64(f)
+		}
+		v.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			// number properties may hold integral values as 12.0
+			f, err2 := strconv.ParseFloat(s, 64)
+			if err2 != nil || f != math.Trunc(f) || f < 0 || f >= math.Ldexp(1, v.Type().Bits()) {
+				return err
+			}
+			i = uint64(f)
+		}
+		v.SetUint(i)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.String {
+			values := strings.Split(s, ";")
+			slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+			for i, value := range values {
+				slice.Index(i).SetString(value)
+			}
+			v.Set(slice)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// parsePropertyTime parses dates, datetimes and milliseconds since epoch, all of which HubSpot returns depending on the property
+func parsePropertyTime(s string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms).UTC(), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.DateOnly, s)
+}
@@ -0,0 +1,229 @@
+package hubspot
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+type testDeal struct {
+	Name      string     `hubspot:"dealname"`
+	Amount    *float64   `hubspot:"amount"`
+	Count     int64      `hubspot:"count"`
+	Closed    bool       `hubspot:"closed"`
+	CloseDate *time.Time `hubspot:"closedate,date"`
+	UpdatedAt time.Time  `hubspot:"updatedat"`
+	Tags      []string   `hubspot:"deal_tags,omitempty"`
+	Stage     string     `hubspot:",omitempty"`
+	Ignored   string
+}
+
+func TestPropertiesRoundTrip(t *testing.T) {
+	amount := 12.5
+	closeDate := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	deal := testDeal{
+		Name:      "deal",
+		Amount:    &amount,
+		Count:     3,
+		Closed:    true,
+		CloseDate: &closeDate,
+		UpdatedAt: time.Date(2024, 5, 1, 12, 30, 15, 123000000, time.UTC),
+		Tags:      []string{"a", "b"},
+		Stage:     "won",
+	}
+
+	properties, e := MarshalProperties(deal)
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+
+	expected := map[string]string{
+		"dealname":  "deal",
+		"amount":    "12.5",
+		"count":     "3",
+		"closed":    "true",
+		"closedate": "2024-05-01",
+		"updatedat": "2024-05-01T12:30:15.123Z",
+		"deal_tags": "a;b",
+		"stage":     "won",
+	}
+	if !reflect.DeepEqual(properties, expected) {
+		t.Errorf("expected %v, got %v", expected, properties)
+	}
+
+	var decoded testDeal
+	if e := UnmarshalProperties(properties, &decoded); e != nil {
+		t.Fatal(e.Message())
+	}
+	if !reflect.DeepEqual(decoded, deal) {
+		t.Errorf("expected %+v, got %+v", deal, decoded)
+	}
+}
+
+func TestPropertiesEmpty(t *testing.T) {
+	properties, e := MarshalProperties(&testDeal{})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	// nil pointers clear their property, empty omitempty fields are left out
+	for _, name := range []string{"amount", "closedate"} {
+		if value, ok := properties[name]; !ok || value != "" {
+			t.Errorf("expected %s to be empty, got %q", name, value)
+		}
+	}
+	for _, name := range []string{"deal_tags", "stage"} {
+		if _, ok := properties[name]; ok {
+			t.Errorf("expected %s to be left out", name)
+		}
+	}
+
+	type optionalDeal struct {
+		Amount *float64 `hubspot:"amount,omitempty"`
+	}
+	properties, e = MarshalProperties(optionalDeal{})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if _, ok := properties["amount"]; ok {
+		t.Errorf("expected a nil omitempty pointer to be left out")
+	}
+
+	amount := 1.0
+	deal := testDeal{Amount: &amount, Tags: []string{"a"}}
+	if e := UnmarshalProperties(map[string]string{"amount": "", "deal_tags": ""}, &deal); e != nil {
+		t.Fatal(e.Message())
+	}
+	if deal.Amount != nil || deal.Tags != nil {
+		t.Errorf("expected empty properties to unmarshal as nil, got %+v", deal)
+	}
+}
+
+func TestPropertiesIntegralFloats(t *testing.T) {
+	type counts struct {
+		Signed   int64   `hubspot:"signed"`
+		Unsigned uint8   `hubspot:"unsigned"`
+		Pointer  *uint64 `hubspot:"pointer"`
+	}
+
+	var decoded counts
+	if e := UnmarshalProperties(map[string]string{"signed": "-12.0", "unsigned": "12.0", "pointer": "3.0"}, &decoded); e != nil {
+		t.Fatal(e.Message())
+	}
+	if decoded.Signed != -12 || decoded.Unsigned != 12 || *decoded.Pointer != 3 {
+		t.Errorf("unexpected counts %+v", decoded)
+	}
+
+	for _, value := range []string{"12.5", "-1.0", "256.0"} {
+		if e := UnmarshalProperties(map[string]string{"unsigned": value}, &decoded); e == nil {
+			t.Errorf("expected an error unmarshalling %s into an uint8", value)
+		}
+	}
+}
+
+func TestPropertiesDateInLocation(t *testing.T) {
+	location := time.FixedZone("UTC+2", 2*60*60)
+	closeDate := time.Date(2024, 5, 1, 0, 0, 0, 0, location)
+
+	properties, e := MarshalProperties(testDeal{CloseDate: &closeDate})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if properties["closedate"] != "2024-05-01" {
+		t.Errorf("expected 2024-05-01, got %s", properties["closedate"])
+	}
+}
+
+func TestPropertyNames(t *testing.T) {
+	expected := []string{"dealname", "amount", "count", "closed", "closedate", "updatedat", "deal_tags", "stage"}
+	if names := PropertyNames(&testDeal{}); !slices.Equal(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestTypedObjects(t *testing.T) {
+	var query []string
+
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		query = append(query, r.URL.Query().Get("properties"))
+
+		switch r.URL.Path {
+		case "/crm/v3/objects/deals/1":
+			writeJson(w, http.StatusOK, `{"id":"1","properties":{"dealname":"deal","amount":"12.5","closedate":"2024-05-01T00:00:00Z","deal_tags":"a;b"}}`)
+		case "/crm/v3/objects/deals":
+			writeJson(w, http.StatusOK, `{"results":[{"id":"1","properties":{"dealname":"deal","count":"12.0"}}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}, nil)
+
+	deals := NewObjects[TypedObject[testDeal]](service, ObjectTypeDeals)
+
+	deal, e := deals.Get(&GetObjectConfig{ObjectId: "1"})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if deal.Id != "1" || deal.Properties.Name != "deal" || *deal.Properties.Amount != 12.5 || !slices.Equal(deal.Properties.Tags, []string{"a", "b"}) {
+		t.Errorf("unexpected deal %+v", deal)
+	}
+	if !deal.Properties.CloseDate.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected close date %s", deal.Properties.CloseDate)
+	}
+
+	list, e := deals.List(nil)
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if len(*list) != 1 || (*list)[0].Properties.Count != 12 {
+		t.Errorf("unexpected deals %+v", *list)
+	}
+
+	_, e = deals.Get(&GetObjectConfig{ObjectId: "1", Properties: &[]string{"dealname"}})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+
+	expected := []string{
+		"dealname,amount,count,closed,closedate,updatedat,deal_tags,stage",
+		"dealname,amount,count,closed,closedate,updatedat,deal_tags,stage",
+		"dealname",
+	}
+	if !slices.Equal(query, expected) {
+		t.Errorf("expected properties %v, got %v", expected, query)
+	}
+}
+
+func TestGoalProperties(t *testing.T) {
+	var query string
+
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("properties")
+		writeJson(w, http.StatusOK, `{"results":[{"id":"1","properties":{"hs_goal_name":"goal","hs_target_amount":"100","hs_start_datetime":"2024-05-01T00:00:00.000Z","hs_object_id":"1","hs_created_by_user_id":null}}]}`)
+	}, nil)
+
+	goals, e := service.GetGoals(nil)
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if query != "hs_created_by_user_id,hs_end_datetime,hs_goal_name,hs_start_datetime,hs_object_id,hs_target_amount" {
+		t.Errorf("unexpected properties %s", query)
+	}
+
+	properties := (*goals)[0].Properties
+	if *properties.GoalName != "goal" || properties.TargetAmount.Value() != 100 || properties.ObjectId.Value() != 1 || properties.CreatedByUserId != nil {
+		t.Errorf("unexpected goal properties %+v", properties)
+	}
+	if !properties.StartDateTime.Value().Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected start %s", properties.StartDateTime.Value())
+	}
+
+	goal := (*goals)[0].TypedProperties
+	if *goal.GoalName != "goal" || *goal.TargetAmount != 100 || *goal.ObjectId != 1 || goal.CreatedByUserId != nil {
+		t.Errorf("unexpected goal %+v", goal)
+	}
+	if !goal.StartDateTime.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected start %s", goal.StartDateTime)
+	}
+}
@@ -0,0 +1,142 @@
+package hubspot
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	errortools "github.com/leapforce-libraries/go_errortools"
+)
+
+const defaultRateLimitInterval time.Duration = 10 * time.Second
+
+var ErrDailyLimitReached = errors.New("hubspot daily api limit reached")
+
+// RateLimiterConfig holds the quotas of a HubSpot app, see https://developers.hubspot.com/docs/api/usage-details
+type RateLimiterConfig struct {
+	Burst      int            // requests allowed per Interval, e.g. 100 or 190
+	Interval   time.Duration  // defaults to 10 seconds
+	DailyLimit int            // requests allowed per day, 0 means not enforced client side
+	Location   *time.Location // timezone of the portal, the daily limit resets at its midnight, defaults to UTC
+}
+
+// RateLimiter is a token bucket that throttles requests before they are sent.
+// It is safe for concurrent use; pass the same RateLimiter to every Service that uses the same app credentials.
+type RateLimiter struct {
+	mutex      sync.Mutex
+	capacity   float64
+	tokens     float64
+	rate       float64 // tokens per second
+	lastRefill time.Time
+	dailyLimit int
+	dailyUsed  int
+	day        string
+	location   *time.Location
+}
+
+func NewRateLimiter(config *RateLimiterConfig) (*RateLimiter, *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("RateLimiterConfig must not be a nil pointer")
+	}
+	if config.Burst <= 0 {
+		return nil, errortools.ErrorMessage("Burst must be greater than zero")
+	}
+
+	interval := defaultRateLimitInterval
+	if config.Interval > 0 {
+		interval = config.Interval
+	}
+
+	location := time.UTC
+	if config.Location != nil {
+		location = config.Location
+	}
+
+	return &RateLimiter{
+		capacity:   float64(config.Burst),
+		tokens:     float64(config.Burst),
+		rate:       float64(config.Burst) / interval.Seconds(),
+		lastRefill: time.Now(),
+		dailyLimit: config.DailyLimit,
+		location:   location,
+	}, nil
+}
+
+// refill must be called with mutex held
+func (l *RateLimiter) refill(now time.Time) {
+	l.tokens = math.Min(l.capacity, l.tokens+now.Sub(l.lastRefill).Seconds()*l.rate)
+	l.lastRefill = now
+
+	day := now.In(l.location).Format(time.DateOnly)
+	if day != l.day {
+		l.day = day
+		l.dailyUsed = 0
+	}
+}
+
+// Wait blocks until a request may be sent or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	for {
+		l.mutex.Lock()
+		l.refill(time.Now())
+
+		if l.dailyLimit > 0 && l.dailyUsed >= l.dailyLimit {
+			l.mutex.Unlock()
+			return ErrDailyLimitReached
+		}
+
+		if l.tokens >= 1 {
+			l.tokens--
+			l.dailyUsed++
+			l.mutex.Unlock()
+			return nil
+		}
+
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mutex.Unlock()
+
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// update adapts the bucket to the rate limit headers HubSpot returns with every response
+func (l *RateLimiter) update(header http.Header) {
+	if l == nil || header == nil {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.refill(time.Now())
+
+	if limit, err := strconv.Atoi(header.Get("X-HubSpot-RateLimit-Max")); err == nil && limit > 0 {
+		interval := time.Duration(l.capacity / l.rate * float64(time.Second))
+		if ms, err := strconv.Atoi(header.Get("X-HubSpot-RateLimit-Interval-Milliseconds")); err == nil && ms > 0 {
+			interval = time.Duration(ms) * time.Millisecond
+		}
+		l.capacity = float64(limit)
+		l.rate = float64(limit) / interval.Seconds()
+	}
+
+	if remaining, err := strconv.Atoi(header.Get("X-HubSpot-RateLimit-Remaining")); err == nil && remaining >= 0 {
+		// other clients may use the same app, so trust the server when it reports less than we think
+		l.tokens = math.Min(l.tokens, float64(remaining))
+	}
+
+	if l.dailyLimit > 0 {
+		if remaining, err := strconv.Atoi(header.Get("X-HubSpot-RateLimit-Daily-Remaining")); err == nil && remaining >= 0 {
+			l.dailyUsed = max(l.dailyUsed, l.dailyLimit-remaining)
+		}
+	}
+}
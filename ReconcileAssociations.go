@@ -0,0 +1,298 @@
+package hubspot
+
+import (
+	"context"
+	"slices"
+	"strconv"
+
+	errortools "github.com/leapforce-libraries/go_errortools"
+)
+
+// DesiredAssociation is an association ReconcileAssociations makes sure exists
+type DesiredAssociation struct {
+	FromId string
+	ToId   string
+	Types  []AssociationTypeV4 // labels the association must have, none for an unlabelled association
+}
+
+type ReconcileAssociationsConfig struct {
+	FromObjectType string
+	ToObjectType   string
+	Desired        []DesiredAssociation
+	FromIds        []string // objects whose associations are reconciled besides those in Desired, e.g. to remove all associations of an object
+	DryRun         *bool    // only report the changes
+	// RemoveHubSpotDefinedLabels also removes labels HubSpot defines, such as Primary, from desired associations that do not list them.
+	// By default these are left alone, so reconciling unlabelled associations does not change for instance the primary company of a contact.
+	RemoveHubSpotDefinedLabels *bool
+}
+
+// ReconcileAssociationsReport lists the changes ReconcileAssociations made, or would make if DryRun is set.
+// Changes HubSpot rejected in a partially failed batch are listed in Failures as well.
+// The changes are applied in the order create, associate/default, labels/archive and archive; Applied lists the actions that completed.
+// If ReconcileAssociations returns an error with the report, the changes of the actions not in Applied were not or only partly made.
+type ReconcileAssociationsReport struct {
+	Additions    []AssociationChange  `json:"additions"`
+	LabelChanges []AssociationChange  `json:"labelChanges"`
+	Removals     []AssociationChange  `json:"removals"`
+	Failures     []AssociationFailure `json:"failures"`
+	Applied      []string             `json:"applied"`
+	Unchanged    int                  `json:"unchanged"`
+	DryRun       bool                 `json:"dryRun"`
+}
+
+type AssociationChange struct {
+	FromId      string              `json:"fromId"`
+	ToId        string              `json:"toId"`
+	AddTypes    []AssociationTypeV4 `json:"addTypes,omitempty"`
+	RemoveTypes []AssociationTypeV4 `json:"removeTypes,omitempty"`
+}
+
+// AssociationFailure is a change of the associations from FromId to ToId that failed.
+// FromId and ToId are empty if HubSpot did not identify the association.
+type AssociationFailure struct {
+	FromId  string       `json:"fromId,omitempty"`
+	ToId    string       `json:"toId,omitempty"`
+	Action  string       `json:"action"` // create, associate/default, labels/archive or archive
+	Failure BatchFailure `json:"failure"`
+}
+
+type associationEdge struct {
+	fromId string
+	toId   string
+}
+
+// ReconcileAssociations makes the associations of the objects in config.Desired and config.FromIds equal to config.Desired.
+// Missing associations are created, labels are added and removed, and associations that are not desired are removed, all in batches of 100.
+// An error while applying the changes is returned together with the report of what was applied so far.
+func (service *Service) ReconcileAssociations(config *ReconcileAssociationsConfig) (*ReconcileAssociationsReport, *errortools.Error) {
+	return service.ReconcileAssociationsWithContext(context.Background(), config)
+}
+
+func (service *Service) ReconcileAssociationsWithContext(ctx context.Context, config *ReconcileAssociationsConfig) (*ReconcileAssociationsReport, *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
+	}
+
+	report, e := service.planAssociations(ctx, config)
+	if e != nil {
+		return nil, e
+	}
+
+	service.logger.Info("reconciling associations", "fromObjectType", config.FromObjectType, "toObjectType", config.ToObjectType, "additions", len(report.Additions), "labelChanges", len(report.LabelChanges), "removals", len(report.Removals), "dryRun", report.DryRun)
+
+	if report.DryRun {
+		return report, nil
+	}
+
+	e = service.applyAssociations(ctx, config, report)
+	if e != nil {
+		service.logger.Warn("reconciling associations stopped", "fromObjectType", config.FromObjectType, "toObjectType", config.ToObjectType, "applied", report.Applied, "message", e.Message())
+		return report, e
+	}
+
+	if len(report.Failures) > 0 {
+		service.logger.Warn("associations not reconciled", "fromObjectType", config.FromObjectType, "toObjectType", config.ToObjectType, "failures", len(report.Failures))
+	}
+
+	return report, nil
+}
+
+// planAssociations compares config.Desired with the current associations
+func (service *Service) planAssociations(ctx context.Context, config *ReconcileAssociationsConfig) (*ReconcileAssociationsReport, *errortools.Error) {
+	report := ReconcileAssociationsReport{DryRun: config.DryRun != nil && *config.DryRun}
+	removeHubSpotDefined := config.RemoveHubSpotDefinedLabels != nil && *config.RemoveHubSpotDefinedLabels
+
+	desired := make(map[associationEdge][]AssociationTypeV4)
+	var desiredEdges []associationEdge
+
+	fromIds := slices.Clone(config.FromIds)
+
+	for _, association := range config.Desired {
+		edge := associationEdge{association.FromId, association.ToId}
+
+		types, ok := desired[edge]
+		if !ok {
+			desiredEdges = append(desiredEdges, edge)
+			fromIds = append(fromIds, association.FromId)
+		}
+		for _, t := range association.Types {
+			if !slices.Contains(types, t) {
+				types = append(types, t)
+			}
+		}
+		desired[edge] = types
+	}
+
+	slices.Sort(fromIds)
+	fromIds = slices.Compact(fromIds)
+
+	current := make(map[associationEdge][]AssociationLabel)
+	var currentEdges []associationEdge
+
+	if len(fromIds) > 0 {
+		inputs := make([]BatchGetAssociationsInput, len(fromIds))
+		for i, fromId := range fromIds {
+			inputs[i] = BatchGetAssociationsInput{Id: fromId}
+		}
+
+		associationsV4Set, e := service.BatchGetAssociationsWithContext(ctx, &BatchGetAssociationsConfig{
+			FromObjectType: config.FromObjectType,
+			ToObjectType:   config.ToObjectType,
+			Inputs:         inputs,
+		})
+		if e != nil {
+			return nil, e
+		}
+
+		for _, associationV4 := range associationsV4Set.Results {
+			for _, to := range associationV4.To {
+				edge := associationEdge{associationV4.From.Id, strconv.FormatInt(to.ToObjectId, 10)}
+				if _, ok := current[edge]; !ok {
+					currentEdges = append(currentEdges, edge)
+				}
+				current[edge] = append(current[edge], to.AssociationTypes...)
+			}
+		}
+	}
+
+	for _, edge := range desiredEdges {
+		types := desired[edge]
+
+		labels, ok := current[edge]
+		if !ok {
+			report.Additions = append(report.Additions, AssociationChange{FromId: edge.fromId, ToId: edge.toId, AddTypes: types})
+			continue
+		}
+
+		change := AssociationChange{FromId: edge.fromId, ToId: edge.toId}
+		for _, t := range types {
+			if !slices.ContainsFunc(labels, func(label AssociationLabel) bool { return label.AssociationTypeV4() == t }) {
+				change.AddTypes = append(change.AddTypes, t)
+			}
+		}
+		// the unlabelled association type is always there
+		for _, label := range labels {
+			if label.Label == nil || *label.Label == "" || slices.Contains(types, label.AssociationTypeV4()) {
+				continue
+			}
+			if label.Category == AssociationCategoryHubSpotDefined && !removeHubSpotDefined {
+				continue
+			}
+			change.RemoveTypes = append(change.RemoveTypes, label.AssociationTypeV4())
+		}
+
+		if len(change.AddTypes) == 0 && len(change.RemoveTypes) == 0 {
+			report.Unchanged++
+			continue
+		}
+		report.LabelChanges = append(report.LabelChanges, change)
+	}
+
+	for _, edge := range currentEdges {
+		if _, ok := desired[edge]; ok {
+			continue
+		}
+
+		change := AssociationChange{FromId: edge.fromId, ToId: edge.toId}
+		for _, label := range current[edge] {
+			change.RemoveTypes = append(change.RemoveTypes, label.AssociationTypeV4())
+		}
+		report.Removals = append(report.Removals, change)
+	}
+
+	return &report, nil
+}
+
+// applyAssociations makes the changes of report, adds the changes that failed to report.Failures and the completed actions to report.Applied
+func (service *Service) applyAssociations(ctx context.Context, config *ReconcileAssociationsConfig, report *ReconcileAssociationsReport) *errortools.Error {
+	var createInputs []BatchCreateAssociationsInput
+	var defaultInputs []BatchDefaultAssociationsInput
+	var archiveLabelsInputs []BatchCreateAssociationsInput
+
+	for _, change := range report.Additions {
+		if len(change.AddTypes) == 0 {
+			defaultInputs = append(defaultInputs, BatchDefaultAssociationsInput{From: AssociationId{Id: change.FromId}, To: AssociationId{Id: change.ToId}})
+			continue
+		}
+		createInputs = append(createInputs, BatchCreateAssociationsInput{Types: change.AddTypes, From: AssociationId{Id: change.FromId}, To: AssociationId{Id: change.ToId}})
+	}
+
+	for _, change := range report.LabelChanges {
+		if len(change.AddTypes) > 0 {
+			createInputs = append(createInputs, BatchCreateAssociationsInput{Types: change.AddTypes, From: AssociationId{Id: change.FromId}, To: AssociationId{Id: change.ToId}})
+		}
+		if len(change.RemoveTypes) > 0 {
+			archiveLabelsInputs = append(archiveLabelsInputs, BatchCreateAssociationsInput{Types: change.RemoveTypes, From: AssociationId{Id: change.FromId}, To: AssociationId{Id: change.ToId}})
+		}
+	}
+
+	var archiveInputs []BatchArchiveAssociationsInput
+	for _, change := range report.Removals {
+		if len(archiveInputs) == 0 || archiveInputs[len(archiveInputs)-1].From.Id != change.FromId {
+			archiveInputs = append(archiveInputs, BatchArchiveAssociationsInput{From: AssociationId{Id: change.FromId}})
+		}
+		input := &archiveInputs[len(archiveInputs)-1]
+		input.To = append(input.To, AssociationId{Id: change.ToId})
+	}
+
+	created, e := service.BatchCreateAssociationsWithResultWithContext(ctx, &BatchCreateAssociationsConfig{
+		FromObjectType: config.FromObjectType,
+		ToObjectType:   config.ToObjectType,
+		Inputs:         createInputs,
+	})
+	if e != nil {
+		return e
+	}
+	addFailures(report, "create", created.Failures, createInputs)
+	report.Applied = append(report.Applied, "create")
+
+	createdDefault, e := service.BatchCreateDefaultAssociationsWithContext(ctx, &BatchCreateDefaultAssociationsConfig{
+		FromObjectType: config.FromObjectType,
+		ToObjectType:   config.ToObjectType,
+		Inputs:         defaultInputs,
+	})
+	if e != nil {
+		return e
+	}
+	addFailures(report, "associate/default", createdDefault.Failures, defaultInputs)
+	report.Applied = append(report.Applied, "associate/default")
+
+	failures, e := service.BatchArchiveAssociationLabelsWithContext(ctx, &BatchArchiveAssociationLabelsConfig{
+		FromObjectType: config.FromObjectType,
+		ToObjectType:   config.ToObjectType,
+		Inputs:         archiveLabelsInputs,
+	})
+	if e != nil {
+		return e
+	}
+	addFailures(report, "labels/archive", failures, archiveLabelsInputs)
+	report.Applied = append(report.Applied, "labels/archive")
+
+	failures, e = service.BatchArchiveAssociationsWithFailuresWithContext(ctx, &BatchArchiveAssociationsConfig{
+		FromObjectType: config.FromObjectType,
+		ToObjectType:   config.ToObjectType,
+		Inputs:         archiveInputs,
+	})
+	if e != nil {
+		return e
+	}
+	addFailures(report, "archive", failures, archiveInputs)
+	report.Applied = append(report.Applied, "archive")
+
+	return nil
+}
+
+// addFailures adds a failure for every association of the failed inputs of action
+func addFailures[I associationInput](report *ReconcileAssociationsReport, action string, failures []BatchFailure, inputs []I) {
+	for _, failure := range failures {
+		if failure.InputIndex < 0 || failure.InputIndex >= len(inputs) {
+			report.Failures = append(report.Failures, AssociationFailure{Action: action, Failure: failure})
+			continue
+		}
+
+		fromId, toIds := inputs[failure.InputIndex].keys()
+		for _, toId := range toIds {
+			report.Failures = append(report.Failures, AssociationFailure{FromId: fromId, ToId: toId, Action: action, Failure: failure})
+		}
+	}
+}
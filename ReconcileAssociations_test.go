@@ -0,0 +1,186 @@
+package hubspot
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// reconcileServer holds the associations of contacts 1 and 2 to companies, and records the writes.
+// The write of action fail is rejected as a whole.
+type reconcileServer struct {
+	mutex  sync.Mutex
+	writes []string
+	fail   string
+}
+
+func (server *reconcileServer) handle(w http.ResponseWriter, r *http.Request) {
+	action := strings.TrimPrefix(r.URL.Path, "/crm/v4/associations/contacts/companies/batch/")
+
+	if action == "read" {
+		writeJson(w, http.StatusOK, `{"results":[{"from":{"id":"1"},"to":[
+			{"toObjectId":10,"associationTypes":[{"category":"HUBSPOT_DEFINED","typeId":279,"label":null},{"category":"HUBSPOT_DEFINED","typeId":1,"label":"Primary"},{"category":"USER_DEFINED","typeId":5,"label":"Manager"}]},
+			{"toObjectId":11,"associationTypes":[{"category":"HUBSPOT_DEFINED","typeId":279,"label":null}]}
+		]}]}`)
+		return
+	}
+
+	server.mutex.Lock()
+	server.writes = append(server.writes, action)
+	server.mutex.Unlock()
+
+	switch action {
+	case server.fail:
+		writeJson(w, http.StatusBadRequest, `{"status":"error","message":"invalid input","category":"VALIDATION_ERROR"}`)
+	case "create":
+		writeJson(w, http.StatusMultiStatus, `{"results":[],"errors":[{"status":"error","category":"VALIDATION_ERROR","message":"No contacts with ids 2","context":{"fromObjectId":["2"]}}]}`)
+	case "associate/default":
+		writeJson(w, http.StatusOK, `{"results":[{"from":{"id":"1"},"to":{"id":"12"},"associationSpec":{"associationCategory":"HUBSPOT_DEFINED","associationTypeId":279}}]}`)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+var manager = AssociationTypeV4{AssociationCategory: "USER_DEFINED", AssociationTypeId: 5}
+
+func reconcileConfig(dryRun bool) *ReconcileAssociationsConfig {
+	return &ReconcileAssociationsConfig{
+		FromObjectType: "contacts",
+		ToObjectType:   "companies",
+		Desired: []DesiredAssociation{
+			{FromId: "1", ToId: "10"},
+			{FromId: "1", ToId: "12"},
+			{FromId: "2", ToId: "20", Types: []AssociationTypeV4{manager}},
+		},
+		DryRun: &dryRun,
+	}
+}
+
+func TestReconcileAssociationsPlan(t *testing.T) {
+	var server reconcileServer
+	service := newTestService(t, server.handle, nil)
+
+	report, e := service.ReconcileAssociations(reconcileConfig(true))
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+
+	if len(server.writes) != 0 {
+		t.Errorf("expected a dry run not to write, got %v", server.writes)
+	}
+	if !report.DryRun || report.Unchanged != 0 {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	if len(report.Additions) != 2 ||
+		report.Additions[0].FromId != "1" || report.Additions[0].ToId != "12" || len(report.Additions[0].AddTypes) != 0 ||
+		report.Additions[1].FromId != "2" || report.Additions[1].ToId != "20" || !slices.Equal(report.Additions[1].AddTypes, []AssociationTypeV4{manager}) {
+		t.Errorf("unexpected additions %+v", report.Additions)
+	}
+	if len(report.LabelChanges) != 1 || report.LabelChanges[0].ToId != "10" || !slices.Equal(report.LabelChanges[0].RemoveTypes, []AssociationTypeV4{manager}) {
+		t.Errorf("unexpected label changes %+v", report.LabelChanges)
+	}
+	if len(report.Removals) != 1 || report.Removals[0].ToId != "11" {
+		t.Errorf("unexpected removals %+v", report.Removals)
+	}
+}
+
+func TestReconcileAssociationsUnchanged(t *testing.T) {
+	var server reconcileServer
+	service := newTestService(t, server.handle, nil)
+
+	report, e := service.ReconcileAssociations(&ReconcileAssociationsConfig{
+		FromObjectType: "contacts",
+		ToObjectType:   "companies",
+		Desired: []DesiredAssociation{
+			{FromId: "1", ToId: "10", Types: []AssociationTypeV4{manager}},
+			{FromId: "1", ToId: "11"},
+		},
+	})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+
+	if report.Unchanged != 2 || len(report.Additions)+len(report.LabelChanges)+len(report.Removals) != 0 || len(server.writes) != 0 {
+		t.Errorf("expected nothing to change, got %+v and writes %v", report, server.writes)
+	}
+}
+
+func TestReconcileAssociationsFailures(t *testing.T) {
+	var server reconcileServer
+	service := newTestService(t, server.handle, nil)
+
+	report, e := service.ReconcileAssociations(reconcileConfig(false))
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+
+	slices.Sort(server.writes)
+	if !slices.Equal(server.writes, []string{"archive", "associate/default", "create", "labels/archive"}) {
+		t.Errorf("unexpected writes %v", server.writes)
+	}
+
+	if len(report.Failures) != 1 {
+		t.Fatalf("expected 1 failure, got %+v", report.Failures)
+	}
+	if failure := report.Failures[0]; failure.FromId != "2" || failure.ToId != "20" || failure.Action != "create" || failure.Failure.Message != "No contacts with ids 2" {
+		t.Errorf("unexpected failure %+v", failure)
+	}
+	if !slices.Equal(report.Applied, []string{"create", "associate/default", "labels/archive", "archive"}) {
+		t.Errorf("unexpected applied actions %v", report.Applied)
+	}
+}
+
+func TestReconcileAssociationsStopped(t *testing.T) {
+	server := reconcileServer{fail: "labels/archive"}
+	service := newTestService(t, server.handle, nil)
+
+	report, e := service.ReconcileAssociations(reconcileConfig(false))
+	if e == nil {
+		t.Fatal("expected an error")
+	}
+	if report == nil {
+		t.Fatal("expected the report of the changes applied before the error")
+	}
+
+	if !slices.Equal(report.Applied, []string{"create", "associate/default"}) {
+		t.Errorf("unexpected applied actions %v", report.Applied)
+	}
+	if len(report.Failures) != 1 || report.Failures[0].Action != "create" {
+		t.Errorf("unexpected failures %+v", report.Failures)
+	}
+	if slices.Contains(server.writes, "archive") {
+		t.Errorf("expected no writes after the error, got %v", server.writes)
+	}
+}
+
+func TestReconcileAssociationsHubSpotDefinedLabels(t *testing.T) {
+	primary := AssociationTypeV4{AssociationCategory: AssociationCategoryHubSpotDefined, AssociationTypeId: 1}
+
+	for _, remove := range []bool{false, true} {
+		var server reconcileServer
+		service := newTestService(t, server.handle, nil)
+
+		dryRun := true
+		report, e := service.ReconcileAssociations(&ReconcileAssociationsConfig{
+			FromObjectType:             "contacts",
+			ToObjectType:               "companies",
+			Desired:                    []DesiredAssociation{{FromId: "1", ToId: "10"}, {FromId: "1", ToId: "11"}},
+			DryRun:                     &dryRun,
+			RemoveHubSpotDefinedLabels: &remove,
+		})
+		if e != nil {
+			t.Fatal(e.Message())
+		}
+
+		want := []AssociationTypeV4{manager}
+		if remove {
+			want = []AssociationTypeV4{primary, manager}
+		}
+		if len(report.LabelChanges) != 1 || !slices.Equal(report.LabelChanges[0].RemoveTypes, want) {
+			t.Errorf("remove %v: got label changes %+v, want to remove %v", remove, report.LabelChanges, want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package hubspot
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts    int           = 5
+	defaultRetryInitialBackoff time.Duration = 500 * time.Millisecond
+	defaultRetryMaxBackoff     time.Duration = 30 * time.Second
+)
+
+// RetryPolicy controls how failed requests are retried.
+// A 429 is retried for every method, since HubSpot rejects the request before processing it.
+// 5xx responses and network errors are only retried for idempotent requests
+// (GET, HEAD, OPTIONS, PUT, DELETE, searches and batch reads), unless RetryNonIdempotent is set.
+type RetryPolicy struct {
+	MaxAttempts        int           // including the first attempt, defaults to 5, 1 disables retries
+	InitialBackoff     time.Duration // defaults to 500ms, doubled after every attempt
+	MaxBackoff         time.Duration // defaults to 30s
+	RetryStatusCodes   []int         // defaults to 429, 500, 502, 503 and 504
+	RetryNonIdempotent bool
+	OnRetry            func(event RetryEvent)
+}
+
+// RetryEvent is passed to RetryPolicy.OnRetry before waiting for the next attempt
+type RetryEvent struct {
+	Method     string
+	Url        string
+	Attempt    int // the attempt that failed, starting at 1
+	StatusCode int // 0 for network errors
+	Wait       time.Duration
+	Message    string
+}
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p == nil || p.MaxAttempts <= 0 {
+		return defaultRetryMaxAttempts
+	}
+	return p.MaxAttempts
+}
+
+func (p *RetryPolicy) isRetryStatusCode(statusCode int) bool {
+	statusCodes := []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	if p != nil && p.RetryStatusCodes != nil {
+		statusCodes = p.RetryStatusCodes
+	}
+
+	for _, s := range statusCodes {
+		if s == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// isIdempotent reports whether a request can be sent twice without side effects
+func isIdempotent(method string, url string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		path := strings.SplitN(url, "?", 2)[0]
+		return strings.HasSuffix(path, "/search") || strings.HasSuffix(path, "/batch/read")
+	}
+	return false
+}
+
+// backoff returns how long to wait before the next attempt, and whether to retry at all
+func (p *RetryPolicy) backoff(attempt int, method string, url string, response *http.Response) (time.Duration, bool) {
+	if attempt >= p.maxAttempts() {
+		return 0, false
+	}
+
+	if response == nil {
+		// network error
+		if !isIdempotent(method, url) && (p == nil || !p.RetryNonIdempotent) {
+			return 0, false
+		}
+		return p.exponentialBackoff(attempt), true
+	}
+
+	if !p.isRetryStatusCode(response.StatusCode) {
+		return 0, false
+	}
+
+	if response.StatusCode == http.StatusTooManyRequests {
+		if response.Header.Get("X-HubSpot-RateLimit-Daily-Remaining") == "0" {
+			// daily limit reached, retrying is pointless
+			return 0, false
+		}
+		if wait, ok := retryAfter(response.Header); ok {
+			return wait, true
+		}
+		if ms, err := strconv.Atoi(response.Header.Get("X-HubSpot-RateLimit-Interval-Milliseconds")); err == nil && ms > 0 {
+			return time.Duration(ms) * time.Millisecond, true
+		}
+		return p.exponentialBackoff(attempt), true
+	}
+
+	if !isIdempotent(method, url) && (p == nil || !p.RetryNonIdempotent) {
+		return 0, false
+	}
+
+	if wait, ok := retryAfter(response.Header); ok {
+		return wait, true
+	}
+	return p.exponentialBackoff(attempt), true
+}
+
+// exponentialBackoff returns a random duration between half and the full backoff for attempt ("equal jitter")
+func (p *RetryPolicy) exponentialBackoff(attempt int) time.Duration {
+	initial, max := defaultRetryInitialBackoff, defaultRetryMaxBackoff
+	if p != nil {
+		if p.InitialBackoff > 0 {
+			initial = p.InitialBackoff
+		}
+		if p.MaxBackoff > 0 {
+			max = p.MaxBackoff
+		}
+	}
+
+	backoff := initial
+	for i := 1; i < attempt && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		backoff = max
+	}
+
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryAfter parses the Retry-After header, which holds either seconds or an http date
+func retryAfter(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		wait := time.Until(t)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
+
+// sleep waits for d, or returns the context error as soon as ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
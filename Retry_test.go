@@ -0,0 +1,112 @@
+package hubspot
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *RetryPolicy
+		attempt int
+		max     time.Duration // the backoff before jitter
+	}{
+		{"default first attempt", nil, 1, 500 * time.Millisecond},
+		{"default third attempt", nil, 3, 2 * time.Second},
+		{"default capped", nil, 10, 30 * time.Second},
+		{"custom", &RetryPolicy{InitialBackoff: 100 * time.Millisecond}, 4, 800 * time.Millisecond},
+		{"custom capped", &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}, 4, 300 * time.Millisecond},
+		{"large attempt", nil, 1000, 30 * time.Second},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var shortest, longest time.Duration = test.max, 0
+			for range 1000 {
+				wait := test.policy.exponentialBackoff(test.attempt)
+				if wait < test.max/2 || wait > test.max {
+					t.Fatalf("expected a backoff between %s and %s, got %s", test.max/2, test.max, wait)
+				}
+				shortest, longest = min(shortest, wait), max(longest, wait)
+			}
+			// equal jitter spreads the backoffs over the upper half
+			if longest-shortest < test.max/4 {
+				t.Errorf("expected jitter, got backoffs between %s and %s", shortest, longest)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	response := func(statusCode int, header map[string]string) *http.Response {
+		r := http.Response{StatusCode: statusCode, Header: http.Header{}}
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		return &r
+	}
+
+	tests := []struct {
+		name     string
+		policy   *RetryPolicy
+		attempt  int
+		method   string
+		url      string
+		response *http.Response
+		retry    bool
+		wait     time.Duration // -1 for an exponential backoff
+	}{
+		{"429 retry after", nil, 1, http.MethodPost, "/crm/v3/objects/contacts", response(429, map[string]string{"Retry-After": "3"}), true, 3 * time.Second},
+		{"429 interval", nil, 1, http.MethodPost, "/crm/v3/objects/contacts", response(429, map[string]string{"X-HubSpot-RateLimit-Interval-Milliseconds": "10000"}), true, 10 * time.Second},
+		{"429 daily limit", nil, 1, http.MethodGet, "/crm/v3/objects/contacts", response(429, map[string]string{"X-HubSpot-RateLimit-Daily-Remaining": "0"}), false, 0},
+		{"429 without headers", nil, 1, http.MethodGet, "/crm/v3/objects/contacts", response(429, nil), true, -1},
+		{"503 get", nil, 1, http.MethodGet, "/crm/v3/objects/contacts", response(503, nil), true, -1},
+		{"503 create", nil, 1, http.MethodPost, "/crm/v3/objects/contacts", response(503, nil), false, 0},
+		{"503 create non idempotent", &RetryPolicy{RetryNonIdempotent: true}, 1, http.MethodPost, "/crm/v3/objects/contacts", response(503, nil), true, -1},
+		{"503 search", nil, 1, http.MethodPost, "/crm/v3/objects/contacts/search?x=1", response(503, nil), true, -1},
+		{"503 batch read", nil, 1, http.MethodPost, "/crm/v3/objects/contacts/batch/read", response(503, nil), true, -1},
+		{"400", nil, 1, http.MethodGet, "/crm/v3/objects/contacts", response(400, nil), false, 0},
+		{"custom status codes", &RetryPolicy{RetryStatusCodes: []int{400}}, 1, http.MethodGet, "/crm/v3/objects/contacts", response(400, nil), true, -1},
+		{"network error get", nil, 1, http.MethodGet, "/crm/v3/objects/contacts", nil, true, -1},
+		{"network error create", nil, 1, http.MethodPost, "/crm/v3/objects/contacts", nil, false, 0},
+		{"last attempt", nil, 5, http.MethodGet, "/crm/v3/objects/contacts", response(503, nil), false, 0},
+		{"single attempt", &RetryPolicy{MaxAttempts: 1}, 1, http.MethodGet, "/crm/v3/objects/contacts", response(503, nil), false, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wait, retry := test.policy.backoff(test.attempt, test.method, test.url, test.response)
+			if retry != test.retry {
+				t.Fatalf("expected retry %v, got %v", test.retry, retry)
+			}
+			if test.wait >= 0 && wait != test.wait {
+				t.Errorf("expected a wait of %s, got %s", test.wait, wait)
+			}
+			if test.wait < 0 && (wait < defaultRetryInitialBackoff/2 || wait > defaultRetryInitialBackoff) {
+				t.Errorf("expected an exponential backoff, got %s", wait)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	if wait, ok := retryAfter(header); !ok || wait < 58*time.Second || wait > time.Minute {
+		t.Errorf("expected about a minute, got %s", wait)
+	}
+
+	header.Set("Retry-After", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	if wait, ok := retryAfter(header); !ok || wait != 0 {
+		t.Errorf("expected no wait for a date in the past, got %s", wait)
+	}
+
+	for _, value := range []string{"", "soon", "-1"} {
+		header.Set("Retry-After", value)
+		if _, ok := retryAfter(header); ok {
+			t.Errorf("expected %q to be ignored", value)
+		}
+	}
+}
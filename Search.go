@@ -0,0 +1,494 @@
+package hubspot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"slices"
+	"strconv"
+
+	errortools "github.com/leapforce-libraries/go_errortools"
+	go_http "github.com/leapforce-libraries/go_http"
+)
+
+const (
+	maxFilterGroups     int  = 5
+	maxFiltersPerGroup  int  = 6
+	maxFiltersPerSearch int  = 18
+	maxSearchResults    int  = 10000
+	defaultSearchLimit  uint = 10
+)
+
+type Operator string
+
+const (
+	OperatorEq               Operator = "EQ"
+	OperatorNeq              Operator = "NEQ"
+	OperatorLt               Operator = "LT"
+	OperatorLte              Operator = "LTE"
+	OperatorGt               Operator = "GT"
+	OperatorGte              Operator = "GTE"
+	OperatorBetween          Operator = "BETWEEN"
+	OperatorIn               Operator = "IN"
+	OperatorNotIn            Operator = "NOT_IN"
+	OperatorHasProperty      Operator = "HAS_PROPERTY"
+	OperatorNotHasProperty   Operator = "NOT_HAS_PROPERTY"
+	OperatorContainsToken    Operator = "CONTAINS_TOKEN"
+	OperatorNotContainsToken Operator = "NOT_CONTAINS_TOKEN"
+)
+
+type SortDirection string
+
+const (
+	SortAscending  SortDirection = "ASCENDING"
+	SortDescending SortDirection = "DESCENDING"
+)
+
+type Sort struct {
+	PropertyName string        `json:"propertyName"`
+	Direction    SortDirection `json:"direction"`
+}
+
+// newFilter returns the filter for operator, values holds the low and high value for BETWEEN and the list for IN and NOT_IN
+func newFilter(propertyName string, operator Operator, values []string) (filter, *errortools.Error) {
+	f := filter{Operator: operator, PropertyName: propertyName}
+
+	switch operator {
+	case OperatorHasProperty, OperatorNotHasProperty:
+		if len(values) != 0 {
+			return f, errortools.ErrorMessage(fmt.Sprintf("%s filter on %s takes no values", operator, propertyName))
+		}
+	case OperatorBetween:
+		if len(values) != 2 {
+			return f, errortools.ErrorMessage(fmt.Sprintf("%s filter on %s takes a low and a high value", operator, propertyName))
+		}
+		f.Value, f.HighValue = values[0], values[1]
+	case OperatorIn, OperatorNotIn, OperatorContainsToken, OperatorNotContainsToken:
+		if len(values) == 0 {
+			return f, errortools.ErrorMessage(fmt.Sprintf("%s filter on %s takes at least one value", operator, propertyName))
+		}
+		if len(values) == 1 && (operator == OperatorContainsToken || operator == OperatorNotContainsToken) {
+			f.Value = values[0]
+		} else {
+			f.Values = values
+		}
+	default:
+		if len(values) != 1 {
+			return f, errortools.ErrorMessage(fmt.Sprintf("%s filter on %s takes one value", operator, propertyName))
+		}
+		f.Value = values[0]
+	}
+
+	return f, nil
+}
+
+// SearchBuilder builds a SearchObjectsConfig, for instance
+//
+//	config, e := hubspot.NewSearchBuilder().
+//		Filter("lifecyclestage", hubspot.OperatorIn, "lead", "opportunity").
+//		Filter("email", hubspot.OperatorHasProperty).
+//		Or().
+//		Filter("hs_lead_status", hubspot.OperatorEq, "NEW").
+//		Sort("createdate", hubspot.SortDescending).
+//		Build()
+//
+// Filters within a group must all match, Or starts a new group of which one must match.
+// Mistakes are reported by Build, which also checks the limits of the search endpoints.
+type SearchBuilder struct {
+	filterGroups []FilterGroup
+	sorts        []Sort
+	properties   *[]string
+	query        *string
+	limit        *uint
+	e            *errortools.Error
+}
+
+func NewSearchBuilder() *SearchBuilder {
+	return &SearchBuilder{}
+}
+
+// Filter adds a filter on propertyName to the current filter group
+func (builder *SearchBuilder) Filter(propertyName string, operator Operator, values ...string) *SearchBuilder {
+	f, e := newFilter(propertyName, operator, values)
+	if e != nil {
+		if builder.e == nil {
+			builder.e = e
+		}
+		return builder
+	}
+
+	if len(builder.filterGroups) == 0 {
+		builder.filterGroups = append(builder.filterGroups, FilterGroup{})
+	}
+	filterGroup := &builder.filterGroups[len(builder.filterGroups)-1]
+	if filterGroup.Filters == nil {
+		filterGroup.Filters = &[]filter{}
+	}
+	*filterGroup.Filters = append(*filterGroup.Filters, f)
+
+	return builder
+}
+
+// Associated adds a filter on the association with the object of objectType with objectId to the current filter group
+func (builder *SearchBuilder) Associated(objectType ObjectType, objectId string) *SearchBuilder {
+	return builder.Filter(associationsProperty(objectType), OperatorEq, objectId)
+}
+
+// Or starts a new filter group
+func (builder *SearchBuilder) Or() *SearchBuilder {
+	builder.filterGroups = append(builder.filterGroups, FilterGroup{})
+
+	return builder
+}
+
+func (builder *SearchBuilder) Sort(propertyName string, direction SortDirection) *SearchBuilder {
+	builder.sorts = append(builder.sorts, Sort{PropertyName: propertyName, Direction: direction})
+
+	return builder
+}
+
+func (builder *SearchBuilder) Properties(properties ...string) *SearchBuilder {
+	builder.properties = &properties
+
+	return builder
+}
+
+func (builder *SearchBuilder) Query(query string) *SearchBuilder {
+	builder.query = &query
+
+	return builder
+}
+
+func (builder *SearchBuilder) Limit(limit uint) *SearchBuilder {
+	builder.limit = &limit
+
+	return builder
+}
+
+// Build returns the SearchObjectsConfig, or the first mistake made building it
+func (builder *SearchBuilder) Build() (*SearchObjectsConfig, *errortools.Error) {
+	if builder.e != nil {
+		return nil, builder.e
+	}
+
+	config := SearchObjectsConfig{
+		Properties: builder.properties,
+		Query:      builder.query,
+		Limit:      builder.limit,
+	}
+
+	var filterGroups []FilterGroup
+	for _, filterGroup := range builder.filterGroups {
+		// Or without filters
+		if filterGroup.Filters != nil {
+			filterGroups = append(filterGroups, filterGroup)
+		}
+	}
+	if len(filterGroups) > 0 {
+		config.FilterGroups = &filterGroups
+	}
+	if len(builder.sorts) > 0 {
+		sorts := builder.sorts
+		config.Sorts = &sorts
+	}
+
+	e := config.validate()
+	if e != nil {
+		return nil, e
+	}
+
+	return &config, nil
+}
+
+// associationsProperty returns the pseudo property to filter a search on associations with objectType, e.g. associations.company.
+// Custom objects are filtered by their object type id, e.g. associations.2-123456.
+func associationsProperty(objectType ObjectType) string {
+	switch objectType {
+	case ObjectTypeCalls:
+		return "associations.call"
+	case ObjectTypeCompanies:
+		return "associations.company"
+	case ObjectTypeContacts:
+		return "associations.contact"
+	case ObjectTypeDeals:
+		return "associations.deal"
+	case ObjectTypeEmails:
+		return "associations.email"
+	case ObjectTypeLineItems:
+		return "associations.line_item"
+	case ObjectTypeMeetings:
+		return "associations.meeting"
+	case ObjectTypeNotes:
+		return "associations.note"
+	case ObjectTypeProducts:
+		return "associations.product"
+	case ObjectTypeQuotes:
+		return "associations.quote"
+	case ObjectTypeTasks:
+		return "associations.task"
+	case ObjectTypeTickets:
+		return "associations.ticket"
+	}
+
+	return "associations." + string(objectType)
+}
+
+// validate checks the limits HubSpot puts on the filter groups of a search
+func (config *SearchObjectsConfig) validate() *errortools.Error {
+	if config == nil || config.FilterGroups == nil {
+		return nil
+	}
+
+	filterGroups := *config.FilterGroups
+	if len(filterGroups) > maxFilterGroups {
+		return errortools.ErrorMessage(fmt.Sprintf("Search has %v filter groups, at most %v are allowed", len(filterGroups), maxFilterGroups))
+	}
+
+	total := 0
+	for i, filterGroup := range filterGroups {
+		if filterGroup.Filters == nil {
+			continue
+		}
+		if len(*filterGroup.Filters) > maxFiltersPerGroup {
+			return errortools.ErrorMessage(fmt.Sprintf("Filter group %v has %v filters, at most %v are allowed", i, len(*filterGroup.Filters), maxFiltersPerGroup))
+		}
+		total += len(*filterGroup.Filters)
+	}
+	if total > maxFiltersPerSearch {
+		return errortools.ErrorMessage(fmt.Sprintf("Search has %v filters, at most %v are allowed", total, maxFiltersPerSearch))
+	}
+
+	return nil
+}
+
+// CountSearchResults returns the number of objects of objectType matching config
+func (service *Service) CountSearchResults(objectType ObjectType, config *SearchObjectsConfig) (int64, *errortools.Error) {
+	return service.CountSearchResultsWithContext(context.Background(), objectType, config)
+}
+
+func (service *Service) CountSearchResultsWithContext(ctx context.Context, objectType ObjectType, config *SearchObjectsConfig) (int64, *errortools.Error) {
+	limit := uint(1)
+
+	body := SearchObjectsConfig{}
+	if config != nil {
+		body = *config
+	}
+	body.Limit = &limit
+	body.After = nil
+	body.Sorts = nil
+	body.Properties = &[]string{objectIdProperty}
+
+	if e := body.validate(); e != nil {
+		return 0, e
+	}
+
+	response := pageResponse[Object]{}
+
+	requestConfig := go_http.RequestConfig{
+		Method:        http.MethodPost,
+		Url:           service.urlCrm(fmt.Sprintf("objects/%s/search", objectType)),
+		BodyModel:     body,
+		ResponseModel: &response,
+	}
+
+	_, _, e := service.httpRequest(ctx, &requestConfig)
+	if e != nil {
+		return 0, e
+	}
+
+	if response.Total == nil {
+		return int64(len(response.Results)), nil
+	}
+
+	return *response.Total, nil
+}
+
+// SearchResult holds the results of a search and the total number of matching objects HubSpot reports,
+// which exceeds len(Results) when only the page at SearchObjectsConfig.After was requested
+type SearchResult[T any] struct {
+	Results []T
+	Total   int64
+}
+
+// collectSearch loads all results of seq, a search iterating cursor, in a SearchResult
+func collectSearch[T any](cursor *Cursor, seq iter.Seq2[T, *errortools.Error]) (*SearchResult[T], *errortools.Error) {
+	results, e := collect(seq)
+	if e != nil {
+		return nil, e
+	}
+
+	return &SearchResult[T]{Results: *results, Total: cursor.Total}, nil
+}
+
+// iterSplitSearch streams all results of a search, also beyond the 10,000 results a search returns.
+// The search is sorted by cursor.Split.By and split in ranges, each starting at the last value of the previous range.
+// Objects with that value the previous range returned already are skipped.
+func iterSplitSearch[T any](ctx context.Context, service *Service, cursor *Cursor, singlePage bool) iter.Seq2[T, *errortools.Error] {
+	split := cursor.Split
+
+	limit := defaultSearchLimit
+	if cursor.Search.Limit != nil {
+		limit = *cursor.Search.Limit
+	}
+
+	if e := (&SearchObjectsConfig{FilterGroups: splitFilterGroups(cursor.Search.FilterGroups, split.By, "0")}).validate(); e != nil {
+		return iterError[T](e)
+	}
+
+	return func(yield func(T, *errortools.Error) bool) {
+		var zero T
+
+		for !cursor.Done {
+			response := pageResponse[json.RawMessage]{}
+
+			body := *cursor.Search
+			body.Limit = &limit
+			body.After = nil
+			if cursor.After != "" {
+				after := cursor.After
+				body.After = &after
+			}
+			body.Sorts = &[]Sort{{PropertyName: split.By, Direction: SortAscending}}
+			body.Properties = splitProperties(cursor.Search.Properties, split.By)
+			if split.From != "" {
+				body.FilterGroups = splitFilterGroups(cursor.Search.FilterGroups, split.By, split.From)
+			}
+
+			requestConfig := go_http.RequestConfig{
+				Method:        http.MethodPost,
+				Url:           service.urlCrm(fmt.Sprintf("objects/%s/search", cursor.ObjectType)),
+				BodyModel:     body,
+				ResponseModel: &response,
+			}
+
+			_, _, e := service.httpRequest(ctx, &requestConfig)
+			if e != nil {
+				yield(zero, e)
+				return
+			}
+
+			if response.Total != nil && split.From == "" && cursor.After == "" {
+				cursor.Total = *response.Total
+			}
+
+			last, lastIds := split.Last, split.LastIds
+
+			for _, raw := range response.Results {
+				var object Object
+				var result T
+
+				err := json.Unmarshal(raw, &object)
+				if err == nil {
+					err = json.Unmarshal(raw, &result)
+				}
+				if err != nil {
+					yield(zero, errortools.ErrorMessage(err))
+					return
+				}
+
+				value, e := splitValue(object, split.By)
+				if e != nil {
+					yield(zero, e)
+					return
+				}
+
+				if value == split.From && slices.Contains(split.Skip, object.Id) {
+					continue
+				}
+
+				if !yield(result, nil) {
+					return
+				}
+
+				if value != last {
+					last = value
+					lastIds = nil
+				}
+				lastIds = append(lastIds, object.Id)
+			}
+
+			split.Last, split.LastIds = last, lastIds
+
+			next := ""
+			if response.Paging != nil {
+				next = response.Paging.Next.After
+			}
+			offset, _ := strconv.Atoi(next)
+
+			switch {
+			case next == "":
+				cursor.After = ""
+				cursor.Done = true
+			case offset+int(limit) > maxSearchResults:
+				if split.Last == split.From {
+					yield(zero, errortools.ErrorMessage(fmt.Sprintf("Cannot split search of %s, more than %v objects have %s %s", cursor.ObjectType, maxSearchResults, split.By, split.From)))
+					return
+				}
+				cursor.After = ""
+				split.From, split.Skip = split.Last, slices.Clone(split.LastIds)
+			default:
+				cursor.After = next
+			}
+
+			if singlePage {
+				return
+			}
+		}
+	}
+}
+
+// splitValue returns the value of property by of object, datetimes as milliseconds since epoch like search filters expect
+func splitValue(object Object, by string) (string, *errortools.Error) {
+	value := object.Properties[by]
+	if by == objectIdProperty && value == "" {
+		value = object.Id
+	}
+
+	i, err := parseExportValue(value)
+	if err != nil {
+		return "", errortools.ErrorMessage(fmt.Sprintf("Cannot split search by %s: %s", by, err.Error()))
+	}
+
+	return strconv.FormatInt(i, 10), nil
+}
+
+// splitFilterGroups adds a lower bound on by to every filter group
+func splitFilterGroups(filterGroups *[]FilterGroup, by string, from string) *[]FilterGroup {
+	filterGroups_ := copyFilterGroups(filterGroups)
+	for i := range filterGroups_ {
+		filterGroups_[i].AddPropertyFilter(OperatorGte, by, from, "")
+	}
+
+	return &filterGroups_
+}
+
+// splitProperties makes sure by is returned, nil returns the default properties which include it
+func splitProperties(properties *[]string, by string) *[]string {
+	if properties == nil || slices.Contains(*properties, by) {
+		return properties
+	}
+
+	properties_ := append(slices.Clone(*properties), by)
+
+	return &properties_
+}
+
+// copyFilterGroups returns a copy of filterGroups that filters can be added to, with one empty group if there are none
+func copyFilterGroups(filterGroups *[]FilterGroup) []FilterGroup {
+	if filterGroups == nil || len(*filterGroups) == 0 {
+		return []FilterGroup{{}}
+	}
+
+	filterGroups_ := []FilterGroup{}
+	for _, filterGroup := range *filterGroups {
+		filters := []filter{}
+		if filterGroup.Filters != nil {
+			filters = append(filters, *filterGroup.Filters...)
+		}
+		filterGroups_ = append(filterGroups_, FilterGroup{Filters: &filters})
+	}
+
+	return filterGroups_
+}
@@ -0,0 +1,424 @@
+package hubspot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// searchServer holds objects sorted by their value and answers searches on them like HubSpot,
+// which returns at most maxSearchResults results per search
+type searchServer struct {
+	values []int // value of the object with id i+1
+
+	mutex    sync.Mutex
+	requests []SearchObjectsConfig
+}
+
+func (server *searchServer) handle(w http.ResponseWriter, r *http.Request) {
+	var config SearchObjectsConfig
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		writeJson(w, http.StatusBadRequest, `{"status":"error","message":"invalid body"}`)
+		return
+	}
+
+	server.mutex.Lock()
+	server.requests = append(server.requests, config)
+	server.mutex.Unlock()
+
+	from := 0
+	if config.FilterGroups != nil {
+		for _, filter := range *(*config.FilterGroups)[0].Filters {
+			if filter.PropertyName == "value" && filter.Operator == OperatorGte {
+				from, _ = strconv.Atoi(filter.Value)
+			}
+		}
+	}
+
+	ids := []int{}
+	for i, value := range server.values {
+		if value >= from {
+			ids = append(ids, i+1)
+		}
+	}
+
+	offset := 0
+	if config.After != nil {
+		offset, _ = strconv.Atoi(*config.After)
+	}
+	limit := int(*config.Limit)
+	if offset+limit > maxSearchResults {
+		writeJson(w, http.StatusBadRequest, `{"status":"error","category":"VALIDATION_ERROR","message":"paging beyond 10000 results"}`)
+		return
+	}
+
+	results := []string{}
+	for _, id := range ids[offset:min(offset+limit, len(ids))] {
+		results = append(results, fmt.Sprintf(`{"id":"%v","properties":{"value":"%v"}}`, id, server.values[id-1]))
+	}
+
+	paging := ""
+	if offset+limit < len(ids) {
+		paging = fmt.Sprintf(`,"paging":{"next":{"after":"%v"}}`, offset+limit)
+	}
+
+	writeJson(w, http.StatusOK, fmt.Sprintf(`{"total":%v,"results":[%s]%s}`, len(ids), strings.Join(results, ","), paging))
+}
+
+func splitSearchCursor() *Cursor {
+	limit := uint(100)
+	by := "value"
+
+	return NewSearchCursor(ObjectTypeContacts, &SearchObjectsConfig{Limit: &limit, SplitBy: &by})
+}
+
+// searchIds returns the ids of the objects yielded by seq, and the error it ended with
+func searchIds(seq iter.Seq2[Object, error]) ([]string, error) {
+	ids := []string{}
+	for object, err := range seq {
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, object.Id)
+	}
+
+	return ids, nil
+}
+
+// checkAllOnce fails unless ids holds the ids 1 to n exactly once
+func checkAllOnce(t *testing.T, ids []string, n int) {
+	t.Helper()
+
+	if len(ids) != n {
+		t.Errorf("got %v objects, want %v", len(ids), n)
+	}
+
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			t.Fatalf("object %s yielded twice", id)
+		}
+		seen[id] = true
+	}
+	for i := 1; i <= n; i++ {
+		if !seen[strconv.Itoa(i)] {
+			t.Fatalf("object %v not yielded", i)
+		}
+	}
+}
+
+// lowerBounds returns the lower bound on value of the first request of every range, empty for the unbounded first range
+func (server *searchServer) lowerBounds() []string {
+	bounds := []string{}
+	for _, request := range server.requests {
+		if request.After != nil {
+			continue
+		}
+		bound := ""
+		if request.FilterGroups != nil {
+			bound = (*(*request.FilterGroups)[0].Filters)[0].Value
+		}
+		bounds = append(bounds, bound)
+	}
+
+	return bounds
+}
+
+func increasing(n int) []int {
+	values := make([]int, n)
+	for i := range values {
+		values[i] = i + 1
+	}
+
+	return values
+}
+
+func TestSplitSearchExactlyMaxResults(t *testing.T) {
+	server := searchServer{values: increasing(maxSearchResults)}
+	service := newTestService(t, server.handle, nil)
+
+	cursor := splitSearchCursor()
+	ids, err := searchIds(service.IterSearchObjectsFrom(context.Background(), cursor))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkAllOnce(t, ids, maxSearchResults)
+	if len(server.requests) != maxSearchResults/100 {
+		t.Errorf("got %v requests, want %v without splitting", len(server.requests), maxSearchResults/100)
+	}
+	if cursor.Total != int64(maxSearchResults) || !cursor.Done {
+		t.Errorf("got total %v and done %v, want %v and true", cursor.Total, cursor.Done, maxSearchResults)
+	}
+}
+
+func TestSplitSearchBeyondMaxResults(t *testing.T) {
+	server := searchServer{values: increasing(maxSearchResults + 1)}
+	service := newTestService(t, server.handle, nil)
+
+	cursor := splitSearchCursor()
+	ids, err := searchIds(service.IterSearchObjectsFrom(context.Background(), cursor))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkAllOnce(t, ids, maxSearchResults+1)
+	// the window ending at 10,000 is not requested, the next range starts at the last value read
+	if bounds := server.lowerBounds(); !slices.Equal(bounds, []string{"", "10000"}) {
+		t.Errorf("got ranges starting at %q, want [\"\" \"10000\"]", bounds)
+	}
+	if cursor.Total != int64(maxSearchResults+1) {
+		t.Errorf("got total %v, want the total of the first range %v", cursor.Total, maxSearchResults+1)
+	}
+}
+
+func TestSplitSearchEqualValuesAcrossRanges(t *testing.T) {
+	// objects 9851 to 10150 share value 9851, so the first range ends halfway through them
+	values := increasing(12000)
+	for i := 9850; i < 10150; i++ {
+		values[i] = 9851
+	}
+	server := searchServer{values: values}
+	service := newTestService(t, server.handle, nil)
+
+	ids, err := searchIds(service.IterSearchObjectsFrom(context.Background(), splitSearchCursor()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkAllOnce(t, ids, 12000)
+	if bounds := server.lowerBounds(); !slices.Equal(bounds, []string{"", "9851"}) {
+		t.Errorf("got ranges starting at %q, want [\"\" \"9851\"]", bounds)
+	}
+}
+
+func TestSplitSearchTooManyEqualValues(t *testing.T) {
+	values := make([]int, maxSearchResults+1)
+	for i := range values {
+		values[i] = 7
+	}
+	server := searchServer{values: values}
+	service := newTestService(t, server.handle, nil)
+
+	ids, err := searchIds(service.IterSearchObjectsFrom(context.Background(), splitSearchCursor()))
+	if err == nil || !strings.Contains(err.Error(), "Cannot split search of contacts, more than 10000 objects have value 7") {
+		t.Fatalf("got error %v, want that the search cannot be split", err)
+	}
+	// the objects of the first range are not yielded again before giving up
+	checkAllOnce(t, ids, maxSearchResults)
+}
+
+func TestSplitSearchResume(t *testing.T) {
+	values := increasing(12000)
+	for i := 9850; i < 10150; i++ {
+		values[i] = 9851
+	}
+	server := searchServer{values: values}
+	service := newTestService(t, server.handle, nil)
+
+	// stop halfway through the second range, then resume at the checkpointed cursor
+	cursor := splitSearchCursor()
+	ids := []string{}
+	for object, err := range service.IterSearchObjectsFrom(context.Background(), cursor) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, object.Id)
+		if len(ids) == 10500 {
+			break
+		}
+	}
+
+	checkpoint, err := json.Marshal(cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resumed Cursor
+	if err := json.Unmarshal(checkpoint, &resumed); err != nil {
+		t.Fatal(err)
+	}
+
+	rest, err := searchIds(service.IterSearchObjectsFrom(context.Background(), &resumed))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the 50 objects read of the interrupted page are delivered again, nothing else
+	if len(ids)+len(rest) != 12000+50 {
+		t.Errorf("got %v objects, want %v", len(ids)+len(rest), 12000+50)
+	}
+	checkAllOnce(t, append(ids[:10450], rest...), 12000)
+	if resumed.Total != 12000 {
+		t.Errorf("got total %v after resuming, want 12000", resumed.Total)
+	}
+}
+
+func TestSearchWithTotal(t *testing.T) {
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		var config SearchObjectsConfig
+		json.NewDecoder(r.Body).Decode(&config)
+
+		if config.After == nil {
+			writeJson(w, http.StatusOK, `{"total":3,"results":[{"id":"1"},{"id":"2"}],"paging":{"next":{"after":"2"}}}`)
+			return
+		}
+		writeJson(w, http.StatusOK, `{"total":3,"results":[{"id":"3"}]}`)
+	}, nil)
+
+	contacts, e := service.SearchContactWithTotal(&SearchObjectsConfig{})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if contacts.Total != 3 || len(contacts.Results) != 3 {
+		t.Errorf("got %v contacts of total %v, want 3 of 3", len(contacts.Results), contacts.Total)
+	}
+
+	// a single page still reports the total of the whole search
+	after := "0"
+	objects, e := NewObjects[Object](service, ObjectTypeDeals).SearchWithTotal(&SearchObjectsConfig{After: &after})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if objects.Total != 3 || len(objects.Results) != 1 {
+		t.Errorf("got %v objects of total %v, want 1 of 3", len(objects.Results), objects.Total)
+	}
+}
+
+func TestSearchBuilder(t *testing.T) {
+	config, e := NewSearchBuilder().
+		Filter("lifecyclestage", OperatorIn, "lead", "opportunity").
+		Filter("email", OperatorHasProperty).
+		Or().
+		Filter("amount", OperatorBetween, "10", "20").
+		Associated(ObjectTypeCompanies, "7").
+		Sort("createdate", SortDescending).
+		Limit(50).
+		Build()
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+
+	b, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"limit":50,"filterGroups":[` +
+		`{"filters":[{"operator":"IN","propertyName":"lifecyclestage","values":["lead","opportunity"]},{"operator":"HAS_PROPERTY","propertyName":"email"}]},` +
+		`{"filters":[{"operator":"BETWEEN","propertyName":"amount","value":"10","highValue":"20"},{"operator":"EQ","propertyName":"associations.company","value":"7"}]}],` +
+		`"sorts":[{"propertyName":"createdate","direction":"DESCENDING"}]}`
+	if string(b) != expected {
+		t.Errorf("got %s, want %s", b, expected)
+	}
+
+	for _, builder := range []*SearchBuilder{
+		NewSearchBuilder().Filter("email", OperatorHasProperty, "x"),
+		NewSearchBuilder().Filter("amount", OperatorBetween, "10"),
+		NewSearchBuilder().Filter("stage", OperatorIn),
+		NewSearchBuilder().Filter("stage", OperatorEq, "a", "b"),
+	} {
+		if _, e := builder.Build(); e == nil {
+			t.Error("expected an error for the values of a filter")
+		}
+	}
+}
+
+// filters returns a search builder with groups of the given number of filters
+func filters(groups ...int) *SearchBuilder {
+	builder := NewSearchBuilder()
+	for i, n := range groups {
+		if i > 0 {
+			builder.Or()
+		}
+		for j := range n {
+			builder.Filter(fmt.Sprintf("property%v", j), OperatorEq, "x")
+		}
+	}
+
+	return builder
+}
+
+func TestSearchBuilderLimits(t *testing.T) {
+	tests := []struct {
+		groups []int
+		err    string
+	}{
+		{[]int{1, 1, 1, 1, 1}, ""},
+		{[]int{1, 1, 1, 1, 1, 1}, "Search has 6 filter groups, at most 5 are allowed"},
+		{[]int{6}, ""},
+		{[]int{1, 7}, "Filter group 1 has 7 filters, at most 6 are allowed"},
+		{[]int{6, 6, 6}, ""},
+		{[]int{6, 6, 6, 1}, "Search has 19 filters, at most 18 are allowed"},
+	}
+
+	for _, test := range tests {
+		_, e := filters(test.groups...).Build()
+		if test.err == "" && e != nil {
+			t.Errorf("groups %v: unexpected error %s", test.groups, e.Message())
+		}
+		if test.err != "" && (e == nil || e.Message() != test.err) {
+			t.Errorf("groups %v: got error %v, want %q", test.groups, e, test.err)
+		}
+	}
+
+	// configs not built by a builder are checked before they are sent
+	config := &SearchObjectsConfig{FilterGroups: &[]FilterGroup{{}, {}, {}, {}, {}, {}}}
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected request")
+	}, nil)
+	if _, e := service.SearchObjects(ObjectTypeDeals, config); e == nil || !strings.Contains(e.Message(), "6 filter groups") {
+		t.Errorf("got error %v, want that the search has too many filter groups", e)
+	}
+}
+
+func TestIterSearchObjects(t *testing.T) {
+	var paths []string
+
+	server := searchServer{values: increasing(250)}
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		server.mutex.Lock()
+		paths = append(paths, r.URL.Path)
+		server.mutex.Unlock()
+		server.handle(w, r)
+	}, nil)
+
+	config, e := NewSearchBuilder().Associated(ObjectTypeCompanies, "7").Limit(100).Build()
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+
+	ids, err := searchIds(service.IterSearchObjects(context.Background(), "p123_cars", config))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkAllOnce(t, ids, 250)
+	if len(paths) != 3 || paths[0] != "/crm/v3/objects/p123_cars/search" {
+		t.Errorf("got requests to %v, want 3 to the search of p123_cars", paths)
+	}
+	for _, request := range server.requests {
+		filter := (*(*request.FilterGroups)[0].Filters)[0]
+		if filter.PropertyName != "associations.company" || filter.Value != "7" {
+			t.Errorf("got filter %+v, want the association with company 7", filter)
+		}
+	}
+
+	// SearchObjects collects all pages of the same search on a builtin object type
+	objects, e := service.SearchObjects(ObjectTypeDeals, config)
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if len(*objects) != 250 || paths[3] != "/crm/v3/objects/deals/search" {
+		t.Errorf("got %v deals from %v, want 250 from the search of deals", len(*objects), paths[3])
+	}
+
+	if _, err := searchIds(service.IterSearchObjects(context.Background(), "", config)); err == nil {
+		t.Error("expected an error for an empty object type")
+	}
+}
@@ -1,34 +1,47 @@
 package hubspot
 
 import (
+	"context"
 	"fmt"
 	errortools "github.com/leapforce-libraries/go_errortools"
 	go_http "github.com/leapforce-libraries/go_http"
 	oauth2 "github.com/leapforce-libraries/go_oauth2"
 	"github.com/leapforce-libraries/go_oauth2/tokensource"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
-	"time"
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 const (
-	apiName                string = "Hubspot"
-	apiUrlContacts         string = "https://api.hubapi.com/contacts"
-	apiUrlEngagements      string = "https://api.hubapi.com/engagements"
-	apiUrlEvents           string = "https://api.hubapi.com/events"
-	apiUrlFiles            string = "https://api.hubapi.com/files"
-	apiUrlForms            string = "https://api.hubapi.com/forms"
-	apiUrlFormIntegrations string = "https://api.hubapi.com/form-integrations"
-	apiUrlCms              string = "https://api.hubapi.com/cms"
-	apiUrlCrm              string = "https://api.hubapi.com/crm"
-	apiUrlOAuth            string = "https://api.hubapi.com/oauth"
-	apiUrlAccountInfo      string = "https://api.hubapi.com/account-info"
-	defaultRedirectUrl     string = "http://localhost:8080/oauth/redirect"
-	authUrl                string = "https://app-eu1.hubspot.com/oauth/authorize"
-	tokenHttpMethod        string = http.MethodPost
-	maxItemsPerBatch       int    = 100
+	apiName                 string = "Hubspot"
+	apiPathContacts         string = "contacts"
+	apiPathEngagements      string = "engagements"
+	apiPathEvents           string = "events"
+	apiPathFiles            string = "files"
+	apiPathForms            string = "forms"
+	apiPathFormIntegrations string = "form-integrations"
+	apiPathCms              string = "cms"
+	apiPathCrm              string = "crm"
+	apiPathOAuth            string = "oauth"
+	apiPathAccountInfo      string = "account-info"
+	defaultBaseUrl          string = "https://api.hubapi.com"
+	defaultAuthHost         string = "https://app-eu1.hubspot.com"
+	defaultRedirectUrl      string = "http://localhost:8080/oauth/redirect"
+	tokenHttpMethod         string = http.MethodPost
+	maxItemsPerBatch        int    = 100
 )
 
+func init() {
+	// go_http sets the process wide errortools context for every request, and errortools creates the context map on first use without holding its lock,
+	// so create it before requests run concurrently. Concurrent requests overwrite each other's context, so errors report their own request instead.
+	errortools.SetContext("http_url", "")
+	errortools.RemoveContext("http_url")
+}
+
 type authorizationMode string
 
 const (
@@ -37,19 +50,38 @@ const (
 	authorizationModeAccessToken authorizationMode = "accesstoken"
 )
 
+// Service may be used by several goroutines at once, every request keeps its own error state.
+// The *errortools.Error a request returns reports that request, read the failing url from e.Request() rather than from the errortools context,
+// which go_http shares between all requests of the process.
 type Service struct {
 	authorizationMode authorizationMode
 	clientId          string
 	apiKey            string
 	accessToken       string
-	httpService       *go_http.Service
+	baseUrl           string
+	authHost          string
+	httpClient        *http.Client
+	retryPolicy       *RetryPolicy
+	rateLimiter       *RateLimiter
+	logger            *slog.Logger
 	oAuth2Service     *oauth2.Service
 	redirectUrl       *string
-	errorResponse     *ErrorResponse
+	lastErrorResponse *ErrorResponse
+	mutex             sync.Mutex
+	requestCount      atomic.Int64
+	batchConcurrency  int
 }
 
 type ServiceConfig struct {
-	BearerToken string
+	BearerToken      string
+	BaseUrl          *string // defaults to https://api.hubapi.com
+	AuthHost         *string // defaults to https://app-eu1.hubspot.com
+	HttpClient       *http.Client
+	RoundTripper     http.RoundTripper // overrules the Transport of HttpClient
+	RetryPolicy      *RetryPolicy
+	RateLimiter      *RateLimiter // share one RateLimiter between all Services of the same app
+	Logger           *slog.Logger // nil keeps the Service silent
+	BatchConcurrency *int         // batches of 100 the batch functions send at a time, defaults to 1
 }
 
 func NewService(config *ServiceConfig) (*Service, *errortools.Error) {
@@ -61,40 +93,73 @@ func NewService(config *ServiceConfig) (*Service, *errortools.Error) {
 		return nil, errortools.ErrorMessage("BearerToken not provided")
 	}
 
-	httpService, e := go_http.NewService(&go_http.ServiceConfig{})
-	if e != nil {
-		return nil, e
-	}
-
 	return &Service{
 		authorizationMode: authorizationModeAccessToken,
 		accessToken:       config.BearerToken,
-		httpService:       httpService,
+		baseUrl:           stringOrDefault(config.BaseUrl, defaultBaseUrl),
+		authHost:          stringOrDefault(config.AuthHost, defaultAuthHost),
+		httpClient:        newHttpClient(config.HttpClient, config.RoundTripper),
+		retryPolicy:       config.RetryPolicy,
+		rateLimiter:       config.RateLimiter,
+		logger:            loggerOrDiscard(config.Logger),
+		batchConcurrency:  intOrDefault(config.BatchConcurrency, 1),
 	}, nil
 }
 
 func NewServiceWithApiKey(apiKey string) (*Service, *errortools.Error) {
-	if apiKey == "" {
-		return nil, errortools.ErrorMessage("apiKey not provided")
+	return NewServiceWithApiKeyConfig(&ServiceWithApiKeyConfig{ApiKey: apiKey})
+}
+
+type ServiceWithApiKeyConfig struct {
+	ApiKey           string
+	BaseUrl          *string // defaults to https://api.hubapi.com
+	AuthHost         *string // defaults to https://app-eu1.hubspot.com
+	HttpClient       *http.Client
+	RoundTripper     http.RoundTripper // overrules the Transport of HttpClient
+	RetryPolicy      *RetryPolicy
+	RateLimiter      *RateLimiter // share one RateLimiter between all Services of the same app
+	Logger           *slog.Logger // nil keeps the Service silent
+	BatchConcurrency *int         // batches of 100 the batch functions send at a time, defaults to 1
+}
+
+func NewServiceWithApiKeyConfig(cfg *ServiceWithApiKeyConfig) (*Service, *errortools.Error) {
+	if cfg == nil {
+		return nil, errortools.ErrorMessage("ServiceWithApiKeyConfig must not be a nil pointer")
 	}
 
-	httpService, e := go_http.NewService(&go_http.ServiceConfig{})
-	if e != nil {
-		return nil, e
+	if cfg.ApiKey == "" {
+		return nil, errortools.ErrorMessage("apiKey not provided")
 	}
 
 	return &Service{
 		authorizationMode: authorizationModeApiKey,
-		apiKey:            apiKey,
-		httpService:       httpService,
+		apiKey:            cfg.ApiKey,
+		baseUrl:           stringOrDefault(cfg.BaseUrl, defaultBaseUrl),
+		authHost:          stringOrDefault(cfg.AuthHost, defaultAuthHost),
+		httpClient:        newHttpClient(cfg.HttpClient, cfg.RoundTripper),
+		retryPolicy:       cfg.RetryPolicy,
+		rateLimiter:       cfg.RateLimiter,
+		logger:            loggerOrDiscard(cfg.Logger),
+		batchConcurrency:  intOrDefault(cfg.BatchConcurrency, 1),
 	}, nil
 }
 
+// ServiceWithOAuth2Config configures a Service using OAuth2.
+// The token requests, both GetTokenFromCode and the refresh when a request finds the token expired, are sent by go_oauth2 to BaseUrl
+// with its own http client, so HttpClient, RoundTripper, RetryPolicy and RateLimiter do not apply to them.
 type ServiceWithOAuth2Config struct {
-	ClientId     string
-	ClientSecret string
-	TokenSource  tokensource.TokenSource
-	RedirectUrl  *string
+	ClientId         string
+	ClientSecret     string
+	TokenSource      tokensource.TokenSource
+	RedirectUrl      *string
+	BaseUrl          *string // defaults to https://api.hubapi.com
+	AuthHost         *string // defaults to https://app-eu1.hubspot.com
+	HttpClient       *http.Client
+	RoundTripper     http.RoundTripper // overrules the Transport of HttpClient
+	RetryPolicy      *RetryPolicy
+	RateLimiter      *RateLimiter // share one RateLimiter between all Services of the same app
+	Logger           *slog.Logger // nil keeps the Service silent
+	BatchConcurrency *int         // batches of 100 the batch functions send at a time, defaults to 1
 }
 
 func NewServiceWithOAuth2(cfg *ServiceWithOAuth2Config) (*Service, *errortools.Error) {
@@ -111,12 +176,15 @@ func NewServiceWithOAuth2(cfg *ServiceWithOAuth2Config) (*Service, *errortools.E
 		redirectUrl = *cfg.RedirectUrl
 	}
 
+	baseUrl := stringOrDefault(cfg.BaseUrl, defaultBaseUrl)
+	authHost := stringOrDefault(cfg.AuthHost, defaultAuthHost)
+
 	oauth2ServiceConfig := oauth2.ServiceConfig{
 		ClientId:        cfg.ClientId,
 		ClientSecret:    cfg.ClientSecret,
 		RedirectUrl:     redirectUrl,
-		AuthUrl:         authUrl,
-		TokenUrl:        fmt.Sprintf("%s/v1/token", apiUrlOAuth),
+		AuthUrl:         fmt.Sprintf("%s/oauth/authorize", authHost),
+		TokenUrl:        fmt.Sprintf("%s/%s/v1/token", baseUrl, apiPathOAuth),
 		TokenHttpMethod: tokenHttpMethod,
 		TokenSource:     cfg.TokenSource,
 	}
@@ -130,78 +198,232 @@ func NewServiceWithOAuth2(cfg *ServiceWithOAuth2Config) (*Service, *errortools.E
 		clientId:          cfg.ClientId,
 		oAuth2Service:     oauth2Service,
 		redirectUrl:       cfg.RedirectUrl,
+		baseUrl:           baseUrl,
+		authHost:          authHost,
+		httpClient:        newHttpClient(cfg.HttpClient, cfg.RoundTripper),
+		retryPolicy:       cfg.RetryPolicy,
+		rateLimiter:       cfg.RateLimiter,
+		logger:            loggerOrDiscard(cfg.Logger),
+		batchConcurrency:  intOrDefault(cfg.BatchConcurrency, 1),
 	}, nil
 }
 
-func (service *Service) httpRequest(requestConfig *go_http.RequestConfig) (*http.Request, *http.Response, *errortools.Error) {
-	return service.tryHttpRequest(requestConfig, false)
+func stringOrDefault(value *string, defaultValue string) string {
+	if value == nil || *value == "" {
+		return defaultValue
+	}
+	return strings.TrimSuffix(*value, "/")
+}
+
+func intOrDefault(value *int, defaultValue int) int {
+	if value == nil || *value <= 0 {
+		return defaultValue
+	}
+	return *value
+}
+
+// newHttpClient returns a copy of httpClient, so that setting roundTripper does not alter the caller's client
+func newHttpClient(httpClient *http.Client, roundTripper http.RoundTripper) *http.Client {
+	client := http.Client{}
+	if httpClient != nil {
+		client = *httpClient
+	}
+	if roundTripper != nil {
+		client.Transport = roundTripper
+	}
+	return &client
+}
+
+func (service *Service) httpRequest(ctx context.Context, requestConfig *go_http.RequestConfig) (*http.Request, *http.Response, *errortools.Error) {
+	// retries are handled by RetryPolicy instead of go_http
+	var maxRetries uint = 0
+	requestConfig.MaxRetries = &maxRetries
+
+	// keep the url as passed, tryHttpRequest may add the api key to it
+	requestUrl := requestConfig.Url
+
+	return service.retry(ctx, requestConfig.Method, requestUrl, func() (*http.Request, *http.Response, *errortools.Error) {
+		return service.tryHttpRequest(ctx, requestConfig)
+	})
 }
 
-func (service *Service) tryHttpRequest(requestConfig *go_http.RequestConfig, isRetry bool) (*http.Request, *http.Response, *errortools.Error) {
+// retry calls try until it succeeds or RetryPolicy gives up, waiting for the RateLimiter before every attempt
+func (service *Service) retry(ctx context.Context, method string, requestUrl string, try func() (*http.Request, *http.Response, *errortools.Error)) (*http.Request, *http.Response, *errortools.Error) {
+	for attempt := 1; ; attempt++ {
+		if err := service.rateLimiter.Wait(ctx); err != nil {
+			return nil, nil, newError(unsentRequest(method, requestUrl), err)
+		}
+
+		request, response, e := try()
+		if response != nil {
+			service.rateLimiter.update(response.Header)
+		} else if e == nil {
+			// go_http returns neither a response nor an error when the tls handshake times out and it may not retry itself
+			e = errortools.ErrorMessage("no response received")
+			e.SetRequest(request)
+		}
+		if e == nil || request == nil || ctx.Err() != nil {
+			return request, response, e
+		}
+
+		wait, retry := service.retryPolicy.backoff(attempt, method, requestUrl, response)
+		if !retry {
+			return request, response, e
+		}
+
+		event := RetryEvent{
+			Method:  method,
+			Url:     requestUrl,
+			Attempt: attempt,
+			Wait:    wait,
+			Message: e.Message(),
+		}
+		if response != nil {
+			event.StatusCode = response.StatusCode
+		}
+
+		service.logger.Info("retrying request", "method", event.Method, "url", event.Url, "attempt", event.Attempt, "statusCode", event.StatusCode, "wait", event.Wait, "message", event.Message)
+
+		if service.retryPolicy != nil && service.retryPolicy.OnRetry != nil {
+			service.retryPolicy.OnRetry(event)
+		}
+
+		if err := sleep(ctx, wait); err != nil {
+			return request, response, newError(request, err)
+		}
+	}
+}
+
+func (service *Service) tryHttpRequest(ctx context.Context, requestConfig *go_http.RequestConfig) (*http.Request, *http.Response, *errortools.Error) {
 	var request *http.Request
 	var response *http.Response
 	var e *errortools.Error
 
-	// add error model
-	service.errorResponse = &ErrorResponse{}
-	requestConfig.ErrorModel = service.errorResponse
+	if err := ctx.Err(); err != nil {
+		return nil, nil, newError(unsentRequest(requestConfig.Method, requestConfig.Url), err)
+	}
+
+	// add error model, one per request so concurrent requests do not share it
+	errorResponse := ErrorResponse{}
+	requestConfig.ErrorModel = &errorResponse
+
+	header := http.Header{}
+	if requestConfig.NonDefaultHeaders != nil {
+		header = *requestConfig.NonDefaultHeaders
+	}
 
 	if service.authorizationMode == authorizationModeOAuth2 {
-		request, response, e = service.oAuth2Service.HttpRequest(requestConfig)
-	} else {
-		if service.authorizationMode == authorizationModeAccessToken {
-			// add authentication header
-			header := http.Header{}
-			header.Set("Authorization", fmt.Sprintf("Bearer %s", service.accessToken))
-			(*requestConfig).NonDefaultHeaders = &header
-		} else if service.authorizationMode == authorizationModeApiKey {
-			// add Api key
-			_url, err := url.Parse(requestConfig.Url)
-			if err != nil {
-				return nil, nil, errortools.ErrorMessage(err)
-			}
-			query := _url.Query()
-			query.Set("hapikey", service.apiKey)
+		token, e := service.oAuth2Service.ValidateToken()
+		if e != nil {
+			return nil, nil, e
+		}
 
-			(*requestConfig).Url = fmt.Sprintf("%s://%s%s?%s", _url.Scheme, _url.Host, _url.Path, query.Encode())
+		// add authentication header
+		header.Set("Authorization", fmt.Sprintf("Bearer %s", *token.AccessToken))
+	} else if service.authorizationMode == authorizationModeAccessToken {
+		// add authentication header
+		header.Set("Authorization", fmt.Sprintf("Bearer %s", service.accessToken))
+	} else if service.authorizationMode == authorizationModeApiKey {
+		// add Api key
+		_url, err := url.Parse(requestConfig.Url)
+		if err != nil {
+			return nil, nil, errortools.ErrorMessage(err)
 		}
+		query := _url.Query()
+		query.Set("hapikey", service.apiKey)
 
-		request, response, e = service.httpService.HttpRequest(requestConfig)
+		(*requestConfig).Url = fmt.Sprintf("%s://%s%s?%s", _url.Scheme, _url.Host, _url.Path, query.Encode())
 	}
+	(*requestConfig).NonDefaultHeaders = &header
 
+	httpService, e := service.newHttpService(ctx)
 	if e != nil {
-		if response != nil {
-			if response.StatusCode == http.StatusTooManyRequests && !isRetry {
-				remaining := response.Header["X-Hubspot-Ratelimit-Daily-Remaining"]
-
-				if len(remaining) == 1 {
-					if remaining[0] != "0" {
-						// try to catch the per second rate limit, but try this only once (isRetry)
-						fmt.Println("waiting 1 second...")
-						time.Sleep(time.Second)
-
-						return service.tryHttpRequest(requestConfig, true)
-					}
-				}
-			}
-		}
-		if service.errorResponse.Message != "" {
-			e.SetMessage(service.errorResponse.Message)
-		}
+		return nil, nil, e
 	}
 
+	service.requestCount.Add(1)
+	request, response, e = httpService.HttpRequest(requestConfig)
+
 	if e != nil {
+		if err := ctx.Err(); err != nil {
+			return request, response, newError(request, err)
+		}
+
+		if errorResponse.Message != "" {
+			e.SetMessage(errorResponse.Message)
+		}
+
+		if response != nil {
+			withCause(e, request, newApiError(response, &errorResponse))
+		}
+
+		service.setLastErrorResponse(&errorResponse)
+
 		return request, response, e
 	}
 
 	return request, response, nil
 }
 
+// newHttpService returns a go_http.Service whose requests are bound to ctx,
+// so cancelling ctx also aborts a request that is already in flight
+func (service *Service) newHttpService(ctx context.Context) (*go_http.Service, *errortools.Error) {
+	client := *service.httpClient
+	client.Transport = &contextTransport{ctx: ctx, base: service.httpClient.Transport}
+
+	return go_http.NewService(&go_http.ServiceConfig{
+		HttpClient: &client,
+	})
+}
+
+// contextTransport cancels a request when either its own context, which holds the deadline of http.Client.Timeout, or ctx is done
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx, cancelCtx := context.WithCancel(request.Context())
+	stop := context.AfterFunc(t.ctx, cancelCtx)
+	cancel := func() {
+		stop()
+		cancelCtx()
+	}
+
+	response, err := base.RoundTrip(request.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return response, err
+	}
+
+	// the body is read after RoundTrip returns, so the context may only be released once it is closed
+	response.Body = &cancelBody{ReadCloser: response.Body, cancel: cancel}
+
+	return response, nil
+}
+
+// cancelBody calls cancel when the body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel func()
+}
+
+func (body *cancelBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.cancel()
+	return err
+}
+
 func (service *Service) AuthorizeUrl(scope string) string {
 	if service.redirectUrl == nil {
 		return ""
 	}
-	return fmt.Sprintf("https://app-eu1.hubspot.com/oauth/authorize?client_id=%s&redirect_uri=%s&scope=%s", service.clientId, *service.redirectUrl, scope)
+	return fmt.Sprintf("%s/oauth/authorize?client_id=%s&redirect_uri=%s&scope=%s", service.authHost, service.clientId, *service.redirectUrl, scope)
 }
 
 func (service *Service) GetTokenFromCode(r *http.Request) *errortools.Error {
@@ -209,43 +431,47 @@ func (service *Service) GetTokenFromCode(r *http.Request) *errortools.Error {
 }
 
 func (service *Service) urlContacts(path string) string {
-	return fmt.Sprintf("%s/v1/%s", apiUrlContacts, path)
+	return fmt.Sprintf("%s/%s/v1/%s", service.baseUrl, apiPathContacts, path)
+}
+
+func (service *Service) urlEngagements(path string) string {
+	return fmt.Sprintf("%s/%s/v1/%s", service.baseUrl, apiPathEngagements, path)
 }
 
 func (service *Service) urlEvents(path string) string {
-	return fmt.Sprintf("%s/v3/%s", apiUrlEvents, path)
+	return fmt.Sprintf("%s/%s/v3/%s", service.baseUrl, apiPathEvents, path)
 }
 
 func (service *Service) urlFiles(path string) string {
-	return fmt.Sprintf("%s/v3/%s", apiUrlFiles, path)
+	return fmt.Sprintf("%s/%s/v3/%s", service.baseUrl, apiPathFiles, path)
 }
 
 func (service *Service) urlForms(path string) string {
-	return fmt.Sprintf("%s/v2/%s", apiUrlForms, path)
+	return fmt.Sprintf("%s/%s/v2/%s", service.baseUrl, apiPathForms, path)
 }
 
 func (service *Service) urlFormIntegrations(path string) string {
-	return fmt.Sprintf("%s/v1/%s", apiUrlFormIntegrations, path)
+	return fmt.Sprintf("%s/%s/v1/%s", service.baseUrl, apiPathFormIntegrations, path)
 }
 
 func (service *Service) urlCrm(path string) string {
-	return fmt.Sprintf("%s/v3/%s", apiUrlCrm, path)
+	return fmt.Sprintf("%s/%s/v3/%s", service.baseUrl, apiPathCrm, path)
 }
 
 func (service *Service) urlCms(path string) string {
-	return fmt.Sprintf("%s/v3/%s", apiUrlCms, path)
+	return fmt.Sprintf("%s/%s/v3/%s", service.baseUrl, apiPathCms, path)
 }
 
 func (service *Service) urlOAuth(path string) string {
-	return fmt.Sprintf("%s/v1/%s", apiUrlOAuth, path)
+	return fmt.Sprintf("%s/%s/v1/%s", service.baseUrl, apiPathOAuth, path)
 }
 
 func (service *Service) urlAccountInfo(path string) string {
-	return fmt.Sprintf("%s/v3/%s", apiUrlAccountInfo, path)
+	return fmt.Sprintf("%s/%s/v3/%s", service.baseUrl, apiPathAccountInfo, path)
 }
 
 func (service *Service) urlV4(path string) string {
-	return fmt.Sprintf("%s/v4/%s", apiUrlCrm, path)
+	return fmt.Sprintf("%s/%s/v4/%s", service.baseUrl, apiPathCrm, path)
 }
 
 func (service *Service) ApiName() string {
@@ -257,15 +483,28 @@ func (service *Service) ApiKey() string {
 }
 
 func (service *Service) ApiCallCount() int64 {
-	return service.httpService.RequestCount()
+	return service.requestCount.Load()
 }
 
 func (service *Service) ApiReset() {
-	service.httpService.ResetRequestCount()
+	service.requestCount.Store(0)
 }
 
+// ErrorResponse returns the error response of the most recently failed request of any goroutine.
+//
+// Deprecated: with concurrent requests this may belong to another call, use AsError on the returned error instead.
 func (service *Service) ErrorResponse() *ErrorResponse {
-	return service.errorResponse
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	return service.lastErrorResponse
+}
+
+func (service *Service) setLastErrorResponse(errorResponse *ErrorResponse) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	service.lastErrorResponse = errorResponse
 }
 
 type batch struct {
@@ -298,3 +537,62 @@ func (service *Service) batches(totalLength int) []batch {
 
 	return b
 }
+
+// forEachBatch calls send for every batch, with at most batchConcurrency batches in flight.
+// send may only store the results of batch i by index, so the caller can merge them in input order.
+// The first error cancels the other batches and is returned.
+func (service *Service) forEachBatch(ctx context.Context, batches []batch, send func(ctx context.Context, i int, batch batch) *errortools.Error) *errortools.Error {
+	if service.batchConcurrency <= 1 || len(batches) <= 1 {
+		for i, batch := range batches {
+			e := send(ctx, i, batch)
+			if e != nil {
+				return e
+			}
+		}
+		return nil
+	}
+
+	ctx_, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstError *errortools.Error
+
+	semaphore := make(chan struct{}, service.batchConcurrency)
+
+	for i, batch := range batches {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx_.Done():
+		}
+		if ctx_.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			e := send(ctx_, i, batch)
+			if e != nil {
+				once.Do(func() {
+					firstError = e
+					cancel()
+				})
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if firstError != nil {
+		return firstError
+	}
+	if ctx.Err() != nil {
+		return newError(nil, ctx.Err())
+	}
+
+	return nil
+}
@@ -0,0 +1,445 @@
+package hubspot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	errortools "github.com/leapforce-libraries/go_errortools"
+)
+
+// newTestService returns a Service that sends its requests to handler
+func newTestService(t *testing.T, handler http.HandlerFunc, config *ServiceConfig) *Service {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	if config == nil {
+		config = &ServiceConfig{}
+	}
+	config.BearerToken = "token"
+	config.BaseUrl = &server.URL
+	if config.RetryPolicy == nil {
+		config.RetryPolicy = &RetryPolicy{InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+	}
+
+	service, e := NewService(config)
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+
+	return service
+}
+
+func intPointer(i int) *int {
+	return &i
+}
+
+func writeJson(w http.ResponseWriter, statusCode int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write([]byte(body))
+}
+
+func TestServiceConcurrentRequests(t *testing.T) {
+	var attempts sync.Map // object id -> *atomic.Int32
+
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		id := path.Base(r.URL.Path)
+
+		count, _ := attempts.LoadOrStore(id, new(atomic.Int32))
+		attempt := count.(*atomic.Int32).Add(1)
+
+		n, _ := strconv.Atoi(id)
+		switch {
+		case n%3 == 0:
+			writeJson(w, http.StatusBadRequest, fmt.Sprintf(`{"status":"error","message":"invalid %s","category":"VALIDATION_ERROR"}`, id))
+		case n%3 == 1 && attempt == 1:
+			writeJson(w, http.StatusServiceUnavailable, `{"status":"error","message":"unavailable"}`)
+		default:
+			writeJson(w, http.StatusOK, fmt.Sprintf(`{"id":"%s"}`, id))
+		}
+	}, nil)
+
+	contacts := NewObjects[Object](service, ObjectTypeContacts)
+
+	const goroutines = 30
+
+	var wg sync.WaitGroup
+	for i := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			id := strconv.Itoa(i)
+
+			object, e := contacts.Get(&GetObjectConfig{ObjectId: id})
+			service.ErrorResponse()
+
+			if i%3 == 0 {
+				var validationError *ValidationError
+				if !errors.As(AsError(e), &validationError) || validationError.Message != "invalid "+id {
+					t.Errorf("%s: unexpected error %v", id, AsError(e))
+				}
+				if e.Request() == nil || path.Base(e.Request().URL.Path) != id {
+					t.Errorf("%s: error reports request %v", id, e.Request())
+				}
+				return
+			}
+			if e != nil {
+				t.Errorf("%s: %s", id, e.Message())
+				return
+			}
+			if object.Id != id {
+				t.Errorf("%s: got object %s", id, object.Id)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// every third request is retried once
+	if count := service.ApiCallCount(); count != goroutines+goroutines/3 {
+		t.Errorf("expected %d requests, got %d", goroutines+goroutines/3, count)
+	}
+	if service.ErrorResponse() == nil {
+		t.Error("expected the last error response")
+	}
+}
+
+func TestServiceRetries(t *testing.T) {
+	var requests atomic.Int32
+
+	var events []RetryEvent
+	var mutex sync.Mutex
+
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			writeJson(w, http.StatusTooManyRequests, `{"status":"error","message":"too many requests","category":"RATE_LIMITS"}`)
+			return
+		}
+		writeJson(w, http.StatusOK, `{"id":"1"}`)
+	}, &ServiceConfig{RetryPolicy: &RetryPolicy{OnRetry: func(event RetryEvent) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		events = append(events, event)
+	}}})
+
+	object, e := NewObjects[Object](service, ObjectTypeContacts).Get(&GetObjectConfig{ObjectId: "1"})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if object.Id != "1" {
+		t.Errorf("got object %s", object.Id)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 retries, got %d", len(events))
+	}
+	for i, event := range events {
+		if event.Attempt != i+1 || event.StatusCode != http.StatusTooManyRequests || event.Wait != 0 {
+			t.Errorf("unexpected retry event %+v", event)
+		}
+	}
+}
+
+func TestServiceRetriesNonIdempotent(t *testing.T) {
+	var requests atomic.Int32
+
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		writeJson(w, http.StatusServiceUnavailable, `{"status":"error","message":"unavailable"}`)
+	}, nil)
+
+	_, e := NewObjects[Object](service, ObjectTypeContacts).Create(&CreateObjectConfig{Properties: map[string]string{"email": "a@b.c"}})
+	if e == nil {
+		t.Fatal("expected an error")
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected a create not to be retried, got %d requests", requests.Load())
+	}
+}
+
+// handshakeTimeoutTransport fails the first failures requests with a tls handshake timeout, which go_http swallows when it may not retry
+type handshakeTimeoutTransport struct {
+	failures int32
+	requests atomic.Int32
+}
+
+func (transport *handshakeTimeoutTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if transport.requests.Add(1) <= transport.failures {
+		return nil, errors.New("net/http: TLS handshake timeout")
+	}
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func TestServiceRetriesHandshakeTimeout(t *testing.T) {
+	transport := handshakeTimeoutTransport{failures: 2}
+
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusOK, `{"id":"1"}`)
+	}, &ServiceConfig{RoundTripper: &transport})
+
+	object, e := NewObjects[Object](service, ObjectTypeContacts).Get(&GetObjectConfig{ObjectId: "1"})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+	if object.Id != "1" || transport.requests.Load() != 3 {
+		t.Errorf("got object %q after %v requests, want object 1 after 3", object.Id, transport.requests.Load())
+	}
+}
+
+func TestServiceHandshakeTimeoutIsError(t *testing.T) {
+	transport := handshakeTimeoutTransport{failures: math.MaxInt32}
+
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusOK, `{"results":[{"id":"1"}]}`)
+	}, &ServiceConfig{RoundTripper: &transport, RetryPolicy: &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}})
+
+	contacts := NewObjects[Object](service, ObjectTypeContacts)
+
+	if _, e := contacts.Get(&GetObjectConfig{ObjectId: "1"}); e == nil {
+		t.Error("expected an error instead of an empty object")
+	}
+
+	// a page without response must not end the iteration as if it were the last
+	cursor := contacts.NewCursor(nil)
+	for _, err := range contacts.IterFrom(context.Background(), cursor) {
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	if cursor.Done {
+		t.Error("cursor is done after a failed page")
+	}
+	if transport.requests.Load() != 4 {
+		t.Errorf("got %v requests, want 2 attempts for each call", transport.requests.Load())
+	}
+}
+
+// contextOnlyTransport cancels requests by their context only, like most RoundTrippers that wrap another
+type contextOnlyTransport struct{}
+
+func (contextOnlyTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	request = request.Clone(request.Context())
+	request.Cancel = nil
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func TestServiceHttpClientTimeout(t *testing.T) {
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		writeJson(w, http.StatusOK, `{"id":"1"}`)
+	}, &ServiceConfig{HttpClient: &http.Client{Timeout: 50 * time.Millisecond, Transport: contextOnlyTransport{}}, RetryPolicy: &RetryPolicy{MaxAttempts: 1}})
+
+	start := time.Now()
+	_, e := NewObjects[Object](service, ObjectTypeContacts).GetWithContext(context.Background(), &GetObjectConfig{ObjectId: "1"})
+	if e == nil {
+		t.Fatal("expected the client timeout to abort the request")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("request took %v despite the client timeout", elapsed)
+	}
+}
+
+func TestServiceContextCancelsRequest(t *testing.T) {
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		writeJson(w, http.StatusOK, `{"id":"1"}`)
+	}, &ServiceConfig{HttpClient: &http.Client{Timeout: time.Minute}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, e := NewObjects[Object](service, ObjectTypeContacts).GetWithContext(ctx, &GetObjectConfig{ObjectId: "1"})
+	if !errors.Is(AsError(e), context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", AsError(e))
+	}
+}
+
+func TestForEachBatch(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		var body BatchGetObjectsConfig
+		json.NewDecoder(r.Body).Decode(&body)
+
+		var response BatchResponse[Object]
+		for _, input := range body.Inputs {
+			response.Results = append(response.Results, Object{Id: input.Id})
+		}
+		b, _ := json.Marshal(response)
+		writeJson(w, http.StatusOK, string(b))
+	}, &ServiceConfig{BatchConcurrency: intPointer(4)})
+
+	var inputs []BatchGetObjectsInput
+	for i := range 1050 {
+		inputs = append(inputs, BatchGetObjectsInput{Id: strconv.Itoa(i)})
+	}
+
+	// the batches of several calls share the Service
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result, e := service.BatchGetObjectsWithResult(&BatchGetObjectsConfig{ObjectType: string(ObjectTypeContacts), Inputs: inputs})
+			if e != nil {
+				t.Error(e.Message())
+				return
+			}
+			if len(result.Successes) != len(inputs) {
+				t.Errorf("expected %d objects, got %d", len(inputs), len(result.Successes))
+				return
+			}
+			for i, object := range result.Successes {
+				if object.Id != inputs[i].Id {
+					t.Errorf("expected object %s at %d, got %s", inputs[i].Id, i, object.Id)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight.Load() > 3*4 {
+		t.Errorf("expected at most 12 batches in flight, got %d", maxInFlight.Load())
+	}
+}
+
+func TestForEachBatchFirstError(t *testing.T) {
+	service := newTestService(t, nil, &ServiceConfig{BatchConcurrency: intPointer(3)})
+
+	var sent atomic.Int32
+
+	e := service.forEachBatch(context.Background(), service.batches(2000), func(ctx context.Context, i int, batch batch) *errortools.Error {
+		sent.Add(1)
+		if i == 2 {
+			return errortools.ErrorMessage("batch 2 failed")
+		}
+		<-ctx.Done()
+		return newError(nil, ctx.Err())
+	})
+	if e == nil || e.Message() != "batch 2 failed" {
+		t.Fatalf("expected the error of batch 2, got %v", e)
+	}
+	if sent.Load() == 20 {
+		t.Error("expected the error to cancel the remaining batches")
+	}
+}
+
+func TestRateLimiterShared(t *testing.T) {
+	rateLimiter, e := NewRateLimiter(&RateLimiterConfig{Burst: 5, Interval: 100 * time.Millisecond, DailyLimit: 25})
+	if e != nil {
+		t.Fatal(e.Message())
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-HubSpot-RateLimit-Max", "5")
+		w.Header().Set("X-HubSpot-RateLimit-Interval-Milliseconds", "100")
+		writeJson(w, http.StatusOK, `{"id":"1"}`)
+	}
+
+	// two Services of the same app
+	services := []*Service{
+		newTestService(t, handler, &ServiceConfig{RateLimiter: rateLimiter}),
+		newTestService(t, handler, &ServiceConfig{RateLimiter: rateLimiter}),
+	}
+
+	var succeeded, limited atomic.Int32
+
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := range 30 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, e := NewObjects[Object](services[i%2], ObjectTypeContacts).Get(&GetObjectConfig{ObjectId: "1"})
+			switch {
+			case e == nil:
+				succeeded.Add(1)
+			case errors.Is(AsError(e), ErrDailyLimitReached):
+				limited.Add(1)
+			default:
+				t.Error(e.Message())
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded.Load() != 25 || limited.Load() != 5 {
+		t.Errorf("expected 25 requests to succeed and 5 to reach the daily limit, got %d and %d", succeeded.Load(), limited.Load())
+	}
+	// 5 requests at once, the other 20 at 50 per second
+	if elapsed := time.Since(start); elapsed < 350*time.Millisecond {
+		t.Errorf("expected the rate limiter to spread the requests over 400ms, took %s", elapsed)
+	}
+}
+
+func TestRateLimiterDailyReset(t *testing.T) {
+	tests := []struct {
+		name     string
+		location *time.Location
+		reset    string // first of the times below at which the daily limit is reset
+	}{
+		{"utc", nil, "2024-05-02T00:00:00Z"},
+		{"portal", time.FixedZone("EST", -5*60*60), "2024-05-02T05:00:00Z"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rateLimiter, e := NewRateLimiter(&RateLimiterConfig{Burst: 1, DailyLimit: 10, Location: test.location})
+			if e != nil {
+				t.Fatal(e.Message())
+			}
+
+			start, _ := time.Parse(time.RFC3339, "2024-05-01T20:00:00Z")
+			rateLimiter.refill(start)
+			rateLimiter.dailyUsed = 10
+
+			for _, at := range []string{"2024-05-01T23:59:00Z", "2024-05-02T00:00:00Z", "2024-05-02T04:59:00Z", "2024-05-02T05:00:00Z"} {
+				now, _ := time.Parse(time.RFC3339, at)
+				rateLimiter.refill(now)
+
+				reset := rateLimiter.dailyUsed == 0
+				if want := at >= test.reset; reset != want {
+					t.Fatalf("got reset %v at %s, want %v", reset, at, want)
+				}
+				if reset {
+					return
+				}
+			}
+			t.Fatal("expected the daily limit to be reset")
+		})
+	}
+}
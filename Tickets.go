@@ -1,8 +1,10 @@
 package hubspot
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"net/http"
 	"net/url"
 	"strings"
@@ -38,8 +40,27 @@ type ListTicketsConfig struct {
 
 // ListTickets returns all tickets
 func (service *Service) ListTickets(config *ListTicketsConfig) (*[]Ticket, *errortools.Error) {
+	return service.ListTicketsWithContext(context.Background(), config)
+}
+
+func (service *Service) ListTicketsWithContext(ctx context.Context, config *ListTicketsConfig) (*[]Ticket, *errortools.Error) {
+	return collect(service.iterTickets(ctx, config, config != nil && config.After != nil))
+}
+
+// IterTickets streams all tickets page by page, starting at config.After
+func (service *Service) IterTickets(ctx context.Context, config *ListTicketsConfig) iter.Seq2[Ticket, error] {
+	return iterErrors(service.iterTickets(ctx, config, false))
+}
+
+func (service *Service) iterTickets(ctx context.Context, config *ListTicketsConfig, singlePage bool) iter.Seq2[Ticket, *errortools.Error] {
+	return func(yield func(Ticket, *errortools.Error) bool) {
+		service.iterTicketsFrom(ctx, NewTicketsCursor(config), singlePage)(yield)
+	}
+}
+
+// NewTicketsCursor returns a cursor at the start of ListTickets, or at config.After
+func NewTicketsCursor(config *ListTicketsConfig) *Cursor {
 	values := url.Values{}
-	endpoint := "objects/tickets"
 	after := ""
 
 	if config != nil {
@@ -73,46 +94,25 @@ func (service *Service) ListTickets(config *ListTicketsConfig) (*[]Ticket, *erro
 		}
 	}
 
-	var tickets []Ticket
-
-	for {
-		ticketsResponse := TicketsResponse{}
-
-		if after != "" {
-			values.Set("after", after)
-		}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodGet,
-			Url:           service.urlCrm(fmt.Sprintf("%s?%s", endpoint, values.Encode())),
-			ResponseModel: &ticketsResponse,
-		}
-
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
-
-		tickets = append(tickets, ticketsResponse.Results...)
-
-		if config != nil {
-			if config.After != nil { // explicit after parameter requested
-				break
-			}
-		}
-
-		if ticketsResponse.Paging == nil {
-			break
-		}
+	return &Cursor{ObjectType: string(ObjectTypeTickets), Query: values, After: after}
+}
 
-		if ticketsResponse.Paging.Next.After == "" {
-			break
-		}
+// IterTicketsFrom resumes IterTickets at cursor
+func (service *Service) IterTicketsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[Ticket, error] {
+	return iterErrors(service.iterTicketsFrom(ctx, cursor, false))
+}
 
-		after = ticketsResponse.Paging.Next.After
+func (service *Service) iterTicketsFrom(ctx context.Context, cursor *Cursor, singlePage bool) iter.Seq2[Ticket, *errortools.Error] {
+	if e := cursor.check(string(ObjectTypeTickets)); e != nil {
+		return iterError[Ticket](e)
 	}
 
-	return &tickets, nil
+	return iterPages[Ticket](ctx, service, cursor, singlePage, func(after string) go_http.RequestConfig {
+		return go_http.RequestConfig{
+			Method: http.MethodGet,
+			Url:    service.urlCrm(fmt.Sprintf("objects/%s?%s", cursor.ObjectType, encodeWithParam(cursor.Query, "after", after))),
+		}
+	})
 }
 
 type CreateTicketConfig struct {
@@ -121,6 +121,10 @@ type CreateTicketConfig struct {
 }
 
 func (service *Service) CreateTicket(config *CreateTicketConfig) (*Ticket, *errortools.Error) {
+	return service.CreateTicketWithContext(context.Background(), config)
+}
+
+func (service *Service) CreateTicketWithContext(ctx context.Context, config *CreateTicketConfig) (*Ticket, *errortools.Error) {
 	endpoint := "objects/tickets"
 	ticket := Ticket{}
 
@@ -131,7 +135,7 @@ func (service *Service) CreateTicket(config *CreateTicketConfig) (*Ticket, *erro
 		ResponseModel: &ticket,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -145,6 +149,10 @@ type UpdateTicketConfig struct {
 }
 
 func (service *Service) UpdateTicket(config *UpdateTicketConfig) (*Ticket, *errortools.Error) {
+	return service.UpdateTicketWithContext(context.Background(), config)
+}
+
+func (service *Service) UpdateTicketWithContext(ctx context.Context, config *UpdateTicketConfig) (*Ticket, *errortools.Error) {
 	endpoint := "objects/tickets"
 	ticket := Ticket{}
 
@@ -169,7 +177,7 @@ func (service *Service) UpdateTicket(config *UpdateTicketConfig) (*Ticket, *erro
 		ResponseModel: &ticket,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	if e != nil {
 		return nil, e
 	}
@@ -178,16 +186,20 @@ func (service *Service) UpdateTicket(config *UpdateTicketConfig) (*Ticket, *erro
 }
 
 func (service *Service) BatchArchiveTickets(ticketIds []string) *errortools.Error {
+	return service.BatchArchiveTicketsWithContext(context.Background(), ticketIds)
+}
+
+func (service *Service) BatchArchiveTicketsWithContext(ctx context.Context, ticketIds []string) *errortools.Error {
 	var maxItemsPerBatch = 100
 	var index = 0
 	for len(ticketIds) > index {
 		if len(ticketIds) > index+maxItemsPerBatch {
-			e := service.batchArchiveTickets(ticketIds[index : index+maxItemsPerBatch])
+			e := service.batchArchiveTickets(ctx, ticketIds[index:index+maxItemsPerBatch])
 			if e != nil {
 				return e
 			}
 		} else {
-			e := service.batchArchiveTickets(ticketIds[index:])
+			e := service.batchArchiveTickets(ctx, ticketIds[index:])
 			if e != nil {
 				return e
 			}
@@ -199,7 +211,7 @@ func (service *Service) BatchArchiveTickets(ticketIds []string) *errortools.Erro
 	return nil
 }
 
-func (service *Service) batchArchiveTickets(ticketIds []string) *errortools.Error {
+func (service *Service) batchArchiveTickets(ctx context.Context, ticketIds []string) *errortools.Error {
 	var body struct {
 		Inputs []struct {
 			Id string `json:"id"`
@@ -218,71 +230,44 @@ func (service *Service) batchArchiveTickets(ticketIds []string) *errortools.Erro
 		BodyModel: body,
 	}
 
-	_, _, e := service.httpRequest(&requestConfig)
+	_, _, e := service.httpRequest(ctx, &requestConfig)
 	return e
 }
 
 // SearchTickets returns a specific ticket
 func (service *Service) SearchTickets(config *SearchObjectsConfig) (*[]Ticket, *errortools.Error) {
-	if config == nil {
-		return nil, errortools.ErrorMessage("Config is nil")
-	}
-
-	endpoint := "objects/tickets/search"
-
-	ticketsResponse := TicketsResponse{}
-
-	requestConfig := go_http.RequestConfig{
-		Method:        http.MethodPost,
-		Url:           service.urlCrm(endpoint),
-		BodyModel:     config,
-		ResponseModel: &ticketsResponse,
-	}
-
-	_, _, e := service.httpRequest(&requestConfig)
-	if e != nil {
-		return nil, e
-	}
-
-	after := config.After
-
-	var tickets []Ticket
-
-	for {
-		ticketsResponse := TicketsResponse{}
-
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(endpoint),
-			BodyModel:     config,
-			ResponseModel: &ticketsResponse,
-		}
-
-		_, _, e := service.httpRequest(&requestConfig)
-		if e != nil {
-			return nil, e
-		}
-
-		tickets = append(tickets, ticketsResponse.Results...)
+	return service.SearchTicketsWithContext(context.Background(), config)
+}
 
-		if after != nil { // explicit after parameter requested
-			break
-		}
+func (service *Service) SearchTicketsWithContext(ctx context.Context, config *SearchObjectsConfig) (*[]Ticket, *errortools.Error) {
+	return collect(iterSearch[Ticket](ctx, service, NewSearchCursor(ObjectTypeTickets, config), ObjectTypeTickets, config != nil && config.After != nil))
+}
 
-		if ticketsResponse.Paging == nil {
-			break
-		}
+// SearchTicketsWithTotal returns the tickets matching config and the total HubSpot reports for the search
+func (service *Service) SearchTicketsWithTotal(config *SearchObjectsConfig) (*SearchResult[Ticket], *errortools.Error) {
+	return service.SearchTicketsWithTotalWithContext(context.Background(), config)
+}
 
-		if ticketsResponse.Paging.Next.After == "" {
-			break
-		}
+func (service *Service) SearchTicketsWithTotalWithContext(ctx context.Context, config *SearchObjectsConfig) (*SearchResult[Ticket], *errortools.Error) {
+	cursor := NewSearchCursor(ObjectTypeTickets, config)
+	return collectSearch(cursor, iterSearch[Ticket](ctx, service, cursor, ObjectTypeTickets, config != nil && config.After != nil))
+}
 
-		config.After = &ticketsResponse.Paging.Next.After
-	}
+// IterSearchTickets streams all tickets matching config page by page, starting at config.After
+func (service *Service) IterSearchTickets(ctx context.Context, config *SearchObjectsConfig) iter.Seq2[Ticket, error] {
+	return iterErrors(func(yield func(Ticket, *errortools.Error) bool) {
+		iterSearch[Ticket](ctx, service, NewSearchCursor(ObjectTypeTickets, config), ObjectTypeTickets, false)(yield)
+	})
+}
 
-	return &tickets, nil
+// IterSearchTicketsFrom resumes IterSearchTickets at a cursor created by NewSearchCursor
+func (service *Service) IterSearchTicketsFrom(ctx context.Context, cursor *Cursor) iter.Seq2[Ticket, error] {
+	return iterErrors(iterSearch[Ticket](ctx, service, cursor, ObjectTypeTickets, false))
 }
 
+// BatchTicketsResponse is the response of a batch request of tickets
+//
+// Deprecated: the batch functions return a BatchResult
 type BatchTicketsResponse struct {
 	CompletedAt *time.Time        `json:"completedAt"`
 	NumErrors   int               `json:"numErrors"`
@@ -311,66 +296,50 @@ type BatchTicketsResponse struct {
 	Status string `json:"status"`
 }
 
+// BatchCreateTickets returns the created tickets, the inputs that failed are only logged
+//
+// Deprecated: use BatchCreateTicketsWithResult, which also returns the inputs that failed
 func (service *Service) BatchCreateTickets(config *BatchObjectsConfig) (*[]Ticket, *errortools.Error) {
-	var tickets []Ticket
-
-	for _, batch := range service.batches(len(config.Inputs)) {
-		var r BatchTicketsResponse
+	result, e := service.BatchCreateTicketsWithResultWithContext(context.Background(), config)
+	if e != nil {
+		return nil, e
+	}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(fmt.Sprintf("objects/%s/batch/create", config.ObjectType)),
-			BodyModel:     BatchObjectsConfig{Inputs: config.Inputs[batch.startIndex:batch.endIndex]},
-			ResponseModel: &r,
-		}
+	return &result.Successes, nil
+}
 
-		_, response, e := service.httpRequest(&requestConfig)
-		if response != nil {
-			if response.StatusCode == http.StatusMultiStatus {
-				fmt.Println(r.Errors)
-				goto ok
-			}
-		}
-		if e != nil {
-			return nil, e
-		}
-	ok:
-		tickets = append(tickets, r.Results...)
+func (service *Service) BatchCreateTicketsWithResult(config *BatchObjectsConfig) (*BatchResult[Ticket], *errortools.Error) {
+	return service.BatchCreateTicketsWithResultWithContext(context.Background(), config)
+}
 
-		fmt.Println("batch", batch.startIndex)
+func (service *Service) BatchCreateTicketsWithResultWithContext(ctx context.Context, config *BatchObjectsConfig) (*BatchResult[Ticket], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
 	}
 
-	return &tickets, nil
+	return batchObjects[Ticket](ctx, service, ObjectType(config.ObjectType), "create", config)
 }
 
+// BatchUpdateTickets returns the updated tickets, the inputs that failed are only logged
+//
+// Deprecated: use BatchUpdateTicketsWithResult, which also returns the inputs that failed
 func (service *Service) BatchUpdateTickets(config *BatchObjectsConfig) (*[]Ticket, *errortools.Error) {
-	var tickets []Ticket
-
-	for _, batch := range service.batches(len(config.Inputs)) {
-		var r BatchTicketsResponse
+	result, e := service.BatchUpdateTicketsWithResultWithContext(context.Background(), config)
+	if e != nil {
+		return nil, e
+	}
 
-		requestConfig := go_http.RequestConfig{
-			Method:        http.MethodPost,
-			Url:           service.urlCrm(fmt.Sprintf("objects/%s/batch/update", config.ObjectType)),
-			BodyModel:     BatchObjectsConfig{Inputs: config.Inputs[batch.startIndex:batch.endIndex]},
-			ResponseModel: &r,
-		}
+	return &result.Successes, nil
+}
 
-		_, response, e := service.httpRequest(&requestConfig)
-		if response != nil {
-			if response.StatusCode == http.StatusMultiStatus {
-				fmt.Println(r.Errors)
-				goto ok
-			}
-		}
-		if e != nil {
-			return nil, e
-		}
-	ok:
-		tickets = append(tickets, r.Results...)
+func (service *Service) BatchUpdateTicketsWithResult(config *BatchObjectsConfig) (*BatchResult[Ticket], *errortools.Error) {
+	return service.BatchUpdateTicketsWithResultWithContext(context.Background(), config)
+}
 
-		fmt.Println("batch", batch.startIndex)
+func (service *Service) BatchUpdateTicketsWithResultWithContext(ctx context.Context, config *BatchObjectsConfig) (*BatchResult[Ticket], *errortools.Error) {
+	if config == nil {
+		return nil, errortools.ErrorMessage("config is nil")
 	}
 
-	return &tickets, nil
+	return batchObjects[Ticket](ctx, service, ObjectType(config.ObjectType), "update", config)
 }